@@ -0,0 +1,61 @@
+// imagemeta 为升级前上传的图片补充 MIME 类型、尺寸、大小、主色和占位符，在项目根目录运行：
+//
+//	go run ./cmd/imagemeta
+//	go run ./cmd/imagemeta -all   # 重新计算全部图片
+//
+// 原图从 STORAGE_DRIVER 配置的存储中读取，使用 Redis 缓存时同时清除受影响文章的缓存
+package main
+
+import (
+	"cms/config"
+	"cms/services"
+	"cms/utils"
+	"cms/utils/cache"
+	"cms/utils/storage"
+	"flag"
+	"fmt"
+	"log"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	all := flag.Bool("all", false, "重新计算全部图片，默认只处理缺少信息的图片")
+	flag.Parse()
+
+	db, err := utils.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	imageConfig, err := config.NewImageConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storageConfig, err := config.NewStorageConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fileStorage, err := storage.New(storageConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cacheConfig, err := config.NewCacheConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	appCache, err := cache.New(cacheConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	imageService, err := services.NewImageService(db, imageConfig, fileStorage, appCache)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	updated, err := imageService.BackfillMetadata(*all)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("已更新 %d 张图片\n", updated)
+}
@@ -0,0 +1,345 @@
+// mockidp 用于本地调试单点登录的模拟 OpenID Connect 身份提供方，数据只保存在内存中。
+//
+//	go run ./cmd/mockidp -addr :9000 -client-id cms -client-secret cms-secret
+//
+// 授权页面列出全部测试用户，点击即可登录；带上 login_hint=用户名 时直接登录，便于脚本测试
+package main
+
+import (
+	"cms/utils/keys"
+	"cms/utils/oidc"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type (
+	// user 测试用户
+	user struct {
+		Subject  string   `json:"sub"`
+		Username string   `json:"preferred_username"`
+		Name     string   `json:"name"`
+		Email    string   `json:"email"`
+		Phone    string   `json:"phone_number,omitempty"`
+		Groups   []string `json:"groups"`
+	}
+
+	// grant 已签发的授权码
+	grant struct {
+		user        *user
+		clientID    string
+		redirectURI string
+		challenge   string
+		nonce       string
+		expiresAt   time.Time
+	}
+
+	server struct {
+		issuer       string
+		clientID     string
+		clientSecret string
+		redirectURI  string
+		users        []*user
+		key          *keys.Key
+
+		mu     sync.Mutex
+		grants map[string]*grant
+		tokens map[string]*user
+	}
+)
+
+var defaultUsers = []*user{
+	{Subject: "1001", Username: "alice", Name: "Alice", Email: "alice@example.com", Groups: []string{"cms-editors"}},
+	{Subject: "1002", Username: "bob", Name: "Bob", Email: "bob@example.com", Groups: []string{"cms-reviewers"}},
+	{Subject: "1003", Username: "carol", Name: "Carol", Email: "carol@example.com", Groups: []string{}},
+}
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Mock IdP</title></head>
+<body>
+<h1>选择登录用户</h1>
+<form method="post" action="/authorize">
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">
+{{end}}{{range .Users}}<p><button type="submit" name="login_hint" value="{{.Username}}">{{.Name}} ({{.Username}}) {{.Groups}}</button></p>
+{{end}}<p><button type="submit" name="deny" value="1">拒绝</button></p>
+</form>
+</body>
+</html>
+`))
+
+func main() {
+	addr := flag.String("addr", ":9000", "监听地址")
+	issuer := flag.String("issuer", "http://localhost:9000", "签发方地址，需要与 OIDC_ISSUER 一致")
+	clientID := flag.String("client-id", "cms", "客户端 ID")
+	clientSecret := flag.String("client-secret", "cms-secret", "客户端密钥，为空时作为公开客户端")
+	redirectURI := flag.String("redirect-uri", "", "允许的回调地址，为空时不检查")
+	usersPath := flag.String("users", "", "测试用户 JSON 文件，为空时使用内置用户")
+	flag.Parse()
+
+	users := defaultUsers
+	if *usersPath != "" {
+		data, err := os.ReadFile(*usersPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &users); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	key, err := keys.Generate(2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := newServer(*issuer, *clientID, *clientSecret, *redirectURI, users, key)
+	app := s.app()
+
+	fmt.Printf("模拟身份提供方启动成功，签发方: %s\n", s.issuer)
+	log.Fatal(app.Listen(*addr))
+}
+
+func newServer(issuer, clientID, clientSecret, redirectURI string, users []*user, key *keys.Key) *server {
+	return &server{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURI:  redirectURI,
+		users:        users,
+		key:          key,
+		grants:       make(map[string]*grant),
+		tokens:       make(map[string]*user),
+	}
+}
+
+func (s *server) app() *fiber.App {
+	app := fiber.New(fiber.Config{AppName: "mock idp"})
+	app.Get("/.well-known/openid-configuration", s.discovery)
+	app.Get("/jwks", s.jwks)
+	app.Get("/authorize", s.authorize)
+	app.Post("/authorize", s.authorize)
+	app.Post("/token", s.token)
+	app.Get("/userinfo", s.userinfo)
+	return app
+}
+
+func (s *server) discovery(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"userinfo_endpoint":                     s.issuer + "/userinfo",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "phone", "groups"},
+	})
+}
+
+func (s *server) jwks(c *fiber.Ctx) error {
+	return c.JSON(keys.NewJWKS("RS256", []*keys.Key{s.key}))
+}
+
+// authorize 授权端点，GET 显示用户列表，POST 为表单提交
+func (s *server) authorize(c *fiber.Ctx) error {
+	params := url.Values{}
+	for _, name := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		if value := c.FormValue(name, c.Query(name)); value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	if params.Get("client_id") != s.clientID {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid client_id")
+	}
+	redirectURI := params.Get("redirect_uri")
+	if redirectURI == "" || (s.redirectURI != "" && redirectURI != s.redirectURI) {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid redirect_uri")
+	}
+
+	// 以下错误通过回调地址返回给客户端
+	callback := func(values url.Values) error {
+		if state := params.Get("state"); state != "" {
+			values.Set("state", state)
+		}
+		sep := "?"
+		if strings.Contains(redirectURI, "?") {
+			sep = "&"
+		}
+		return c.Redirect(redirectURI+sep+values.Encode(), fiber.StatusFound)
+	}
+	if params.Get("response_type") != "code" {
+		return callback(url.Values{"error": {"unsupported_response_type"}})
+	}
+	if params.Get("code_challenge") == "" || params.Get("code_challenge_method") != "S256" {
+		return callback(url.Values{"error": {"invalid_request"}, "error_description": {"PKCE S256 is required"}})
+	}
+	if c.FormValue("deny") != "" {
+		return callback(url.Values{"error": {"access_denied"}})
+	}
+
+	hint := c.FormValue("login_hint", c.Query("login_hint"))
+	if hint == "" {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return authorizeTemplate.Execute(c, fiber.Map{"Params": params, "Users": s.users})
+	}
+
+	u := s.findUser(hint)
+	if u == nil {
+		return callback(url.Values{"error": {"access_denied"}, "error_description": {"unknown user"}})
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.grants[code] = &grant{
+		user:        u,
+		clientID:    s.clientID,
+		redirectURI: redirectURI,
+		challenge:   params.Get("code_challenge"),
+		nonce:       params.Get("nonce"),
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	return callback(url.Values{"code": {code}})
+}
+
+// token 令牌端点，只支持授权码模式
+func (s *server) token(c *fiber.Ctx) error {
+	clientID, clientSecret := c.FormValue("client_id"), c.FormValue("client_secret")
+	if id, secret, ok := basicAuth(c.Get(fiber.HeaderAuthorization)); ok {
+		clientID, clientSecret = id, secret
+	}
+	if clientID != s.clientID || clientSecret != s.clientSecret {
+		return tokenError(c, fiber.StatusUnauthorized, "invalid_client")
+	}
+	if c.FormValue("grant_type") != "authorization_code" {
+		return tokenError(c, fiber.StatusBadRequest, "unsupported_grant_type")
+	}
+
+	// 授权码只能使用一次
+	s.mu.Lock()
+	g, ok := s.grants[c.FormValue("code")]
+	delete(s.grants, c.FormValue("code"))
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(g.expiresAt) || g.redirectURI != c.FormValue("redirect_uri") {
+		return tokenError(c, fiber.StatusBadRequest, "invalid_grant")
+	}
+	if oidc.CodeChallenge(c.FormValue("code_verifier")) != g.challenge {
+		return tokenError(c, fiber.StatusBadRequest, "invalid_grant")
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.issuer,
+		"sub":                g.user.Subject,
+		"aud":                g.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              g.nonce,
+		"name":               g.user.Name,
+		"preferred_username": g.user.Username,
+		"email":              g.user.Email,
+		"email_verified":     g.user.Email != "",
+		"groups":             g.user.Groups,
+	}
+	if g.user.Phone != "" {
+		claims["phone_number"] = g.user.Phone
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.key.ID
+	idToken, err := token.SignedString(s.key.Private)
+	if err != nil {
+		return tokenError(c, fiber.StatusInternalServerError, "server_error")
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.tokens[accessToken] = g.user
+	s.mu.Unlock()
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (s *server) userinfo(c *fiber.Ctx) error {
+	accessToken, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	s.mu.Lock()
+	u := s.tokens[accessToken]
+	s.mu.Unlock()
+
+	if !ok || u == nil {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	return c.JSON(fiber.Map{
+		"sub":                u.Subject,
+		"name":               u.Name,
+		"preferred_username": u.Username,
+		"email":              u.Email,
+		"email_verified":     u.Email != "",
+		"groups":             u.Groups,
+	})
+}
+
+func (s *server) findUser(username string) *user {
+	for _, u := range s.users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+// basicAuth 解析 client_secret_basic，客户端 ID 和密钥经过表单编码
+func basicAuth(header string) (string, string, bool) {
+	encoded, ok := strings.CutPrefix(header, "Basic ")
+	if !ok {
+		return "", "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return "", "", false
+	}
+	id, err1 := url.QueryUnescape(id)
+	secret, err2 := url.QueryUnescape(secret)
+	return id, secret, err1 == nil && err2 == nil
+}
+
+func tokenError(c *fiber.Ctx, status int, code string) error {
+	return c.Status(status).JSON(fiber.Map{"error": code})
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
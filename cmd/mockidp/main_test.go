@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cms/utils/keys"
+	"cms/utils/oidc"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+const (
+	testClientID     = "cms"
+	testClientSecret = "cms-secret"
+	testRedirectURL  = "http://localhost:8002/api/common/account/oidc/callback"
+)
+
+// startServer 在随机端口启动模拟身份提供方，返回签发方地址
+func startServer(t *testing.T) string {
+	t.Helper()
+	key, err := keys.Generate(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := "http://" + ln.Addr().String()
+
+	app := newServer(issuer, testClientID, testClientSecret, testRedirectURL, defaultUsers, key).app()
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return issuer
+}
+
+func newProvider(issuer, clientSecret string) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       issuer,
+		ClientID:     testClientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "profile", "email", "groups"},
+	})
+}
+
+// authorize 带上 login_hint 访问授权地址，返回回调地址中的参数
+func authorize(t *testing.T, p *oidc.Provider, username, state, nonce, verifier string) url.Values {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL + "&login_hint=" + url.QueryEscape(username))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURL {
+		t.Fatalf("redirect to %s, want %s", got, testRedirectURL)
+	}
+	return location.Query()
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	p := newProvider(startServer(t), testClientSecret)
+
+	query := authorize(t, p, "alice", "state-1", "nonce-1", "verifier-1")
+	if query.Get("state") != "state-1" || query.Get("code") == "" {
+		t.Fatalf("callback query = %v", query)
+	}
+
+	token, err := p.Exchange(ctx, query.Get("code"), "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.String("sub") != "1001" || claims.String("preferred_username") != "alice" ||
+		claims.String("email") != "alice@example.com" || !claims.Bool("email_verified") ||
+		!slices.Equal(claims.Strings("groups"), []string{"cms-editors"}) {
+		t.Errorf("ID token claims = %v", claims)
+	}
+
+	info, err := p.UserInfo(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("UserInfo: %v", err)
+	}
+	if info.String("sub") != "1001" || info.String("name") != "Alice" {
+		t.Errorf("userinfo = %v", info)
+	}
+
+	// 授权码只能使用一次
+	if _, err := p.Exchange(ctx, query.Get("code"), "verifier-1"); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("reuse code error = %v, want ErrExchange", err)
+	}
+	if _, err := p.UserInfo(ctx, "invalid"); !errors.Is(err, oidc.ErrUserInfo) {
+		t.Errorf("UserInfo with invalid token error = %v, want ErrUserInfo", err)
+	}
+}
+
+func TestAuthorizationCodeFlowErrors(t *testing.T) {
+	ctx := context.Background()
+	issuer := startServer(t)
+	p := newProvider(issuer, testClientSecret)
+
+	tests := []struct {
+		name      string
+		provider  *oidc.Provider
+		verifier  string
+		nonce     string
+		wantError error
+	}{
+		{"wrong code verifier", p, "other-verifier", "nonce", oidc.ErrExchange},
+		{"wrong client secret", newProvider(issuer, "wrong-secret"), "verifier", "nonce", oidc.ErrExchange},
+		{"wrong nonce", p, "verifier", "other-nonce", oidc.ErrIDTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := authorize(t, tt.provider, "bob", "state", "nonce", "verifier")
+			token, err := tt.provider.Exchange(ctx, query.Get("code"), tt.verifier)
+			if err == nil {
+				_, err = tt.provider.VerifyIDToken(ctx, token.IDToken, tt.nonce)
+			}
+			if !errors.Is(err, tt.wantError) {
+				t.Errorf("error = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+
+	query := authorize(t, p, "nobody", "state", "nonce", "verifier")
+	if query.Get("error") != "access_denied" || query.Get("state") != "state" {
+		t.Errorf("unknown user callback query = %v", query)
+	}
+}
@@ -0,0 +1,252 @@
+// mocks3 用于本地调试对象存储的模拟 S3 服务，只支持路径形式的地址和单个对象的上传、下载、删除，数据只保存在内存中。
+//
+//	go run ./cmd/mocks3 -addr :9001 -bucket cms -access-key cms -secret-key cms-secret
+//
+// 请求和签名链接都按 Signature Version 4 校验，与 STORAGE_S3_PATH_STYLE=true 配合使用
+package main
+
+import (
+	"cms/utils/storage"
+	"crypto/subtle"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// 请求时间与服务器时间允许的误差
+const maxClockSkew = 15 * time.Minute
+
+type (
+	// object 保存的对象
+	object struct {
+		data        []byte
+		contentType string
+		modTime     time.Time
+	}
+
+	server struct {
+		signer storage.SigV4
+
+		mu      sync.RWMutex
+		buckets map[string]map[string]*object
+	}
+
+	// s3Error 与 S3 相同格式的错误
+	s3Error struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string   `xml:"Code"`
+		Message  string   `xml:"Message"`
+		Resource string   `xml:"Resource"`
+	}
+)
+
+func main() {
+	addr := flag.String("addr", ":9001", "监听地址")
+	region := flag.String("region", "us-east-1", "区域，需要与 STORAGE_S3_REGION 一致")
+	buckets := flag.String("bucket", "cms", "存储桶，多个用逗号分隔")
+	accessKey := flag.String("access-key", "cms", "访问密钥 ID")
+	secretKey := flag.String("secret-key", "cms-secret", "访问密钥")
+	flag.Parse()
+
+	s := newServer(storage.SigV4{AccessKey: *accessKey, SecretKey: *secretKey, Region: *region}, strings.Split(*buckets, ","))
+	app := s.app()
+
+	fmt.Printf("模拟 S3 服务启动成功，存储桶: %s\n", *buckets)
+	log.Fatal(app.Listen(*addr))
+}
+
+func newServer(signer storage.SigV4, buckets []string) *server {
+	s := &server{
+		signer:  signer,
+		buckets: make(map[string]map[string]*object),
+	}
+	for _, bucket := range buckets {
+		if bucket = strings.TrimSpace(bucket); bucket != "" {
+			s.buckets[bucket] = make(map[string]*object)
+		}
+	}
+	return s
+}
+
+func (s *server) app() *fiber.App {
+	app := fiber.New(fiber.Config{AppName: "mock s3", BodyLimit: 100 << 20})
+	// Get 同时处理 HEAD
+	app.Get("/:bucket/*", s.getObject)
+	app.Put("/:bucket/*", s.putObject)
+	app.Delete("/:bucket/*", s.deleteObject)
+	return app
+}
+
+// objects 校验签名并返回存储桶和对象名，失败时已写入错误响应
+func (s *server) objects(c *fiber.Ctx) (map[string]*object, string, bool) {
+	if code, message := s.verify(c); code != "" {
+		sendError(c, fiber.StatusForbidden, code, message)
+		return nil, "", false
+	}
+
+	s.mu.RLock()
+	bucket, ok := s.buckets[c.Params("bucket")]
+	s.mu.RUnlock()
+	if !ok {
+		sendError(c, fiber.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return nil, "", false
+	}
+
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil || key == "" {
+		sendError(c, fiber.StatusBadRequest, "InvalidURI", "Couldn't parse the specified URI")
+		return nil, "", false
+	}
+	return bucket, key, true
+}
+
+func (s *server) getObject(c *fiber.Ctx) error {
+	bucket, key, ok := s.objects(c)
+	if !ok {
+		return nil
+	}
+
+	s.mu.RLock()
+	obj := bucket[key]
+	s.mu.RUnlock()
+	if obj == nil {
+		return sendError(c, fiber.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+	}
+
+	c.Set(fiber.HeaderContentType, obj.contentType)
+	c.Set(fiber.HeaderLastModified, obj.modTime.UTC().Format(http.TimeFormat))
+	if disposition := c.Query("response-content-disposition"); disposition != "" {
+		c.Set(fiber.HeaderContentDisposition, disposition)
+	}
+	return c.Send(obj.data)
+}
+
+func (s *server) putObject(c *fiber.Ctx) error {
+	bucket, key, ok := s.objects(c)
+	if !ok {
+		return nil
+	}
+
+	// 签名中的内容哈希需要与实际内容一致
+	body := c.Body()
+	if hash := c.Get("X-Amz-Content-Sha256"); hash != storage.UnsignedPayload && hash != storage.HashHex(body) {
+		return sendError(c, fiber.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed")
+	}
+
+	contentType := c.Get(fiber.HeaderContentType)
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+
+	s.mu.Lock()
+	bucket[key] = &object{data: append([]byte(nil), body...), contentType: contentType, modTime: time.Now()}
+	s.mu.Unlock()
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (s *server) deleteObject(c *fiber.Ctx) error {
+	bucket, key, ok := s.objects(c)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	delete(bucket, key)
+	s.mu.Unlock()
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// verify 校验 Authorization 请求头或签名链接，成功时返回空字符串
+func (s *server) verify(c *fiber.Ctx) (string, string) {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return "AuthorizationQueryParametersError", "Invalid query string"
+	}
+
+	header := http.Header{}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	header.Set("Host", c.Get(fiber.HeaderHost))
+
+	var credential, amzDate, signature, payloadHash string
+	var signedHeaders []string
+	if auth := c.Get(fiber.HeaderAuthorization); auth != "" {
+		params, ok := strings.CutPrefix(auth, storage.SigV4Algorithm+" ")
+		if !ok {
+			return "AccessDenied", "Unsupported authorization type"
+		}
+		for _, part := range strings.Split(params, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = strings.Split(value, ";")
+			case "Signature":
+				signature = value
+			}
+		}
+		amzDate = c.Get("X-Amz-Date")
+		payloadHash = c.Get("X-Amz-Content-Sha256")
+	} else {
+		if query.Get("X-Amz-Algorithm") != storage.SigV4Algorithm {
+			return "AccessDenied", "Request is not signed"
+		}
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		signature = query.Get("X-Amz-Signature")
+		amzDate = query.Get("X-Amz-Date")
+		payloadHash = storage.UnsignedPayload
+		query.Del("X-Amz-Signature")
+	}
+
+	t, err := time.Parse(storage.SigV4TimeFormat, amzDate)
+	if err != nil {
+		return "AccessDenied", "Invalid X-Amz-Date"
+	}
+	if expires := query.Get("X-Amz-Expires"); expires != "" && c.Get(fiber.HeaderAuthorization) == "" {
+		seconds, err := strconv.Atoi(expires)
+		if err != nil || time.Now().After(t.Add(time.Duration(seconds)*time.Second)) {
+			return "AccessDenied", "Request has expired"
+		}
+	} else if d := time.Since(t); d > maxClockSkew || d < -maxClockSkew {
+		return "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large"
+	}
+
+	if credential != s.signer.AccessKey+"/"+s.signer.Scope(t) {
+		return "InvalidAccessKeyId", "The access key or credential scope is invalid"
+	}
+
+	path := string(c.Request().URI().PathOriginal())
+	expected := s.signer.Signature(c.Method(), path, query, header, signedHeaders, payloadHash, t)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided"
+	}
+	return "", ""
+}
+
+func sendError(c *fiber.Ctx, status int, code, message string) error {
+	c.Status(status)
+	// HEAD 请求没有响应体
+	if c.Method() == fiber.MethodHead {
+		return nil
+	}
+	data, err := xml.Marshal(s3Error{Code: code, Message: message, Resource: c.Path()})
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Send(append([]byte(xml.Header), data...))
+}
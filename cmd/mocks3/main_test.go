@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"cms/utils/storage"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testSigner = storage.SigV4{AccessKey: "cms", SecretKey: "cms-secret", Region: "us-east-1"}
+
+// startServer 在随机端口启动模拟服务，返回地址
+func startServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newServer(testSigner, []string{"cms"}).app()
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+func newStorage(t *testing.T, endpoint, bucket, secretKey string) storage.Storage {
+	t.Helper()
+	s, err := storage.NewS3Storage(storage.S3Options{
+		Endpoint:  endpoint,
+		Region:    testSigner.Region,
+		Bucket:    bucket,
+		AccessKey: testSigner.AccessKey,
+		SecretKey: secretKey,
+		Prefix:    "uploads/",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestS3RoundTrip(t *testing.T) {
+	endpoint := startServer(t)
+	s := newStorage(t, endpoint, "cms", testSigner.SecretKey)
+
+	// 对象名中的空格和中文需要按 SigV4 编码
+	key := "images/示例 图片.png"
+	data := []byte("\x89PNG fake image")
+
+	if err := s.Put(key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	info, err := s.Stat(key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size != int64(len(data)) || info.ContentType != "image/png" || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	file, err := s.Get(key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Get = %q, %v, want %q", got, err, data)
+	}
+
+	signed, err := s.SignedURL(key, time.Minute, "示例 图片.png")
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(got, data) {
+		t.Errorf("GET signed URL = %d %q", resp.StatusCode, got)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); disposition == "" {
+		t.Error("signed URL response has no Content-Disposition")
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get after delete error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat(key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Stat after delete error = %v, want ErrNotFound", err)
+	}
+	// 删除不存在的文件不报错
+	if err := s.Delete(key); err != nil {
+		t.Errorf("Delete missing object: %v", err)
+	}
+}
+
+func TestS3Errors(t *testing.T) {
+	endpoint := startServer(t)
+	data := []byte("data")
+
+	tests := []struct {
+		name    string
+		storage storage.Storage
+	}{
+		{"wrong secret key", newStorage(t, endpoint, "cms", "wrong-secret")},
+		// 存储桶不存在不能当作文件不存在
+		{"missing bucket", newStorage(t, endpoint, "missing", testSigner.SecretKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.storage.Put("a.png", bytes.NewReader(data), int64(len(data)), "image/png"); !errors.Is(err, storage.ErrS3Request) {
+				t.Errorf("Put error = %v, want ErrS3Request", err)
+			}
+			if _, err := tt.storage.Get("a.png"); !errors.Is(err, storage.ErrS3Request) {
+				t.Errorf("Get error = %v, want ErrS3Request", err)
+			}
+		})
+	}
+
+	// 签名链接被修改后校验失败
+	s := newStorage(t, endpoint, "cms", testSigner.SecretKey)
+	if err := s.Put("a.png", bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := s.SignedURL("a.png", time.Minute, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(signed + "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("GET tampered signed URL = %d, want 403", resp.StatusCode)
+	}
+
+	// 内容大小与声明的不一致时不上传
+	if err := s.Put("b.png", bytes.NewReader(data), int64(len(data))+1, "image/png"); !errors.Is(err, storage.ErrS3Request) {
+		t.Errorf("Put with wrong size error = %v, want ErrS3Request", err)
+	}
+}
@@ -0,0 +1,137 @@
+// storagemigrate 在存储之间复制已上传的原图，切换 STORAGE_DRIVER 前在项目根目录运行：
+//
+//	go run ./cmd/storagemigrate -from local -to s3
+//	go run ./cmd/storagemigrate -from local -to s3 -delete   # 复制成功后删除原存储中的文件
+//
+// 两个存储都使用 .env 中的配置，只迁移数据库中存在的图片；目标中已存在且大小相同的文件会跳过，可以重复运行。
+// 缩略图等衍生图片是本地缓存，不需要迁移
+package main
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/services"
+	"cms/utils"
+	"cms/utils/storage"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	from := flag.String("from", storage.DriverLocal, "源存储：local 或 s3")
+	to := flag.String("to", storage.DriverS3, "目标存储：local 或 s3")
+	remove := flag.Bool("delete", false, "复制成功后删除源存储中的文件")
+	overwrite := flag.Bool("overwrite", false, "覆盖目标中已存在的文件")
+	dryRun := flag.Bool("dry-run", false, "只列出需要复制的文件")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal("源存储和目标存储不能相同")
+	}
+
+	storageConfig, err := config.NewStorageConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := newStorage(storageConfig, *from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dst, err := newStorage(storageConfig, *to)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := utils.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var images []*models.Image
+	if err := db.Order("created_at ASC").Find(&images).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	var copied, skipped, missing, failed int
+	for _, image := range images {
+		key := services.ImageKey(image.Hash)
+
+		info, err := src.Stat(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("%s（%s）在源存储中不存在", key, image.Title)
+			missing++
+			continue
+		}
+		if err != nil {
+			log.Printf("%s 读取失败: %v", key, err)
+			failed++
+			continue
+		}
+
+		if !*overwrite {
+			if existing, err := dst.Stat(key); err == nil && existing.Size == info.Size {
+				skipped++
+				continue
+			}
+		}
+
+		if *dryRun {
+			fmt.Printf("%s %s %d\n", key, image.Title, info.Size)
+			copied++
+			continue
+		}
+
+		if err := copyObject(src, dst, key, info.Size, image.MimeType); err != nil {
+			log.Printf("%s 复制失败: %v", key, err)
+			failed++
+			continue
+		}
+		copied++
+
+		if *remove {
+			if err := src.Delete(key); err != nil {
+				log.Printf("%s 删除源文件失败: %v", key, err)
+				failed++
+			}
+		}
+	}
+
+	fmt.Printf("共 %d 张图片，复制 %d，跳过 %d，源文件缺失 %d，失败 %d\n", len(images), copied, skipped, missing, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// newStorage 使用指定的驱动创建存储，其他配置与 .env 相同
+func newStorage(cfg *config.StorageConfig, driver string) (storage.Storage, error) {
+	c := *cfg
+	c.Driver = driver
+	return storage.New(&c)
+}
+
+// copyObject 复制文件并检查目标文件的大小
+func copyObject(src, dst storage.Storage, key string, size int64, contentType string) error {
+	file, err := src.Get(key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := dst.Put(key, file, size, contentType); err != nil {
+		return err
+	}
+
+	info, err := dst.Stat(key)
+	if err != nil {
+		return err
+	}
+	if info.Size != size {
+		return fmt.Errorf("大小不一致：源 %d，目标 %d", size, info.Size)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type CacheConfig struct {
+	Driver   string        `mapstructure:"CACHE_DRIVER"`   // memory 或 redis
+	TTL      time.Duration `mapstructure:"CACHE_TTL"`      // 默认过期时间
+	Capacity int           `mapstructure:"CACHE_CAPACITY"` // 内存缓存最大条目数
+
+	RedisAddr     string `mapstructure:"REDIS_ADDR"`
+	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB       int    `mapstructure:"REDIS_DB"`
+	RedisPrefix   string `mapstructure:"REDIS_PREFIX"`
+	// 登录失败计数等安全状态使用的前缀，不能与 RedisPrefix 互为前缀
+	RedisSecurityPrefix string `mapstructure:"REDIS_SECURITY_PREFIX"`
+}
+
+func NewCacheConfig() (*CacheConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("CACHE_DRIVER", "memory")
+	viper.SetDefault("CACHE_TTL", "5m")
+	viper.SetDefault("CACHE_CAPACITY", 10000)
+	viper.SetDefault("REDIS_ADDR", "127.0.0.1:6379")
+	viper.SetDefault("REDIS_PREFIX", "cms:")
+	viper.SetDefault("REDIS_SECURITY_PREFIX", "cms-security:")
+
+	var cfg CacheConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
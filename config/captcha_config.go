@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type CaptchaConfig struct {
+	Enabled bool          `mapstructure:"CAPTCHA_ENABLED"`
+	Mode    string        `mapstructure:"CAPTCHA_MODE"`   // image 或 math
+	TTL     time.Duration `mapstructure:"CAPTCHA_TTL"`    // 验证码有效期
+	Length  int           `mapstructure:"CAPTCHA_LENGTH"` // 图片验证码位数
+	// 同一用户名或 IP 登录失败次数达到该值后需要验证码，0 表示始终需要
+	AfterFailures int `mapstructure:"CAPTCHA_AFTER_FAILURES"`
+}
+
+func NewCaptchaConfig() (*CaptchaConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("CAPTCHA_ENABLED", true)
+	viper.SetDefault("CAPTCHA_MODE", "image")
+	viper.SetDefault("CAPTCHA_TTL", "5m")
+	viper.SetDefault("CAPTCHA_LENGTH", 4)
+	viper.SetDefault("CAPTCHA_AFTER_FAILURES", 3)
+
+	var cfg CaptchaConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type ImageConfig struct {
+	// 缩略图等衍生图片的缓存目录，相对路径基于工作目录
+	VariantPath string `mapstructure:"IMAGE_VARIANT_PATH"`
+	// 允许的尺寸，格式为 宽x高，省略一边表示按比例计算，如 320x 或 x200
+	Presets []ImageSize `mapstructure:"-"`
+	// 允许的 JPEG 质量，未指定时使用 IMAGE_DEFAULT_QUALITY
+	Qualities      []int `mapstructure:"IMAGE_QUALITIES"`
+	DefaultQuality int   `mapstructure:"IMAGE_DEFAULT_QUALITY"`
+	// 上传和生成衍生图片时允许的最大像素数，防止解压炸弹
+	MaxPixels int `mapstructure:"IMAGE_MAX_PIXELS"`
+
+	// 允许上传的格式，按文件头判断：jpeg、png、gif、webp、bmp、svg；SVG 保存前会删除脚本和外部引用
+	AllowedFormats []string `mapstructure:"IMAGE_ALLOWED_FORMATS"`
+	// 单个文件的最大字节数
+	MaxBytes int64 `mapstructure:"IMAGE_MAX_BYTES"`
+	// 一次最多上传的文件数
+	MaxFiles  int `mapstructure:"IMAGE_MAX_FILES"`
+	MaxWidth  int `mapstructure:"IMAGE_MAX_WIDTH"`
+	MaxHeight int `mapstructure:"IMAGE_MAX_HEIGHT"`
+
+	// 上传时删除 EXIF（GPS 位置、相机序列号等）、XMP、IPTC 和注释，保留颜色配置文件
+	StripMetadata bool `mapstructure:"IMAGE_STRIP_METADATA"`
+	// 删除元数据时保留的信息：artist（作者）、copyright（版权）
+	KeepMetadata []string `mapstructure:"IMAGE_KEEP_METADATA"`
+	// 上传时按 EXIF 方向旋转 JPEG 和 PNG，旋转后使用 IMAGE_ORIENT_QUALITY 重新编码 JPEG
+	AutoOrient    bool `mapstructure:"IMAGE_AUTO_ORIENT"`
+	OrientQuality int  `mapstructure:"IMAGE_ORIENT_QUALITY"`
+}
+
+// ImageSize 图片尺寸，0 表示按比例计算
+type ImageSize struct {
+	Width  int
+	Height int
+}
+
+func NewImageConfig() (*ImageConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("IMAGE_VARIANT_PATH", "variants")
+	viper.SetDefault("IMAGE_PRESETS", "100x100,320x,640x,1280x")
+	viper.SetDefault("IMAGE_QUALITIES", []int{60, 75, 90})
+	viper.SetDefault("IMAGE_DEFAULT_QUALITY", 75)
+	viper.SetDefault("IMAGE_MAX_PIXELS", 50_000_000)
+	viper.SetDefault("IMAGE_ALLOWED_FORMATS", []string{"jpeg", "png", "gif", "webp"})
+	viper.SetDefault("IMAGE_MAX_BYTES", 10<<20)
+	viper.SetDefault("IMAGE_MAX_FILES", 20)
+	viper.SetDefault("IMAGE_MAX_WIDTH", 10000)
+	viper.SetDefault("IMAGE_MAX_HEIGHT", 10000)
+	viper.SetDefault("IMAGE_STRIP_METADATA", true)
+	viper.SetDefault("IMAGE_KEEP_METADATA", []string{"artist", "copyright"})
+	viper.SetDefault("IMAGE_AUTO_ORIENT", true)
+	viper.SetDefault("IMAGE_ORIENT_QUALITY", 90)
+
+	var cfg ImageConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	for _, item := range strings.Split(viper.GetString("IMAGE_PRESETS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		size, err := parseImageSize(item)
+		if err != nil {
+			return nil, fmt.Errorf("IMAGE_PRESETS 格式错误: %q", item)
+		}
+		cfg.Presets = append(cfg.Presets, size)
+	}
+
+	return &cfg, nil
+}
+
+func parseImageSize(s string) (ImageSize, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok || (w == "" && h == "") {
+		return ImageSize{}, fmt.Errorf("invalid size %q", s)
+	}
+
+	var size ImageSize
+	var err error
+	if w != "" {
+		if size.Width, err = strconv.Atoi(w); err != nil || size.Width <= 0 {
+			return ImageSize{}, fmt.Errorf("invalid width %q", s)
+		}
+	}
+	if h != "" {
+		if size.Height, err = strconv.Atoi(h); err != nil || size.Height <= 0 {
+			return ImageSize{}, fmt.Errorf("invalid height %q", s)
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type KeyConfig struct {
+	Store string `mapstructure:"KEY_STORE"` // file 或 database
+	Dir   string `mapstructure:"KEY_DIR"`   // file 模式下 PEM 文件所在目录
+	Bits  int    `mapstructure:"KEY_BITS"`
+
+	// 密钥轮换间隔，不大于 0 时不自动轮换
+	RotateInterval time.Duration `mapstructure:"KEY_ROTATE_INTERVAL"`
+	// 轮换后旧密钥继续用于验证的时间，应不小于 token 有效期
+	GracePeriod time.Duration `mapstructure:"KEY_GRACE_PERIOD"`
+	// 从存储中重新加载密钥的间隔，用于多实例之间同步
+	ReloadInterval time.Duration `mapstructure:"KEY_RELOAD_INTERVAL"`
+}
+
+func NewKeyConfig() (*KeyConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("KEY_STORE", "file")
+	viper.SetDefault("KEY_DIR", "keys")
+	viper.SetDefault("KEY_BITS", 2048)
+	viper.SetDefault("KEY_ROTATE_INTERVAL", "720h")
+	viper.SetDefault("KEY_GRACE_PERIOD", "24h")
+	viper.SetDefault("KEY_RELOAD_INTERVAL", "1m")
+
+	var cfg KeyConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type LoginConfig struct {
+	// 同一用户名连续失败次数达到该值后锁定账号
+	MaxFailures  int           `mapstructure:"LOGIN_MAX_FAILURES"`
+	LockDuration time.Duration `mapstructure:"LOGIN_LOCK_DURATION"`
+	// 失败次数的统计窗口，窗口内没有新的失败时计数清零
+	FailureWindow time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	// 每次失败后需要等待 BackoffBase * 2^(失败次数-1)，不超过 BackoffMax
+	BackoffBase time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+	BackoffMax  time.Duration `mapstructure:"LOGIN_BACKOFF_MAX"`
+	// 同一 IP 在统计窗口内失败次数达到该值后暂时禁止登录
+	IPMaxFailures   int           `mapstructure:"LOGIN_IP_MAX_FAILURES"`
+	IPBlockDuration time.Duration `mapstructure:"LOGIN_IP_BLOCK_DURATION"`
+}
+
+func NewLoginConfig() (*LoginConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_LOCK_DURATION", "15m")
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", "15m")
+	viper.SetDefault("LOGIN_BACKOFF_BASE", "1s")
+	viper.SetDefault("LOGIN_BACKOFF_MAX", "1m")
+	viper.SetDefault("LOGIN_IP_MAX_FAILURES", 50)
+	viper.SetDefault("LOGIN_IP_BLOCK_DURATION", "15m")
+
+	var cfg LoginConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
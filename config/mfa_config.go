@@ -0,0 +1,42 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type MFAConfig struct {
+	// 验证器应用中显示的发行方名称
+	Issuer string `mapstructure:"MFA_ISSUER"`
+	// 密码验证通过后完成两步验证的时限
+	ChallengeTTL time.Duration `mapstructure:"MFA_CHALLENGE_TTL"`
+	// 每个挑战允许输错验证码的次数
+	MaxAttempts int `mapstructure:"MFA_MAX_ATTEMPTS"`
+	// 允许的时钟误差（时间步数，每步 30 秒）
+	Skew          int `mapstructure:"MFA_SKEW"`
+	RecoveryCodes int `mapstructure:"MFA_RECOVERY_CODES"`
+}
+
+func NewMFAConfig() (*MFAConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("MFA_ISSUER", "CMS")
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("MFA_MAX_ATTEMPTS", 5)
+	viper.SetDefault("MFA_SKEW", 1)
+	viper.SetDefault("MFA_RECOVERY_CODES", 10)
+
+	var cfg MFAConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
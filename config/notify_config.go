@@ -0,0 +1,43 @@
+package config
+
+import "github.com/spf13/viper"
+
+type NotifyConfig struct {
+	// console 输出到日志，file 追加到文件，smtp 发送邮件
+	Driver   string `mapstructure:"NOTIFY_DRIVER"`
+	FilePath string `mapstructure:"NOTIFY_FILE_PATH"`
+
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+	// starttls、tls（端口 465）或 none
+	SMTPEncryption string `mapstructure:"SMTP_ENCRYPTION"`
+}
+
+func NewNotifyConfig() (*NotifyConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("NOTIFY_DRIVER", "console")
+	viper.SetDefault("NOTIFY_FILE_PATH", "notifications.log")
+	viper.SetDefault("SMTP_HOST", "")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("SMTP_USERNAME", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("SMTP_FROM", "")
+	viper.SetDefault("SMTP_ENCRYPTION", "starttls")
+
+	var cfg NotifyConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
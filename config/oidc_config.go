@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type OIDCConfig struct {
+	Enabled bool `mapstructure:"OIDC_ENABLED"`
+	// 身份提供方地址，从 {issuer}/.well-known/openid-configuration 读取端点
+	Issuer       string `mapstructure:"OIDC_ISSUER"`
+	ClientID     string `mapstructure:"OIDC_CLIENT_ID"`
+	ClientSecret string `mapstructure:"OIDC_CLIENT_SECRET"`
+	// 在身份提供方登记的回调地址，指向 /api/common/account/oidc/callback
+	RedirectURL string `mapstructure:"OIDC_REDIRECT_URL"`
+	// 登录完成后允许跳转的前端地址，需要完全匹配，第一个为默认地址
+	AllowedRedirects []string `mapstructure:"OIDC_ALLOWED_REDIRECTS"`
+	Scopes           []string `mapstructure:"OIDC_SCOPES"`
+	// 登录状态和一次性登录码的有效期
+	StateTTL time.Duration `mapstructure:"OIDC_STATE_TTL"`
+	CodeTTL  time.Duration `mapstructure:"OIDC_CODE_TTL"`
+
+	// 用户信息对应的声明名称
+	UsernameClaim string `mapstructure:"OIDC_USERNAME_CLAIM"`
+	NicknameClaim string `mapstructure:"OIDC_NICKNAME_CLAIM"`
+	EmailClaim    string `mapstructure:"OIDC_EMAIL_CLAIM"`
+	PhoneClaim    string `mapstructure:"OIDC_PHONE_CLAIM"`
+	GroupsClaim   string `mapstructure:"OIDC_GROUPS_CLAIM"`
+
+	// 首次登录时自动创建用户
+	AutoCreate bool `mapstructure:"OIDC_AUTO_CREATE"`
+	// 首次登录时按身份提供方声明已验证的邮箱关联已有用户。本地邮箱没有经过验证，
+	// 任何人都可以把邮箱填写成他人的地址，只有本地邮箱可信时才应开启；超级管理员不会被关联
+	LinkByEmail bool `mapstructure:"OIDC_LINK_BY_EMAIL"`
+	// 用户组与角色的对应关系，格式为 group=角色名，多个用逗号分隔
+	RoleMapping map[string][]string `mapstructure:"-"`
+	// 没有匹配到任何用户组时分配的角色
+	DefaultRoles []string `mapstructure:"OIDC_DEFAULT_ROLES"`
+	// 每次登录时按用户组重新设置角色，关闭时只在创建用户时设置
+	SyncRoles bool `mapstructure:"OIDC_SYNC_ROLES"`
+}
+
+func NewOIDCConfig() (*OIDCConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("OIDC_ENABLED", false)
+	viper.SetDefault("OIDC_ISSUER", "")
+	viper.SetDefault("OIDC_CLIENT_ID", "")
+	viper.SetDefault("OIDC_CLIENT_SECRET", "")
+	viper.SetDefault("OIDC_REDIRECT_URL", "")
+	viper.SetDefault("OIDC_ALLOWED_REDIRECTS", []string{})
+	viper.SetDefault("OIDC_SCOPES", []string{"openid", "profile", "email"})
+	viper.SetDefault("OIDC_STATE_TTL", "10m")
+	viper.SetDefault("OIDC_CODE_TTL", "1m")
+	viper.SetDefault("OIDC_USERNAME_CLAIM", "preferred_username")
+	viper.SetDefault("OIDC_NICKNAME_CLAIM", "name")
+	viper.SetDefault("OIDC_EMAIL_CLAIM", "email")
+	viper.SetDefault("OIDC_PHONE_CLAIM", "phone_number")
+	viper.SetDefault("OIDC_GROUPS_CLAIM", "groups")
+	viper.SetDefault("OIDC_AUTO_CREATE", true)
+	viper.SetDefault("OIDC_LINK_BY_EMAIL", false)
+	viper.SetDefault("OIDC_ROLE_MAPPING", "")
+	viper.SetDefault("OIDC_DEFAULT_ROLES", []string{})
+	viper.SetDefault("OIDC_SYNC_ROLES", true)
+
+	var cfg OIDCConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.RoleMapping = make(map[string][]string)
+	for _, item := range strings.Split(viper.GetString("OIDC_ROLE_MAPPING"), ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(item, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || role == "" {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING 格式错误: %q", item)
+		}
+		cfg.RoleMapping[group] = append(cfg.RoleMapping[group], role)
+	}
+
+	if cfg.Enabled && (cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "") {
+		return nil, fmt.Errorf("启用 OIDC 登录时必须配置 OIDC_ISSUER、OIDC_CLIENT_ID 和 OIDC_REDIRECT_URL")
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import "github.com/spf13/viper"
+
+type PasswordConfig struct {
+	MinLength int `mapstructure:"PASSWORD_MIN_LENGTH"`
+	// 必须包含的字符类型：lower、upper、letter、digit、symbol
+	CharacterClasses []string `mapstructure:"PASSWORD_CHARACTER_CLASSES"`
+	// 额外的弱密码列表文件，每行一个，内置列表始终生效
+	DenylistPath string `mapstructure:"PASSWORD_DENYLIST_PATH"`
+	// 禁止重复使用最近 N 次的密码，0 表示不限制
+	History int `mapstructure:"PASSWORD_HISTORY"`
+
+	// 新密码使用的哈希算法，bcrypt 或 argon2id；算法或参数变化后用户登录时自动重新哈希
+	HashAlgorithm     string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost        int    `mapstructure:"PASSWORD_BCRYPT_COST"`
+	Argon2Memory      uint32 `mapstructure:"PASSWORD_ARGON2_MEMORY"` // KiB
+	Argon2Iterations  uint32 `mapstructure:"PASSWORD_ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8  `mapstructure:"PASSWORD_ARGON2_PARALLELISM"`
+}
+
+func NewPasswordConfig() (*PasswordConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_CHARACTER_CLASSES", []string{"letter", "digit"})
+	viper.SetDefault("PASSWORD_DENYLIST_PATH", "")
+	viper.SetDefault("PASSWORD_HISTORY", 0)
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	viper.SetDefault("PASSWORD_BCRYPT_COST", 10)
+	viper.SetDefault("PASSWORD_ARGON2_MEMORY", 64*1024)
+	viper.SetDefault("PASSWORD_ARGON2_ITERATIONS", 3)
+	viper.SetDefault("PASSWORD_ARGON2_PARALLELISM", 2)
+
+	var cfg PasswordConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type PasswordResetConfig struct {
+	CodeLength int           `mapstructure:"RESET_CODE_LENGTH"`
+	CodeTTL    time.Duration `mapstructure:"RESET_CODE_TTL"`
+	// 每个验证码允许输错的次数，用完后需要重新获取
+	MaxAttempts int `mapstructure:"RESET_MAX_ATTEMPTS"`
+	// 同一账号两次获取验证码的最小间隔
+	ResendInterval time.Duration `mapstructure:"RESET_RESEND_INTERVAL"`
+	// 同一 IP 在统计窗口内最多获取验证码的次数
+	IPMaxRequests int           `mapstructure:"RESET_IP_MAX_REQUESTS"`
+	IPWindow      time.Duration `mapstructure:"RESET_IP_WINDOW"`
+	// 验证码校验通过后设置新密码的时限
+	TokenTTL time.Duration `mapstructure:"RESET_TOKEN_TTL"`
+}
+
+func NewPasswordResetConfig() (*PasswordResetConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("RESET_CODE_LENGTH", 6)
+	viper.SetDefault("RESET_CODE_TTL", "10m")
+	viper.SetDefault("RESET_MAX_ATTEMPTS", 5)
+	viper.SetDefault("RESET_RESEND_INTERVAL", "1m")
+	viper.SetDefault("RESET_IP_MAX_REQUESTS", 10)
+	viper.SetDefault("RESET_IP_WINDOW", "1h")
+	viper.SetDefault("RESET_TOKEN_TTL", "10m")
+
+	var cfg PasswordResetConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
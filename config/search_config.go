@@ -0,0 +1,28 @@
+package config
+
+import "github.com/spf13/viper"
+
+type SearchConfig struct {
+	Driver   string `mapstructure:"SEARCH_DRIVER"`    // memory 或 mysql
+	DictPath string `mapstructure:"SEARCH_DICT_PATH"` // 中文分词词典，为空时按二元切分
+}
+
+func NewSearchConfig() (*SearchConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("SEARCH_DRIVER", "memory")
+	viper.SetDefault("SEARCH_DICT_PATH", "")
+
+	var cfg SearchConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type SiteConfig struct {
+	URL         string `mapstructure:"SITE_URL"` // 站点地址，用于生成订阅源中的绝对链接
+	Title       string `mapstructure:"SITE_TITLE"`
+	Description string `mapstructure:"SITE_DESCRIPTION"`
+	Language    string `mapstructure:"SITE_LANGUAGE"`
+	// 文章页地址，{slug} 会被替换为文章 slug
+	ArticlePath string `mapstructure:"SITE_ARTICLE_PATH"`
+	// 分类页地址，{alias} 会被替换为分类别名
+	CategoryPath string `mapstructure:"SITE_CATEGORY_PATH"`
+	// 订阅源中的文章数量
+	FeedSize int `mapstructure:"FEED_SIZE"`
+}
+
+func NewSiteConfig() (*SiteConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("SITE_URL", "http://localhost:8002")
+	viper.SetDefault("SITE_TITLE", "CMS")
+	viper.SetDefault("SITE_DESCRIPTION", "")
+	viper.SetDefault("SITE_LANGUAGE", "zh-CN")
+	viper.SetDefault("SITE_ARTICLE_PATH", "/article/{slug}")
+	viper.SetDefault("SITE_CATEGORY_PATH", "/category/{alias}")
+	viper.SetDefault("FEED_SIZE", 20)
+
+	var cfg SiteConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	return &cfg, nil
+}
+
+// ArticleURL 返回文章页的绝对地址
+func (c *SiteConfig) ArticleURL(slug string) string {
+	return c.URL + strings.ReplaceAll(c.ArticlePath, "{slug}", slug)
+}
+
+// CategoryURL 返回分类页的绝对地址
+func (c *SiteConfig) CategoryURL(alias string) string {
+	return c.URL + strings.ReplaceAll(c.CategoryPath, "{alias}", alias)
+}
+
+// AbsURL 将站内路径转换为绝对地址
+func (c *SiteConfig) AbsURL(path string) string {
+	return c.URL + "/" + strings.TrimLeft(path, "/")
+}
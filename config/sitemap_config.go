@@ -0,0 +1,45 @@
+package config
+
+import "github.com/spf13/viper"
+
+type SitemapConfig struct {
+	// 单个 sitemap 文件的最大 URL 数量，超过后生成 sitemap 索引
+	MaxURLs int `mapstructure:"SITEMAP_MAX_URLS"`
+
+	HomePriority       float64 `mapstructure:"SITEMAP_HOME_PRIORITY"`
+	HomeChangeFreq     string  `mapstructure:"SITEMAP_HOME_CHANGEFREQ"`
+	CategoryPriority   float64 `mapstructure:"SITEMAP_CATEGORY_PRIORITY"`
+	CategoryChangeFreq string  `mapstructure:"SITEMAP_CATEGORY_CHANGEFREQ"`
+	ArticlePriority    float64 `mapstructure:"SITEMAP_ARTICLE_PRIORITY"`
+	ArticleChangeFreq  string  `mapstructure:"SITEMAP_ARTICLE_CHANGEFREQ"`
+}
+
+func NewSitemapConfig() (*SitemapConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("SITEMAP_MAX_URLS", 50000)
+	viper.SetDefault("SITEMAP_HOME_PRIORITY", 1.0)
+	viper.SetDefault("SITEMAP_HOME_CHANGEFREQ", "daily")
+	viper.SetDefault("SITEMAP_CATEGORY_PRIORITY", 0.8)
+	viper.SetDefault("SITEMAP_CATEGORY_CHANGEFREQ", "daily")
+	viper.SetDefault("SITEMAP_ARTICLE_PRIORITY", 0.6)
+	viper.SetDefault("SITEMAP_ARTICLE_CHANGEFREQ", "weekly")
+
+	var cfg SitemapConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	// 协议规定单个文件最多 50000 个 URL
+	if cfg.MaxURLs <= 0 || cfg.MaxURLs > 50000 {
+		cfg.MaxURLs = 50000
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type StorageConfig struct {
+	Driver string `mapstructure:"STORAGE_DRIVER"` // local 或 s3
+	// 本地存储目录，相对路径基于工作目录
+	LocalPath string `mapstructure:"STORAGE_LOCAL_PATH"`
+
+	S3Endpoint  string `mapstructure:"STORAGE_S3_ENDPOINT"`
+	S3Region    string `mapstructure:"STORAGE_S3_REGION"`
+	S3Bucket    string `mapstructure:"STORAGE_S3_BUCKET"`
+	S3AccessKey string `mapstructure:"STORAGE_S3_ACCESS_KEY"`
+	S3SecretKey string `mapstructure:"STORAGE_S3_SECRET_KEY"`
+	// 对象名前缀，如 uploads/
+	S3Prefix string `mapstructure:"STORAGE_S3_PREFIX"`
+	// 使用 endpoint/bucket/key 形式的地址，MinIO 等自建服务通常需要开启
+	S3PathStyle bool `mapstructure:"STORAGE_S3_PATH_STYLE"`
+
+	// 下载原图时重定向到存储的签名链接，不经过应用转发；本地存储不支持，始终由应用返回
+	Redirect bool `mapstructure:"STORAGE_REDIRECT"`
+	// 签名链接的有效期
+	SignedURLTTL time.Duration `mapstructure:"STORAGE_SIGNED_URL_TTL"`
+}
+
+func NewStorageConfig() (*StorageConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_PATH", "uploads")
+	viper.SetDefault("STORAGE_S3_REGION", "us-east-1")
+	viper.SetDefault("STORAGE_S3_PATH_STYLE", true)
+	viper.SetDefault("STORAGE_REDIRECT", false)
+	viper.SetDefault("STORAGE_SIGNED_URL_TTL", "10m")
+
+	var cfg StorageConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == "s3" && (cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "") {
+		return nil, fmt.Errorf("使用 s3 存储时必须配置 STORAGE_S3_ENDPOINT、STORAGE_S3_BUCKET、STORAGE_S3_ACCESS_KEY 和 STORAGE_S3_SECRET_KEY")
+	}
+
+	return &cfg, nil
+}
@@ -1,12 +1,19 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type SystemConfig struct {
 	Host              string `mapstructure:"HOST"`
 	Port              string `mapstructure:"PORT"`
 	InitAdminUser     string `mapstructure:"INIT_ADMIN_USER"`
 	InitAdminPassword string `mapstructure:"INIT_ADMIN_PASSWORD"`
+
+	// 定时发布任务的执行间隔
+	SchedulerInterval time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
 }
 
 func NewSystemConfig() (*SystemConfig, error) {
@@ -18,6 +25,8 @@ func NewSystemConfig() (*SystemConfig, error) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("SCHEDULER_INTERVAL", "30s")
+
 	var cfg SystemConfig
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
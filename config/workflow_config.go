@@ -0,0 +1,34 @@
+package config
+
+import "github.com/spf13/viper"
+
+type WorkflowConfig struct {
+	// 是否启用审核流程，关闭时拥有 article:publish 权限的用户可以直接发布；
+	// 开启时文章只能按 草稿 → 审核中 → 已通过 → 发布 的顺序流转
+	Enabled bool `mapstructure:"WORKFLOW_ENABLED"`
+	// 审核人用户名列表，超级管理员始终可以审核
+	Reviewers []string `mapstructure:"WORKFLOW_REVIEWERS"`
+	// 是否允许审核人审核自己的文章
+	AllowSelfReview bool `mapstructure:"WORKFLOW_ALLOW_SELF_REVIEW"`
+}
+
+func NewWorkflowConfig() (*WorkflowConfig, error) {
+	viper.SetConfigFile(".env")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	viper.AutomaticEnv()
+
+	viper.SetDefault("WORKFLOW_ENABLED", true)
+	viper.SetDefault("WORKFLOW_REVIEWERS", []string{})
+	viper.SetDefault("WORKFLOW_ALLOW_SELF_REVIEW", false)
+
+	var cfg WorkflowConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
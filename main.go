@@ -2,13 +2,18 @@ package main
 
 import (
 	"cms/config"
+	"cms/middleware/bodylimit"
 	"cms/middleware/roleauth"
+	"cms/middleware/tokenauth"
 	"cms/models/domain"
 	"cms/models/scopes"
 	"cms/routes/admin"
 	"cms/routes/common"
 	"cms/services"
 	"cms/utils"
+	"cms/utils/cache"
+	"cms/utils/notify"
+	"cms/utils/storage"
 	"fmt"
 	"time"
 
@@ -34,16 +39,71 @@ func main() {
 		panic(err)
 	}
 
-	privateKey, err := utils.GeneratePrivateKey()
+	keyConfig, err := config.NewKeyConfig()
 	if err != nil {
 		panic(err)
 	}
 
+	// 签名密钥持久化保存，重启和多实例部署时 token 仍然有效
+	keyService, err := services.NewKeyService(db, keyConfig)
+	if err != nil {
+		panic(err)
+	}
+	keyService.Start()
+	defer keyService.Stop()
+
+	tokenConfig, err := config.NewTokenConfig()
+	if err != nil {
+		panic(err)
+	}
+	tokenService := services.NewTokenService(db, keyService, tokenConfig)
+
 	systemConfig, err := config.NewSystemConfig()
 	if err != nil {
 		panic(err)
 	}
 
+	workflowConfig, err := config.NewWorkflowConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	searchConfig, err := config.NewSearchConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	siteConfig, err := config.NewSiteConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	sitemapConfig, err := config.NewSitemapConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	cacheConfig, err := config.NewCacheConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	appCache, err := cache.New(cacheConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	// 登录失败计数、MFA 和找回密码的验证状态不随缓存淘汰或清空
+	securityStore, err := cache.NewSecurityStore(cacheConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	imageConfig, err := config.NewImageConfig()
+	if err != nil {
+		panic(err)
+	}
+
 	app := fiber.New(fiber.Config{
 		// Prefork:       true,
 		CaseSensitive: true,
@@ -52,6 +112,9 @@ func main() {
 		AppName:      "cms v0.0.1",
 		JSONEncoder:  sonic.Marshal,
 		JSONDecoder:  sonic.Unmarshal,
+		// 请求体按需读取，大小由 bodylimit 中间件按路由限制；上传的图片超过 8KB 时写入临时文件
+		StreamRequestBody: true,
+		BodyLimit:         fiber.DefaultBodyLimit,
 		// ErrorHandler: func(c *fiber.Ctx, err error) error {
 		// 	return c.Status(fiber.StatusInternalServerError).JSON(domain.Response{
 		// 		Code:    fiber.StatusInternalServerError,
@@ -62,6 +125,15 @@ func main() {
 		// },
 	})
 
+	// 只有批量上传图片的请求体较大，单个文件的大小由 IMAGE_MAX_BYTES 限制
+	uploadBodyLimit := 0
+	if imageConfig.MaxBytes > 0 && imageConfig.MaxFiles > 0 {
+		uploadBodyLimit = int(imageConfig.MaxBytes)*imageConfig.MaxFiles + 1<<20
+	}
+	app.Use(bodylimit.New(fiber.DefaultBodyLimit, map[string]int{
+		fiber.MethodPost + " /api/admin/image": uploadBodyLimit,
+	}))
+
 	// 设置压缩中间件
 	app.Use(compress.New(compress.Config{
 		Level: compress.LevelBestCompression, // 2
@@ -81,48 +153,158 @@ func main() {
 		Title: "CMS 指标监控",
 	}))
 
+	articleScope := scopes.NewArticleScope(db)
+
+	// 公开验证 token 的公钥
+	common.NewWellKnownRoute(app.Group("/.well-known"), keyService).RegisterRoutes()
+
+	// 站点地图
+	common.NewSitemapRoute(app, services.NewSitemapService(db, appCache, articleScope, siteConfig, sitemapConfig)).RegisterRoutes()
+
 	api := app.Group("api")
 
-	userService := services.NewUserService(db)
+	passwordConfig, err := config.NewPasswordConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	userService, err := services.NewUserService(db, passwordConfig)
+	if err != nil {
+		panic(err)
+	}
 
 	// 创建初始用户
 	if err := userService.CreateInitialUser(systemConfig.InitAdminUser, systemConfig.InitAdminPassword); err != nil {
 		panic(err)
 	}
 
-	roleAuthMiddleware := roleauth.New(userService)
+	loginConfig, err := config.NewLoginConfig()
+	if err != nil {
+		panic(err)
+	}
+	// 使用 Redis 缓存时登录失败计数在多个实例之间共享
+	loginGuard := services.NewLoginGuard(db, securityStore, loginConfig)
+
+	captchaConfig, err := config.NewCaptchaConfig()
+	if err != nil {
+		panic(err)
+	}
+	captchaService := services.NewCaptchaService(appCache, loginGuard, captchaConfig)
+
+	mfaConfig, err := config.NewMFAConfig()
+	if err != nil {
+		panic(err)
+	}
+	mfaService := services.NewMFAService(db, securityStore, loginGuard, mfaConfig)
+
+	notifyConfig, err := config.NewNotifyConfig()
+	if err != nil {
+		panic(err)
+	}
+	notifier, err := notify.New(notifyConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	passwordResetConfig, err := config.NewPasswordResetConfig()
+	if err != nil {
+		panic(err)
+	}
+	passwordResetService := services.NewPasswordResetService(db, securityStore, notifier, userService, tokenService, loginGuard, passwordResetConfig)
+
+	rbacService := services.NewRBACService(db, appCache)
+	// 写入内置权限和默认角色
+	if err := rbacService.Seed(); err != nil {
+		panic(err)
+	}
+
+	oidcConfig, err := config.NewOIDCConfig()
+	if err != nil {
+		panic(err)
+	}
+	// 单点登录，首次登录时按用户组创建用户并分配角色
+	oidcService := services.NewOIDCService(db, appCache, rbacService, oidcConfig)
+
+	// 个人访问令牌，用于脚本和客户端调用管理接口
+	accessTokenService := services.NewAccessTokenService(db, rbacService)
+
+	categoryService := services.NewCategoryService(db, appCache)
+
+	searchService, err := services.NewSearchService(db, appCache, articleScope, searchConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	// 启动时构建搜索索引
+	if err := searchService.Rebuild(); err != nil {
+		panic(err)
+	}
+
+	articleWorkflow := services.NewArticleWorkflow(db, workflowConfig, rbacService)
+	articleService := services.NewArticleService(db, articleScope, appCache, articleWorkflow, searchService)
+	// 为升级前创建的文章补充 slug
+	if err := articleService.GenerateMissingSlugs(); err != nil {
+		panic(err)
+	}
+	storageConfig, err := config.NewStorageConfig()
+	if err != nil {
+		panic(err)
+	}
+	fileStorage, err := storage.New(storageConfig)
+	if err != nil {
+		panic(err)
+	}
+	imageService, err := services.NewImageService(db, imageConfig, fileStorage, appCache)
+	if err != nil {
+		panic(err)
+	}
+	dictService := services.NewDictService(db, appCache)
 
-	categoryService := services.NewCategoryService(db)
-	articleService := services.NewArticleService(db, scopes.NewArticleScope(db))
-	imageService := services.NewImageService(db)
-	dictService := services.NewDictService(db)
+	// 定时发布、下线文章
+	articleScheduler := services.NewArticleScheduler(db, appCache, searchService, systemConfig.SchedulerInterval)
+	articleScheduler.Start()
+	defer articleScheduler.Stop()
 
 	{
 		// 对于所有admin路由，使用jwt中间件进行验证
 		// 这里的jwt中间件会在请求到达路由之前进行验证
-		adminGroup := api.Group("admin", jwtware.New(jwtware.Config{
-			SigningKey: jwtware.SigningKey{
-				JWTAlg: jwtware.RS512,
-				Key:    privateKey.Public()},
+		// 个人访问令牌在 jwt 中间件之前校验，校验通过后跳过 jwt 中间件
+		adminGroup := api.Group("admin", tokenauth.AccessToken(accessTokenService), jwtware.New(jwtware.Config{
+			Filter:  tokenauth.IsAccessToken,
+			KeyFunc: keyService.KeyFunc,
 			ErrorHandler: func(c *fiber.Ctx, err error) error {
 				return domain.ErrorResponse(c, fiber.StatusUnauthorized, "您的身份验证已过期，请重新登录", err)
 			},
-		}))
+		}), tokenauth.New(tokenService), roleauth.New(rbacService))
 
 		// 分类
-		admin.NewCategoryRoute(adminGroup.Group("category", roleAuthMiddleware), categoryService, validate).RegisterRoutes()
+		admin.NewCategoryRoute(adminGroup.Group("category"), categoryService, validate).RegisterRoutes()
 		// 文章
-		admin.NewArticleRoute(adminGroup.Group("article"), articleService, validate).RegisterRoutes()
+		admin.NewArticleRoute(adminGroup.Group("article"), articleService, searchService, validate).RegisterRoutes()
+		// 文章审核
+		admin.NewArticleReviewRoute(adminGroup.Group("article"), services.NewArticleReviewService(db, appCache, articleWorkflow, searchService), validate).RegisterRoutes()
+		// 文章修订版本
+		admin.NewArticleRevisionRoute(adminGroup.Group("article/:id<guid>/revisions"), services.NewArticleRevisionService(db, articleService), validate).RegisterRoutes()
 		// 图片
-		admin.NewImageRoute(adminGroup.Group("image"), imageService, validate).RegisterRoutes()
+		admin.NewImageRoute(adminGroup.Group("image"), imageService, imageConfig, validate).RegisterRoutes()
 		// 用户
-		admin.NewUserRoute(adminGroup.Group("user", roleAuthMiddleware), userService, validate).RegisterRoutes()
+		admin.NewUserRoute(adminGroup.Group("user"), userService, tokenService, rbacService, loginGuard, mfaService, accessTokenService, validate).RegisterRoutes()
 		// 标签
-		admin.NewTagRoute(adminGroup.Group("tag"), services.NewTagService(db), validate).RegisterRoutes()
+		admin.NewTagRoute(adminGroup.Group("tag"), services.NewTagService(db, appCache, searchService), validate).RegisterRoutes()
 		// 字典
-		admin.NewDictRoute(adminGroup.Group("dict", roleAuthMiddleware), dictService, validate).RegisterRoutes()
+		admin.NewDictRoute(adminGroup.Group("dict"), dictService, validate).RegisterRoutes()
 		// 账号
-		admin.NewAccountRoute(adminGroup.Group("account"), userService, validate).RegisterRoutes()
+		admin.NewAccountRoute(adminGroup.Group("account"), userService, tokenService, validate).RegisterRoutes()
+		// 两步验证
+		admin.NewMFARoute(adminGroup.Group("account/mfa", tokenauth.RequireSession()), mfaService, validate).RegisterRoutes()
+		// 个人访问令牌不能用于创建新的令牌
+		admin.NewAccessTokenRoute(adminGroup.Group("accessToken", tokenauth.RequireSession()), accessTokenService, validate).RegisterRoutes()
+		// 缓存
+		admin.NewCacheRoute(adminGroup.Group("cache"), appCache).RegisterRoutes()
+		// 角色
+		admin.NewRoleRoute(adminGroup.Group("role"), rbacService, validate).RegisterRoutes()
+		// 签名密钥
+		admin.NewKeyRoute(adminGroup.Group("key"), keyService).RegisterRoutes()
 	}
 
 	{
@@ -149,15 +331,19 @@ func main() {
 		}))
 
 		// 图片
-		common.NewImageRoute(commonGroup.Group("image"), imageService, validate).RegisterRoutes()
+		common.NewImageRoute(commonGroup.Group("image"), imageService, storageConfig, validate).RegisterRoutes()
 		// 账号
-		common.NewAccountRoute(commonGroup.Group("account"), userService, validate, privateKey).RegisterRoutes()
+		common.NewAccountRoute(commonGroup.Group("account"), userService, validate, tokenService, loginGuard, captchaService, mfaService, passwordResetService, oidcService).RegisterRoutes()
+		// 验证码
+		common.NewCaptchaRoute(commonGroup.Group("captcha"), captchaService, validate).RegisterRoutes()
 		// 分类
 		common.NewCategoryRoute(commonGroup.Group("category"), categoryService, validate).RegisterRoutes()
 		// 新闻
-		common.NewArticleRoute(commonGroup.Group("article"), articleService, validate).RegisterRoutes()
+		common.NewArticleRoute(commonGroup.Group("article"), articleService, searchService, validate).RegisterRoutes()
 		// 字典
 		common.NewDictRoute(commonGroup.Group("dict"), dictService, validate).RegisterRoutes()
+		// 订阅源
+		common.NewFeedRoute(commonGroup.Group("feed"), services.NewFeedService(db, appCache, articleScope, siteConfig), siteConfig).RegisterRoutes()
 	}
 
 	// // 从环境变量中读取端口号，默认为 ":3000"
@@ -0,0 +1,67 @@
+package bodylimit
+
+import (
+	"cms/models/domain"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// ErrBodyTooLarge 请求体超过大小限制
+	ErrBodyTooLarge = errors.New("请求体过大")
+)
+
+// New 限制请求体大小，需要开启 fiber.Config.StreamRequestBody。带 Content-Length 的请求超出限制时不读取请求体，
+// 分块传输的请求读取到超出限制为止。
+// routes 的键为 "方法 路径"，如 "POST /api/admin/image"，为个别路由单独设置限制；限制小于等于 0 表示不限制
+func New(limit int, routes map[string]int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		allowed := limit
+		if n, ok := routes[c.Method()+" "+strings.TrimSuffix(c.Path(), "/")]; ok {
+			allowed = n
+		}
+		if allowed <= 0 {
+			return c.Next()
+		}
+
+		length := c.Request().Header.ContentLength()
+		if length == -1 {
+			return readChunked(c, allowed)
+		}
+		if length > allowed {
+			// 请求体没有读取，不能继续复用连接
+			c.Context().SetConnectionClose()
+			return domain.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "请求体过大", ErrBodyTooLarge)
+		}
+		return c.Next()
+	}
+}
+
+// readChunked 分块传输时无法预先判断大小，最多读取 allowed 字节，读取完整的请求体后交给后续处理
+func readChunked(c *fiber.Ctx, allowed int) error {
+	stream := c.Request().BodyStream()
+	if stream == nil {
+		// 未开启 StreamRequestBody 时请求体已经读取
+		if len(c.Body()) > allowed {
+			return domain.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "请求体过大", ErrBodyTooLarge)
+		}
+		return c.Next()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(stream, int64(allowed)+1))
+	if err != nil {
+		c.Context().SetConnectionClose()
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "读取请求体失败", err)
+	}
+	if len(body) > allowed {
+		c.Context().SetConnectionClose()
+		return domain.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "请求体过大", ErrBodyTooLarge)
+	}
+
+	c.Request().SetBody(body)
+	c.Request().Header.SetContentLength(len(body))
+	return c.Next()
+}
@@ -1,37 +1,86 @@
 package roleauth
 
 import (
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 	"errors"
+	"slices"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// 当前用户权限在 Locals 中的键
+const permissionsKey = "permissions"
+
 var (
 	ErrUserNotFound = errors.New("用户未找到")
+	// ErrForbidden 没有权限访问该资源
+	ErrForbidden = errors.New("没有权限访问该资源")
 )
 
-func New(userService services.UserService) fiber.Handler {
+// New 加载当前用户的权限供 Require 使用，需要放在 JWT 中间件之后
+func New(rbacService services.RBACService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := c.Locals("user").(*jwt.Token)
 		claims := user.Claims.(jwt.MapClaims)
-		userID, err := uuid.Parse(claims["user_id"].(string))
+		userIDStr, _ := claims["user_id"].(string)
+		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
 			return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", ErrUserNotFound)
 		}
 
-		isSuper, err := userService.GetUserIsSuper(userID)
+		permissions, err := rbacService.GetUserPermissions(userID)
 		if err != nil {
-			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取用户角色失败", err)
+			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取用户权限失败", err)
 		}
 
-		if !isSuper {
-			return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限访问该资源", errors.New("没有权限访问该资源"))
+		// 个人访问令牌只能使用令牌范围内的权限
+		if scopes, ok := claims["scopes"].([]string); ok {
+			permissions = restrict(permissions, scopes)
 		}
 
+		c.Locals(permissionsKey, permissions)
 		return c.Next()
 	}
 }
+
+// Require 要求当前用户拥有全部指定权限
+func Require(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, permission := range permissions {
+			if !Has(c, permission) {
+				return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限访问该资源", ErrForbidden)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// Has 检查当前用户是否拥有权限
+func Has(c *fiber.Ctx, permission string) bool {
+	return services.HasPermission(Permissions(c), permission)
+}
+
+// Permissions 返回当前用户的权限
+func Permissions(c *fiber.Ctx) []string {
+	permissions, _ := c.Locals(permissionsKey).([]string)
+	return permissions
+}
+
+// restrict 计算用户权限与令牌范围的交集
+func restrict(permissions, scopes []string) []string {
+	if slices.Contains(scopes, models.PermAll) {
+		return permissions
+	}
+
+	restricted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if services.HasPermission(permissions, scope) {
+			restricted = append(restricted, scope)
+		}
+	}
+	return restricted
+}
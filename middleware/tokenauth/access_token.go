@@ -0,0 +1,63 @@
+package tokenauth
+
+import (
+	"cms/models/domain"
+	"cms/services"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 个人访问令牌在 Locals 中的键
+const accessTokenKey = "accessToken"
+
+var (
+	// ErrSessionRequired 需要使用登录后的访问令牌
+	ErrSessionRequired = errors.New("个人访问令牌不能访问该接口，请登录后操作")
+)
+
+// AccessToken 校验 Authorization: Bearer cms_pat_... 形式的个人访问令牌，需要放在 JWT 中间件之前。
+// 校验通过后写入与 JWT 中间件相同的 user，claims 中的 scopes 由 roleauth 用于限制权限
+func AccessToken(accessTokenService services.AccessTokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		auth := c.Get(fiber.HeaderAuthorization)
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || !strings.HasPrefix(token, services.AccessTokenPrefix) {
+			return c.Next()
+		}
+
+		accessToken, err := accessTokenService.Authenticate(token, c.IP())
+		if err != nil {
+			return domain.ErrorResponse(c, fiber.StatusUnauthorized, "访问令牌无效或已过期", err)
+		}
+
+		c.Locals("user", &jwt.Token{
+			Valid: true,
+			Claims: jwt.MapClaims{
+				"user_id": accessToken.UserID.String(),
+				"pat":     accessToken.ID.String(),
+				"scopes":  accessToken.Scopes,
+			},
+		})
+		c.Locals(accessTokenKey, true)
+		return c.Next()
+	}
+}
+
+// IsAccessToken 当前请求是否使用个人访问令牌认证，用于跳过 JWT 中间件
+func IsAccessToken(c *fiber.Ctx) bool {
+	ok, _ := c.Locals(accessTokenKey).(bool)
+	return ok
+}
+
+// RequireSession 拒绝使用个人访问令牌的请求，用于令牌管理、修改密码等账号安全相关的接口
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if IsAccessToken(c) {
+			return domain.ErrorResponse(c, fiber.StatusForbidden, "个人访问令牌不能访问该接口", ErrSessionRequired)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package tokenauth
+
+import (
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// New 拒绝已登出或会话已吊销的访问令牌，需要放在 JWT 中间件之后
+func New(tokenService services.TokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 个人访问令牌没有会话，已在 AccessToken 中校验
+		if IsAccessToken(c) {
+			return c.Next()
+		}
+
+		token := c.Locals("user").(*jwt.Token)
+		claims := token.Claims.(jwt.MapClaims)
+
+		if err := tokenService.Validate(claims); err != nil {
+			return domain.ErrorResponse(c, fiber.StatusUnauthorized, "您的登录状态已失效，请重新登录", err)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccessToken 个人访问令牌，供脚本和客户端调用管理接口，只保存 SHA-256 摘要
+type AccessToken struct {
+	ID     uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
+	UserID uuid.UUID `json:"userId" gorm:"type:char(36);index;not null"`
+	Name   string    `json:"name" gorm:"size:64;not null"`
+	// 令牌开头的几个字符，用于在列表中辨认令牌
+	Prefix    string `json:"prefix" gorm:"size:16;not null"`
+	TokenHash string `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	// 令牌可以使用的权限，实际权限为用户权限与 Scopes 的交集，* 表示用户的全部权限
+	Scopes []string `json:"scopes" gorm:"serializer:json;type:text"`
+	// 过期时间，为空表示永不过期
+	ExpiresAt  *CustomTime `json:"expiresAt" gorm:"index"`
+	LastUsedAt *CustomTime `json:"lastUsedAt"`
+	LastUsedIP string      `json:"lastUsedIp" gorm:"size:64"`
+
+	CommonNotDeletedModel
+}
+
+func (t *AccessToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
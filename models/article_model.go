@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,15 +12,28 @@ type ArticleStatus uint8
 const (
 	StatusDraft ArticleStatus = iota
 	StatusPublished
+	// StatusScheduled 已设置发布时间，等待定时发布
+	StatusScheduled
+	// StatusInReview 已提交审核
+	StatusInReview
+	// StatusApproved 审核通过，等待发布
+	StatusApproved
+	// StatusRejected 审核被驳回
+	StatusRejected
 )
 
 type Article struct {
 	ID          uuid.UUID     `json:"id" gorm:"primary_key;type:char(36)"`
 	Title       string        `json:"title" gorm:"not null;unique"`
+	Slug        string        `json:"slug" gorm:"size:191;uniqueIndex"`
 	Description string        `json:"description"`
 	Content     string        `json:"content" gorm:"type:mediumtext"`
 	Status      ArticleStatus `json:"status"`
 
+	// 定时发布、下线时间，为空表示不限制
+	PublishAt   *CustomTime `json:"publishAt" gorm:"index"`
+	UnpublishAt *CustomTime `json:"unpublishAt" gorm:"index"`
+
 	CategoryID uuid.UUID `json:"categoryId"`
 
 	Images []*Image `json:"images" gorm:"many2many:article_images"`
@@ -27,6 +42,9 @@ type Article struct {
 
 	UserID uuid.UUID `json:"userId"`
 
+	// 指定的审核人，为空表示任何审核人都可以审核
+	ReviewerID *uuid.UUID `json:"reviewerId"`
+
 	CommonModel
 }
 
@@ -36,3 +54,17 @@ func (a *Article) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// IsVisible 判断文章在指定时间是否处于公开状态
+func (a *Article) IsVisible(now time.Time) bool {
+	if a.Status != StatusPublished && a.Status != StatusScheduled {
+		return false
+	}
+	if a.PublishAt != nil && time.Time(*a.PublishAt).After(now) {
+		return false
+	}
+	if a.UnpublishAt != nil && !time.Time(*a.UnpublishAt).After(now) {
+		return false
+	}
+	return true
+}
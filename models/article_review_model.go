@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReviewAction string
+
+const (
+	ReviewActionSubmit   ReviewAction = "submit"
+	ReviewActionApprove  ReviewAction = "approve"
+	ReviewActionReject   ReviewAction = "reject"
+	ReviewActionPublish  ReviewAction = "publish"
+	ReviewActionWithdraw ReviewAction = "withdraw"
+)
+
+// ArticleReview 文章审核记录
+type ArticleReview struct {
+	ID         uuid.UUID     `json:"id" gorm:"primary_key;type:char(36)"`
+	ArticleID  uuid.UUID     `json:"articleId" gorm:"index;not null"`
+	UserID     uuid.UUID     `json:"userId"`
+	Action     ReviewAction  `json:"action" gorm:"size:16;not null"`
+	FromStatus ArticleStatus `json:"fromStatus"`
+	ToStatus   ArticleStatus `json:"toStatus"`
+	Comment    string        `json:"comment" gorm:"type:text"`
+
+	CommonNotDeletedModel
+}
+
+func (r *ArticleReview) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
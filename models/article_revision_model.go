@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ArticleRevision 文章修订版本，每次创建或更新文章时保存一份快照
+type ArticleRevision struct {
+	ID          uuid.UUID     `json:"id" gorm:"primary_key;type:char(36)"`
+	ArticleID   uuid.UUID     `json:"articleId" gorm:"uniqueIndex:idx_article_revision_version;not null"`
+	Version     uint          `json:"version" gorm:"uniqueIndex:idx_article_revision_version;not null"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Content     string        `json:"content,omitempty" gorm:"type:mediumtext"`
+	Status      ArticleStatus `json:"status"`
+	CategoryID  uuid.UUID     `json:"categoryId"`
+	TagIDs      UUIDs         `json:"tagIds" gorm:"type:text"`
+	ImageIDs    UUIDs         `json:"imageIds" gorm:"type:text"`
+
+	// 产生该版本的用户
+	UserID uuid.UUID `json:"userId"`
+
+	CommonNotDeletedModel
+}
+
+func (r *ArticleRevision) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
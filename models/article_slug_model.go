@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ArticleSlug 文章曾经使用过的 slug，用于旧链接跳转
+type ArticleSlug struct {
+	ID        uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
+	Slug      string    `json:"slug" gorm:"size:191;uniqueIndex;not null"`
+	ArticleID uuid.UUID `json:"articleId" gorm:"index;not null"`
+
+	CommonNotDeletedModel
+}
+
+func (s *ArticleSlug) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -45,3 +46,58 @@ func (ct CustomTime) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(t.Format("2006-01-02 15:04:05"))
 }
+
+func (ct *CustomTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*ct = CustomTime(time.Time{})
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	// 空字符串表示清空时间
+	if s == "" {
+		*ct = CustomTime(time.Time{})
+		return nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+	if err != nil {
+		return err
+	}
+	*ct = CustomTime(t)
+	return nil
+}
+
+// UUIDs 以 JSON 数组形式存储的 UUID 列表
+type UUIDs []uuid.UUID
+
+func (u *UUIDs) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*u = UUIDs{}
+		return nil
+	default:
+		return fmt.Errorf("failed to scan UUIDs: %v", value)
+	}
+	return json.Unmarshal(data, u)
+}
+
+func (u UUIDs) Value() (driver.Value, error) {
+	if u == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
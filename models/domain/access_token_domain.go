@@ -0,0 +1,18 @@
+package domain
+
+import "cms/models"
+
+type (
+	// 创建个人访问令牌参数
+	CreateAccessTokenParams struct {
+		Name   string   `json:"name" validate:"required,max=64"`
+		Scopes []string `json:"scopes" validate:"required,min=1"` // 权限编码，* 表示全部权限
+		// 有效天数，为空表示永不过期
+		ExpiresInDays *int `json:"expiresInDays" validate:"omitempty,min=1,max=3650"`
+	}
+	// 创建个人访问令牌响应，Token 只返回一次
+	CreateAccessTokenResponse struct {
+		models.AccessToken
+		Token string `json:"token"`
+	}
+)
@@ -12,27 +12,35 @@ type (
 		Page       int                   `json:"page" validate:"required,min=1"`
 		PageSize   int                   `json:"pageSize" validate:"required,min=1,max=100"`
 		Title      *string               `json:"title"`
-		Status     *models.ArticleStatus `json:"status" validate:"omitempty,oneof=0 1"`
+		Status     *models.ArticleStatus `json:"status" validate:"omitempty,oneof=0 1 2 3 4 5"`
 		CategoryID *uuid.UUID            `json:"categoryId"`
+		UserID     *uuid.UUID            `json:"userId"` // 作者
+		Mine       bool                  `json:"mine"`   // 只看自己的文章，优先于 userId
 	}
 
 	// 添加文章参数
 	CreateArticleParams struct {
 		Title       string               `json:"title" validate:"required"`
+		Slug        *string              `json:"slug" validate:"omitempty,max=80"` // 为空时根据标题生成
 		Description string               `json:"description" validate:"required"`
 		Content     string               `json:"content" validate:"required"`
 		CategoryID  uuid.UUID            `json:"categoryId" validate:"required"`
-		Status      models.ArticleStatus `json:"status" validate:"oneof=0 1"`
+		Status      models.ArticleStatus `json:"status" validate:"oneof=0 1 2"`
+		PublishAt   *models.CustomTime   `json:"publishAt"`
+		UnpublishAt *models.CustomTime   `json:"unpublishAt"`
 		ImageIds    []uuid.UUID          `json:"imageIds"`
 		TagIds      []uuid.UUID          `json:"tagIds"`
 	}
 	// 修改文章参数
 	UpdateArticleParams struct {
 		Title       *string               `json:"title"`
+		Slug        *string               `json:"slug" validate:"omitempty,max=80"` // 为空且标题变化时根据新标题重新生成
 		Description *string               `json:"description"`
 		Content     *string               `json:"content"`
 		CategoryID  *uuid.UUID            `json:"categoryId"`
-		Status      *models.ArticleStatus `json:"status"`
+		Status      *models.ArticleStatus `json:"status" validate:"omitempty,oneof=0 1 2"`
+		PublishAt   *models.CustomTime    `json:"publishAt"`   // 传空字符串表示清除定时
+		UnpublishAt *models.CustomTime    `json:"unpublishAt"` // 传空字符串表示清除定时
 		ImageIds    []uuid.UUID           `json:"imageIds"`
 		TagIds      []uuid.UUID           `json:"tagIds"`
 	}
@@ -48,4 +56,9 @@ type (
 		Page     int `json:"page" validate:"required,min=1"`
 		PageSize int `json:"pageSize" validate:"required,min=1,max=100"`
 	}
+
+	// 转移文章参数
+	TransferArticleParams struct {
+		UserID uuid.UUID `json:"userId" validate:"required"`
+	}
 )
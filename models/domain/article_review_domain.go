@@ -0,0 +1,32 @@
+package domain
+
+import "github.com/google/uuid"
+
+type (
+	// 提交审核参数
+	SubmitArticleReviewParams struct {
+		ReviewerID *uuid.UUID `json:"reviewerId"`
+		Comment    string     `json:"comment"`
+	}
+
+	// 审核通过参数
+	ApproveArticleParams struct {
+		Comment string `json:"comment"`
+	}
+
+	// 驳回参数
+	RejectArticleParams struct {
+		Comment string `json:"comment" validate:"required"`
+	}
+
+	// 撤回参数
+	WithdrawArticleParams struct {
+		Comment string `json:"comment"`
+	}
+
+	// 获取待审核列表参数
+	GetReviewQueueParams struct {
+		Page     int `json:"page" validate:"required,min=1"`
+		PageSize int `json:"pageSize" validate:"required,min=1,max=100"`
+	}
+)
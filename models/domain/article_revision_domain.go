@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"cms/models"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// 比较文章修订版本参数
+	DiffArticleRevisionsParams struct {
+		From uuid.UUID `json:"from" validate:"required"`
+		To   uuid.UUID `json:"to" validate:"required"`
+	}
+
+	// 字段差异
+	ArticleRevisionFieldDiff struct {
+		Field string `json:"field"`
+		From  any    `json:"from"`
+		To    any    `json:"to"`
+	}
+
+	// 比较文章修订版本响应
+	DiffArticleRevisionsResponse struct {
+		From   *models.ArticleRevision     `json:"from"`
+		To     *models.ArticleRevision     `json:"to"`
+		Fields []*ArticleRevisionFieldDiff `json:"fields"`
+	}
+)
@@ -0,0 +1,23 @@
+package domain
+
+type (
+	// 获取验证码参数
+	GetCaptchaParams struct {
+		Mode string `query:"mode" validate:"omitempty,oneof=image math"` // 为空时使用 CAPTCHA_MODE
+	}
+	// 验证码
+	CaptchaResponse struct {
+		ID        string `json:"id"`
+		Image     string `json:"image"`     // data:image/png;base64,...
+		ExpiresIn int64  `json:"expiresIn"` // 有效期（秒）
+	}
+
+	// 查询是否需要验证码参数
+	CaptchaRequiredParams struct {
+		Username string `query:"username"`
+	}
+	// 是否需要验证码
+	CaptchaRequiredResponse struct {
+		Required bool `json:"required"`
+	}
+)
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -27,6 +29,12 @@ func SuccessResponse(ctx *fiber.Ctx, data any, message string) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
+// DetailedError 携带详情的错误，例如未通过的校验规则，ErrorResponse 会将详情写入 data
+type DetailedError interface {
+	error
+	Details() any
+}
+
 func ErrorResponse(ctx *fiber.Ctx, code int, message string, err error) error {
 	resp := Response{
 		Code:    code,
@@ -35,5 +43,10 @@ func ErrorResponse(ctx *fiber.Ctx, code int, message string, err error) error {
 		Data:    nil,
 	}
 
+	var detailed DetailedError
+	if errors.As(err, &detailed) {
+		resp.Data = detailed.Details()
+	}
+
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
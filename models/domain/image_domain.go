@@ -7,8 +7,52 @@ type (
 	CreateImageParams struct {
 		Title string `json:"title" validate:"required"`
 		Hash  uint64 `json:"hash,string" validate:"required"`
+
+		MimeType      string `json:"mimeType"`
+		Width         int    `json:"width"`
+		Height        int    `json:"height"`
+		Size          int64  `json:"size"`
+		DominantColor string `json:"dominantColor"`
+		Placeholder   string `json:"placeholder"`
+	}
+
+	// 下载图片参数，全部为空时返回原图
+	ImageVariantParams struct {
+		Width   int    `query:"w" validate:"min=0"`
+		Height  int    `query:"h" validate:"min=0"`
+		Fit     string `query:"fit" validate:"omitempty,oneof=cover contain"` // 默认 contain
+		Quality int    `query:"q" validate:"min=0,max=100"`                   // 只对 JPEG 有效
+		Format  string `query:"format" validate:"omitempty,oneof=webp jpeg png"`
 	}
 
-	// 添加图片响应
-	CreateImageResponse []models.Image
+	// 单个文件的上传结果
+	ImageUploadResult struct {
+		Filename string        `json:"filename"`
+		Status   string        `json:"status"`           // created、exists、rejected
+		Reason   string        `json:"reason,omitempty"` // 被拒绝的原因
+		Image    *models.Image `json:"image,omitempty"`
+	}
+
+	// 添加图片响应，按上传顺序返回每个文件的结果
+	CreateImageResponse []ImageUploadResult
+
+	// ImageRejectedError 全部文件都未通过校验，详情为每个文件的结果
+	ImageRejectedError struct {
+		Results CreateImageResponse
+	}
+)
+
+// 图片上传结果
+const (
+	ImageUploadCreated  = "created"
+	ImageUploadExists   = "exists"
+	ImageUploadRejected = "rejected"
 )
+
+func (e *ImageRejectedError) Error() string {
+	return "图片均未通过校验"
+}
+
+func (e *ImageRejectedError) Details() any {
+	return e.Results
+}
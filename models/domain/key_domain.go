@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+type SigningKeyResponse struct {
+	ID        string     `json:"id"`
+	Active    bool       `json:"active"` // 是否用于签名
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt"`
+}
@@ -0,0 +1,51 @@
+package domain
+
+type (
+	// 密码验证通过后需要两步验证时的登录响应
+	MFAChallengeResponse struct {
+		MFARequired    bool   `json:"mfaRequired"`
+		ChallengeToken string `json:"challengeToken"`
+		ExpiresIn      int64  `json:"expiresIn"` // 挑战有效期（秒）
+		// 角色要求两步验证但用户尚未绑定，需要先绑定验证器
+		EnrollRequired bool `json:"enrollRequired"`
+	}
+
+	// 绑定验证器的密钥和扫码地址
+	MFAEnrollResponse struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"` // otpauth:// 地址，用于生成二维码
+	}
+
+	// 两步验证状态
+	MFAStatusResponse struct {
+		Enabled       bool  `json:"enabled"`
+		Required      bool  `json:"required"`      // 角色要求开启
+		RecoveryCodes int64 `json:"recoveryCodes"` // 剩余可用的恢复码数量
+	}
+
+	// 恢复码，只在生成时返回一次
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+
+	// 验证码参数，可以填写验证器中的验证码或恢复码
+	MFACodeParams struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	// 登录时绑定验证器参数
+	MFAChallengeParams struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+	}
+
+	// 登录时两步验证参数
+	VerifyMFAParams struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+		Code           string `json:"code" validate:"required"`
+	}
+
+	// 设置角色是否要求两步验证参数
+	SetRoleMFAParams struct {
+		RequireMFA bool `json:"requireMfa"`
+	}
+)
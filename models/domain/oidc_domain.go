@@ -0,0 +1,20 @@
+package domain
+
+type (
+	// 单点登录参数
+	OIDCLoginParams struct {
+		// 登录完成后跳转的前端地址，必须在 OIDC_ALLOWED_REDIRECTS 中，为空时使用第一个
+		Redirect string `query:"redirect"`
+	}
+	// 身份提供方回调参数
+	OIDCCallbackParams struct {
+		Code             string `query:"code"`
+		State            string `query:"state" validate:"required"`
+		Error            string `query:"error"`
+		ErrorDescription string `query:"error_description"`
+	}
+	// 使用一次性登录码换取访问令牌参数
+	OIDCTokenParams struct {
+		Code string `json:"code" validate:"required"`
+	}
+)
@@ -0,0 +1,22 @@
+package domain
+
+import "github.com/google/uuid"
+
+type (
+	// 添加角色参数
+	CreateRoleParams struct {
+		Name        string   `json:"name" validate:"required"`
+		Description *string  `json:"description"`
+		Permissions []string `json:"permissions"` // 权限编码
+	}
+	// 修改角色参数
+	UpdateRoleParams struct {
+		Name        *string  `json:"name"`
+		Description *string  `json:"description"`
+		Permissions []string `json:"permissions"` // 为空时不修改
+	}
+	// 设置用户角色参数
+	SetUserRolesParams struct {
+		RoleIDs []uuid.UUID `json:"roleIds"`
+	}
+)
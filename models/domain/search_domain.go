@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"cms/models"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// 搜索文章参数
+	SearchArticlesParams struct {
+		Q          string     `json:"q" validate:"required,max=100"`
+		CategoryID *uuid.UUID `json:"categoryId"`
+		TagID      *uuid.UUID `json:"tagId"`
+		Page       int        `json:"page" validate:"required,min=1"`
+		PageSize   int        `json:"pageSize" validate:"required,min=1,max=100"`
+	}
+
+	// 后台搜索文章参数
+	AdminSearchArticlesParams struct {
+		SearchArticlesParams
+		Status *models.ArticleStatus `json:"status" validate:"omitempty,oneof=0 1 2 3 4 5"`
+	}
+
+	// 搜索命中的文章
+	ArticleSearchHit struct {
+		Article *models.Article `json:"article"`
+		Score   float64         `json:"score"`
+		// 高亮后的标题，命中的词包裹在 <em> 中
+		Title string `json:"title"`
+		// 高亮后的正文片段
+		Snippet string `json:"snippet"`
+	}
+)
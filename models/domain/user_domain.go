@@ -11,12 +11,28 @@ type (
 	LoginParams struct {
 		Username string `json:"username" validate:"required"`
 		Password string `json:"password" validate:"required"` // 密码
-		// Captcha   string `json:"captcha" validate:"required"` // 验证码
+		// 登录失败次数达到 CAPTCHA_AFTER_FAILURES 后必须填写验证码
+		CaptchaID string `json:"captchaId"`
+		Captcha   string `json:"captcha"` // 验证码
 	}
 	// 用户登录响应
 	LoginResponse struct {
 		models.User
-		Token string `json:"token"` // token
+		TokenPair
+		// 登录时绑定验证器生成的恢复码，只返回一次
+		RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	}
+
+	// 访问令牌与刷新令牌
+	TokenPair struct {
+		Token        string `json:"token"`        // 访问令牌
+		RefreshToken string `json:"refreshToken"` // 刷新令牌，每次刷新后更换
+		ExpiresIn    int64  `json:"expiresIn"`    // 访问令牌有效期（秒）
+	}
+
+	// 刷新令牌参数
+	RefreshTokenParams struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
 	}
 
 	// 添加用户参数
@@ -24,6 +40,7 @@ type (
 		Nickname string     `json:"nickname" validate:"required"`
 		Phone    string     `json:"phone" validate:"required"`
 		Username string     `json:"username" validate:"required"`
+		Email    *string    `json:"email" validate:"omitempty,email"`
 		Password string     `json:"password" validate:"required"`
 		ImageID  *uuid.UUID `json:"imageId"`
 	}
@@ -33,7 +50,41 @@ type (
 		Nickname *string    `json:"nickname"`
 		Phone    *string    `json:"phone"`
 		Username *string    `json:"username"`
+		Email    *string    `json:"email" validate:"omitempty,email"`
 		Password *string    `json:"password"`
 		ImageID  *uuid.UUID `json:"imageId"`
 	}
+
+	// 修改个人资料参数
+	UpdateProfileParams struct {
+		Nickname *string    `json:"nickname" validate:"omitempty,min=1"`
+		Email    *string    `json:"email" validate:"omitempty,email"`
+		ImageID  *uuid.UUID `json:"imageId"` // 头像
+	}
+
+	// 修改密码参数
+	ChangePasswordParams struct {
+		OldPassword string `json:"oldPassword" validate:"required"`
+		NewPassword string `json:"newPassword" validate:"required"`
+	}
+
+	// 找回密码：获取验证码参数
+	RequestPasswordResetParams struct {
+		Account string `json:"account" validate:"required"` // 用户名或手机号
+	}
+	// 找回密码：校验验证码参数
+	VerifyPasswordResetParams struct {
+		Account string `json:"account" validate:"required"`
+		Code    string `json:"code" validate:"required"`
+	}
+	// 找回密码：校验通过后返回的重置令牌
+	PasswordResetTokenResponse struct {
+		ResetToken string `json:"resetToken"`
+		ExpiresIn  int64  `json:"expiresIn"` // 有效期（秒）
+	}
+	// 找回密码：设置新密码参数
+	ResetPasswordParams struct {
+		ResetToken  string `json:"resetToken" validate:"required"`
+		NewPassword string `json:"newPassword" validate:"required"`
+	}
 )
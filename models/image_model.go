@@ -8,7 +8,18 @@ import (
 type Image struct {
 	ID    uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
 	Title string    `json:"title"`
-	Hash  uint64    `json:"hash,string" gorm:"uniqueIndex;not null"`
+	// 处理后保存的文件内容的 xxHash64，存储中的文件名由它生成，见 services.ImageHash
+	Hash uint64 `json:"hash,string" gorm:"uniqueIndex;not null"`
+
+	// 根据文件头判断的 MIME 类型
+	MimeType string `json:"mimeType" gorm:"size:64"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"` // 字节数
+	// 主色，格式为 #rrggbb，可以作为图片加载前的背景色
+	DominantColor string `json:"dominantColor" gorm:"size:7"`
+	// BlurHash 占位符
+	Placeholder string `json:"placeholder" gorm:"size:64"`
 
 	Articles []*Article `json:"articles" gorm:"many2many:article_images"`
 
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode 两步验证恢复码，只保存 SHA-256 摘要，每个只能使用一次
+type RecoveryCode struct {
+	ID       uuid.UUID   `json:"id" gorm:"primary_key;type:char(36)"`
+	UserID   uuid.UUID   `json:"userId" gorm:"type:char(36);index;not null"`
+	CodeHash string      `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	UsedAt   *CustomTime `json:"usedAt"`
+
+	CommonNotDeletedModel
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
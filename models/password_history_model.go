@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory 用户使用过的密码哈希，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	ID       uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
+	UserID   uuid.UUID `json:"userId" gorm:"type:char(36);index;not null"`
+	Password string    `json:"-" gorm:"not null"`
+
+	CommonNotDeletedModel
+}
+
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 权限编码
+const (
+	PermCategoryWrite = "category:write"
+	PermArticleWrite  = "article:write"
+	PermArticleDelete = "article:delete"
+	// PermArticleManage 修改、删除他人的文章
+	PermArticleManage = "article:manage"
+	// PermArticlePublish 发布文章，开启审核流程时只能发布审核通过的文章
+	PermArticlePublish = "article:publish"
+	// PermArticleReview 审核文章
+	PermArticleReview = "article:review"
+	PermImageUpload   = "image:upload"
+	PermImageDelete   = "image:delete"
+	PermTagWrite      = "tag:write"
+	PermDictWrite     = "dict:write"
+	PermUserRead      = "user:read"
+	PermUserWrite     = "user:write"
+	PermRoleWrite     = "role:write"
+	PermCacheManage   = "cache:manage"
+	PermKeyManage     = "key:manage"
+
+	// PermAll 拥有全部权限，超级管理员使用
+	PermAll = "*"
+)
+
+// Permissions 系统内置的全部权限，启动时写入数据库
+var Permissions = []*Permission{
+	{Code: PermCategoryWrite, Name: "管理分类"},
+	{Code: PermArticleWrite, Name: "编辑文章"},
+	{Code: PermArticleDelete, Name: "删除文章"},
+	{Code: PermArticleManage, Name: "管理他人文章"},
+	{Code: PermArticlePublish, Name: "发布文章"},
+	{Code: PermArticleReview, Name: "审核文章"},
+	{Code: PermImageUpload, Name: "上传图片"},
+	{Code: PermImageDelete, Name: "删除图片"},
+	{Code: PermTagWrite, Name: "管理标签"},
+	{Code: PermDictWrite, Name: "管理字典"},
+	{Code: PermUserRead, Name: "查看用户"},
+	{Code: PermUserWrite, Name: "管理用户"},
+	{Code: PermRoleWrite, Name: "管理角色"},
+	{Code: PermCacheManage, Name: "管理缓存"},
+	{Code: PermKeyManage, Name: "管理签名密钥"},
+}
+
+type Permission struct {
+	Code string `json:"code" gorm:"primary_key;size:64"`
+	Name string `json:"name" gorm:"not null"`
+}
+
+type Role struct {
+	ID          uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
+	Name        string    `json:"name" gorm:"not null;unique"`
+	Description string    `json:"description"`
+	// 拥有该角色的用户必须开启两步验证
+	RequireMFA bool `json:"requireMfa" gorm:"not null;default:false"`
+
+	Permissions []*Permission `json:"permissions" gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+
+	Users []*User `json:"-" gorm:"many2many:user_roles;constraint:OnDelete:CASCADE"`
+
+	CommonModel
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
@@ -3,6 +3,7 @@ package scopes
 import (
 	"cms/models"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -13,6 +14,8 @@ type (
 		Title(title *string) func(*gorm.DB) *gorm.DB
 		Category(categoryID *uuid.UUID) func(*gorm.DB) *gorm.DB
 		Status(status *models.ArticleStatus) func(*gorm.DB) *gorm.DB
+		Author(userID *uuid.UUID) func(*gorm.DB) *gorm.DB
+		Visible(now time.Time) func(*gorm.DB) *gorm.DB
 	}
 	articleScope struct {
 		db *gorm.DB
@@ -49,3 +52,21 @@ func (s *articleScope) Status(status *models.ArticleStatus) func(*gorm.DB) *gorm
 		return db
 	}
 }
+
+func (s *articleScope) Author(userID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if userID != nil {
+			return db.Where("user_id = ?", *userID)
+		}
+		return db
+	}
+}
+
+// Visible 在指定时间处于公开状态的文章，即使定时任务尚未执行也按发布窗口过滤
+func (s *articleScope) Visible(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status IN ?", []models.ArticleStatus{models.StatusPublished, models.StatusScheduled}).
+			Where("publish_at IS NULL OR publish_at <= ?", now).
+			Where("unpublish_at IS NULL OR unpublish_at > ?", now)
+	}
+}
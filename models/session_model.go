@@ -0,0 +1,54 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session 一次登录产生的会话，同一会话中的刷新令牌依次轮换
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"primary_key;type:char(36)"`
+	UserID     uuid.UUID  `json:"userId" gorm:"type:char(36);index;not null"`
+	UserAgent  string     `json:"userAgent" gorm:"size:255"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	ExpiresAt  CustomTime `json:"expiresAt" gorm:"index"`
+	LastUsedAt CustomTime `json:"lastUsedAt"`
+	// 吊销时间，为空表示会话有效
+	RevokedAt *CustomTime `json:"revokedAt" gorm:"index"`
+
+	CommonNotDeletedModel
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// RefreshToken 刷新令牌，只保存 SHA-256 摘要
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"primary_key;type:char(36)"`
+	SessionID uuid.UUID  `json:"sessionId" gorm:"type:char(36);index;not null"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt CustomTime `json:"expiresAt" gorm:"index"`
+	// 使用时间，已使用的令牌再次出现说明令牌被盗用
+	UsedAt *CustomTime `json:"usedAt"`
+
+	CommonNotDeletedModel
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// RevokedToken 已吊销的访问令牌，过期后删除
+type RevokedToken struct {
+	JTI       string     `json:"jti" gorm:"primary_key;size:36"`
+	ExpiresAt CustomTime `json:"expiresAt" gorm:"index"`
+
+	CommonNotDeletedModel
+}
@@ -0,0 +1,12 @@
+package models
+
+// SigningKey JWT 签名密钥，KEY_STORE=database 时使用
+type SigningKey struct {
+	ID         string `json:"id" gorm:"primary_key;size:64"` // kid
+	PrivateKey string `json:"-" gorm:"type:text;not null"`   // PKCS#8 PEM
+
+	// 轮换下线时间，为空表示当前可用于签名
+	RetiredAt *CustomTime `json:"retiredAt" gorm:"index"`
+
+	CommonNotDeletedModel
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity 用户在外部身份提供方的账号，通过 OIDC 登录时按签发方和 sub 查找用户
+type UserIdentity struct {
+	ID      uuid.UUID `json:"id" gorm:"primary_key;type:char(36)"`
+	UserID  uuid.UUID `json:"userId" gorm:"type:char(36);index;not null"`
+	Issuer  string    `json:"issuer" gorm:"size:191;uniqueIndex:idx_user_identity_subject;not null"`
+	Subject string    `json:"subject" gorm:"size:191;uniqueIndex:idx_user_identity_subject;not null"`
+	// 最近一次登录时身份提供方返回的邮箱
+	Email       string      `json:"email" gorm:"size:191"`
+	LastLoginAt *CustomTime `json:"lastLoginAt"`
+
+	CommonNotDeletedModel
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
@@ -13,10 +13,24 @@ type User struct {
 	Password string    `json:"-" gorm:"not null"`
 	IsSuper  bool      `json:"isSuper" gorm:"not null"`
 
+	// 邮箱可以为空，用于接收找回密码等邮件通知
+	Email *string `json:"email" gorm:"size:191;uniqueIndex"`
+
+	// 登录失败次数过多时锁定到该时间
+	LockedUntil *CustomTime `json:"lockedUntil"`
+
+	// 两步验证密钥，绑定确认前也会保存，MFAEnabled 为 true 时才生效
+	TOTPSecret string `json:"-" gorm:"size:64"`
+	MFAEnabled bool   `json:"mfaEnabled" gorm:"not null;default:false"`
+	// 最近一次使用的验证码时间步，防止验证码被重放
+	TOTPLastStep int64 `json:"-" gorm:"not null;default:0"`
+
 	ImageID *uuid.UUID `json:"imageId"`
 
 	Articles []*Article `json:"articles"`
 
+	Roles []*Role `json:"roles" gorm:"many2many:user_roles"`
+
 	CommonModel
 }
 
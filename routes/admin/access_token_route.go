@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type (
+	// AccessTokenRoute 当前用户的个人访问令牌
+	AccessTokenRoute interface {
+		RegisterRoutes()
+		getAccessTokens(c *fiber.Ctx) error
+		createAccessToken(c *fiber.Ctx) error
+		deleteAccessToken(c *fiber.Ctx) error
+	}
+	accessTokenRoute struct {
+		app                fiber.Router
+		validator          *validator.Validate
+		accessTokenService services.AccessTokenService
+	}
+)
+
+func NewAccessTokenRoute(app fiber.Router, accessTokenService services.AccessTokenService, validator *validator.Validate) AccessTokenRoute {
+	return &accessTokenRoute{
+		app:                app,
+		validator:          validator,
+		accessTokenService: accessTokenService,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (r *accessTokenRoute) RegisterRoutes() {
+	r.app.Get("/", r.getAccessTokens)
+	r.app.Post("/", r.createAccessToken)
+	r.app.Delete("/:id<guid>", r.deleteAccessToken)
+}
+
+// 获取个人访问令牌列表
+func (r *accessTokenRoute) getAccessTokens(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	tokens, err := r.accessTokenService.GetAccessTokens(userID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取访问令牌失败", err)
+	}
+	return domain.SuccessResponse(c, tokens, "获取访问令牌成功")
+}
+
+// 创建个人访问令牌
+func (r *accessTokenRoute) createAccessToken(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.CreateAccessTokenParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := r.accessTokenService.Create(userID, *params)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "创建访问令牌失败", err)
+	}
+	return domain.SuccessResponse(c, res, "创建访问令牌成功，令牌只显示一次，请妥善保存")
+}
+
+// 删除个人访问令牌
+func (r *accessTokenRoute) deleteAccessToken(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	if err := r.accessTokenService.Delete(&userID, id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除访问令牌失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "删除访问令牌成功")
+}
@@ -1,12 +1,13 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/middleware/tokenauth"
 	"cms/models/domain"
 	"cms/services"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -14,30 +15,142 @@ type (
 	AccountRoute interface {
 		RegisterRoutes()
 		logout(c *fiber.Ctx) error
+		logoutAll(c *fiber.Ctx) error
+		getSessions(c *fiber.Ctx) error
+		getPermissions(c *fiber.Ctx) error
+		getProfile(c *fiber.Ctx) error
+		updateProfile(c *fiber.Ctx) error
+		changePassword(c *fiber.Ctx) error
 	}
 	accountRoute struct {
-		app         fiber.Router
-		validator   *validator.Validate
-		userService services.UserService
+		app          fiber.Router
+		validator    *validator.Validate
+		userService  services.UserService
+		tokenService services.TokenService
 	}
 )
 
-func NewAccountRoute(app fiber.Router, userService services.UserService, validator *validator.Validate) AccountRoute {
+func NewAccountRoute(app fiber.Router, userService services.UserService, tokenService services.TokenService, validator *validator.Validate) AccountRoute {
 	return &accountRoute{
-		app:         app,
-		validator:   validator,
-		userService: userService,
+		app:          app,
+		validator:    validator,
+		userService:  userService,
+		tokenService: tokenService,
 	}
 }
 
 // RegisterRoutes 注册路由
 func (ar *accountRoute) RegisterRoutes() {
-	ar.app.Post("/logout", ar.logout)
+	// 会话相关接口需要登录后的访问令牌
+	ar.app.Post("/logout", tokenauth.RequireSession(), ar.logout)
+	ar.app.Post("/logoutAll", tokenauth.RequireSession(), ar.logoutAll)
+	ar.app.Get("/sessions", tokenauth.RequireSession(), ar.getSessions)
+	ar.app.Get("/permissions", ar.getPermissions)
+	ar.app.Get("/profile", ar.getProfile)
+	ar.app.Put("/profile", ar.updateProfile)
+	ar.app.Post("/password", tokenauth.RequireSession(), ar.changePassword)
 }
 
-// 登出
+// 登出，吊销当前访问令牌及其会话
 func (ar *accountRoute) logout(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
-	log.Info("用户 %s 登出", user)
+	if err := ar.tokenService.Revoke(user.Claims.(jwt.MapClaims)); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "登出失败", err)
+	}
 	return domain.SuccessResponse(c, fiber.Map{}, "登出成功")
 }
+
+// 退出全部设备
+func (ar *accountRoute) logoutAll(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := ar.tokenService.RevokeUser(userID); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "登出失败", err)
+	}
+	return domain.SuccessResponse(c, fiber.Map{}, "已退出全部设备")
+}
+
+// 获取当前用户的有效会话
+func (ar *accountRoute) getSessions(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	sessions, err := ar.tokenService.GetSessions(userID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取会话失败", err)
+	}
+	return domain.SuccessResponse(c, sessions, "获取会话成功")
+}
+
+// 获取当前用户的权限
+func (ar *accountRoute) getPermissions(c *fiber.Ctx) error {
+	return domain.SuccessResponse(c, roleauth.Permissions(c), "获取权限成功")
+}
+
+// 获取个人资料
+func (ar *accountRoute) getProfile(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	user, err := ar.userService.GetProfile(userID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取个人资料失败", err)
+	}
+	return domain.SuccessResponse(c, user, "获取个人资料成功")
+}
+
+// 修改个人资料
+func (ar *accountRoute) updateProfile(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.UpdateProfileParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ar.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := ar.userService.UpdateProfile(userID, *params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "修改个人资料失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "修改个人资料成功")
+}
+
+// 修改密码，其他设备上的登录随即失效
+func (ar *accountRoute) changePassword(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.ChangePasswordParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ar.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := ar.userService.ChangePassword(userID, *params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "修改密码失败", err)
+	}
+
+	user := c.Locals("user").(*jwt.Token)
+	if err := ar.tokenService.RevokeOthers(user.Claims.(jwt.MapClaims)); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "吊销其他会话失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "修改密码成功")
+}
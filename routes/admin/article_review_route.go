@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"cms/middleware/roleauth"
+	"cms/models"
+	"cms/models/domain"
+	"cms/services"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type (
+	ArticleReviewRoute interface {
+		RegisterRoutes()
+		getReviewQueue(c *fiber.Ctx) error
+		getReviews(c *fiber.Ctx) error
+		submit(c *fiber.Ctx) error
+		approve(c *fiber.Ctx) error
+		reject(c *fiber.Ctx) error
+		publish(c *fiber.Ctx) error
+		withdraw(c *fiber.Ctx) error
+	}
+	articleReviewRoute struct {
+		app                  fiber.Router
+		articleReviewService services.ArticleReviewService
+		validator            *validator.Validate
+	}
+)
+
+func NewArticleReviewRoute(app fiber.Router, articleReviewService services.ArticleReviewService, validator *validator.Validate) ArticleReviewRoute {
+	return &articleReviewRoute{
+		app,
+		articleReviewService,
+		validator,
+	}
+}
+
+// 注册
+func (r *articleReviewRoute) RegisterRoutes() {
+	r.app.Get("/reviewQueue", r.getReviewQueue)
+	r.app.Get("/:id<guid>/reviews", r.getReviews)
+	r.app.Post("/:id<guid>/submit", roleauth.Require(models.PermArticleWrite), r.submit)
+	r.app.Post("/:id<guid>/approve", roleauth.Require(models.PermArticleReview), r.approve)
+	r.app.Post("/:id<guid>/reject", roleauth.Require(models.PermArticleReview), r.reject)
+	r.app.Post("/:id<guid>/publish", roleauth.Require(models.PermArticlePublish), r.publish)
+	r.app.Post("/:id<guid>/withdraw", roleauth.Require(models.PermArticleWrite), r.withdraw)
+}
+
+// 获取我的待审核文章列表
+func (r *articleReviewRoute) getReviewQueue(c *fiber.Ctx) error {
+	params := new(domain.GetReviewQueueParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	res, err := r.articleReviewService.GetReviewQueue(userID, *params)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取待审核列表失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取待审核列表成功")
+}
+
+// 获取文章审核记录
+func (r *articleReviewRoute) getReviews(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	res, err := r.articleReviewService.GetReviews(userID, id)
+	switch {
+	case errors.Is(err, services.ErrArticleNotOwner):
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限查看审核记录", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取审核记录失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取审核记录成功")
+}
+
+// 提交审核
+func (r *articleReviewRoute) submit(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.SubmitArticleReviewParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleReviewService.Submit(userID, id, *params); err != nil {
+		return transitionError(c, "提交审核失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "提交审核成功")
+}
+
+// 审核通过
+func (r *articleReviewRoute) approve(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.ApproveArticleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleReviewService.Approve(userID, id, *params); err != nil {
+		return transitionError(c, "审核通过失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "审核通过成功")
+}
+
+// 驳回
+func (r *articleReviewRoute) reject(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.RejectArticleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleReviewService.Reject(userID, id, *params); err != nil {
+		return transitionError(c, "驳回失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "驳回成功")
+}
+
+// 发布
+func (r *articleReviewRoute) publish(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleReviewService.Publish(userID, id); err != nil {
+		return transitionError(c, "发布失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "发布成功")
+}
+
+// 撤回
+func (r *articleReviewRoute) withdraw(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.WithdrawArticleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleReviewService.Withdraw(userID, id, *params); err != nil {
+		return transitionError(c, "撤回失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "撤回成功")
+}
+
+// transitionError 并发修改导致状态冲突时返回 409，客户端可以刷新后重试
+func transitionError(c *fiber.Ctx, message string, err error) error {
+	if errors.Is(err, services.ErrArticleStatusConflict) {
+		return domain.ErrorResponse(c, fiber.StatusConflict, message, err)
+	}
+	return domain.ErrorResponse(c, fiber.StatusInternalServerError, message, err)
+}
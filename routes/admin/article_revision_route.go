@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"cms/middleware/roleauth"
+	"cms/models"
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type (
+	ArticleRevisionRoute interface {
+		RegisterRoutes()
+		getRevisions(c *fiber.Ctx) error
+		getRevision(c *fiber.Ctx) error
+		diffRevisions(c *fiber.Ctx) error
+		restoreRevision(c *fiber.Ctx) error
+	}
+	articleRevisionRoute struct {
+		app                    fiber.Router
+		articleRevisionService services.ArticleRevisionService
+		validator              *validator.Validate
+	}
+)
+
+func NewArticleRevisionRoute(app fiber.Router, articleRevisionService services.ArticleRevisionService, validator *validator.Validate) ArticleRevisionRoute {
+	return &articleRevisionRoute{
+		app,
+		articleRevisionService,
+		validator,
+	}
+}
+
+// 注册
+func (r *articleRevisionRoute) RegisterRoutes() {
+	r.app.Get("/", r.getRevisions)
+	r.app.Get("/diff", r.diffRevisions)
+	r.app.Get("/:revisionId<guid>", r.getRevision)
+	r.app.Post("/:revisionId<guid>/restore", roleauth.Require(models.PermArticleWrite), r.restoreRevision)
+}
+
+// 获取文章修订版本列表
+func (r *articleRevisionRoute) getRevisions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	res, err := r.articleRevisionService.GetRevisions(id)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取修订版本列表失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取修订版本列表成功")
+}
+
+// 获取文章修订版本详情
+func (r *articleRevisionRoute) getRevision(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	revisionID, err := uuid.Parse(c.Params("revisionId"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析修订版本ID失败", err)
+	}
+
+	res, err := r.articleRevisionService.GetRevision(id, revisionID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取修订版本失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取修订版本成功")
+}
+
+// 比较两个修订版本
+func (r *articleRevisionRoute) diffRevisions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.DiffArticleRevisionsParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := r.articleRevisionService.DiffRevisions(id, *params)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "比较修订版本失败", err)
+	}
+	return domain.SuccessResponse(c, res, "比较修订版本成功")
+}
+
+// 恢复到指定修订版本
+func (r *articleRevisionRoute) restoreRevision(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	revisionID, err := uuid.Parse(c.Params("revisionId"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析修订版本ID失败", err)
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleRevisionService.RestoreRevision(userID, id, revisionID); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "恢复修订版本失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "恢复修订版本成功")
+}
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 	"errors"
@@ -23,18 +25,22 @@ type (
 		createArticle(c *fiber.Ctx) error
 		updateArticle(c *fiber.Ctx) error
 		deleteArticle(c *fiber.Ctx) error
+		transferArticle(c *fiber.Ctx) error
+		searchArticles(c *fiber.Ctx) error
 	}
 	articleRoute struct {
 		app            fiber.Router
 		articleService services.ArticleService
+		searchService  services.SearchService
 		validator      *validator.Validate
 	}
 )
 
-func NewArticleRoute(app fiber.Router, articleService services.ArticleService, validator *validator.Validate) ArticleRoute {
+func NewArticleRoute(app fiber.Router, articleService services.ArticleService, searchService services.SearchService, validator *validator.Validate) ArticleRoute {
 	return &articleRoute{
 		app,
 		articleService,
+		searchService,
 		validator,
 	}
 }
@@ -42,9 +48,12 @@ func NewArticleRoute(app fiber.Router, articleService services.ArticleService, v
 // 注册
 func (r *articleRoute) RegisterRoutes() {
 	r.app.Get("/", r.getArticles)
-	r.app.Post("/", r.createArticle)
-	r.app.Put("/:id<guid>", r.updateArticle)
-	r.app.Delete("/:id<guid>", r.deleteArticle)
+	r.app.Get("/search", r.searchArticles)
+	r.app.Post("/", roleauth.Require(models.PermArticleWrite), r.createArticle)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermArticleWrite), r.updateArticle)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermArticleDelete), r.deleteArticle)
+	// 只有超级管理员可以转移文章
+	r.app.Put("/:id<guid>/owner", roleauth.Require(models.PermAll), r.transferArticle)
 }
 
 // 获取文章列表
@@ -58,6 +67,14 @@ func (r *articleRoute) getArticles(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
 	}
 
+	if params.Mine {
+		userID, err := getUserID(c)
+		if err != nil {
+			return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+		}
+		params.UserID = &userID
+	}
+
 	res, err := r.articleService.GetArticles(*params)
 	if err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取文章列表失败", err)
@@ -76,11 +93,9 @@ func (r *articleRoute) createArticle(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
 	}
 
-	user := c.Locals("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	userID, err := uuid.Parse(claims["user_id"].(string))
+	userID, err := getUserID(c)
 	if err != nil {
-		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", ErrGetUserIDFailed)
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
 	}
 
 	if err := r.articleService.CreateArticle(userID, *params); err != nil {
@@ -105,7 +120,12 @@ func (r *articleRoute) updateArticle(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
 	}
 
-	if err := r.articleService.UpdateArticle(id, *body); err != nil {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleService.UpdateArticle(userID, id, *body); err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "更新文章失败", err)
 	}
 
@@ -119,8 +139,64 @@ func (r *articleRoute) deleteArticle(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
 	}
 
-	if err := r.articleService.DeleteArticle(id); err != nil {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	if err := r.articleService.DeleteArticle(userID, id); err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除文章失败", err)
 	}
 	return domain.SuccessResponse(c, nil, "删除文章成功")
 }
+
+// 转移文章给其他用户
+func (r *articleRoute) transferArticle(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.TransferArticleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := r.articleService.TransferArticle(id, *params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "转移文章失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "转移文章成功")
+}
+
+// 搜索文章
+func (r *articleRoute) searchArticles(c *fiber.Ctx) error {
+	params := new(domain.AdminSearchArticlesParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := r.searchService.AdminSearchArticles(*params)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "搜索文章失败", err)
+	}
+	return domain.SuccessResponse(c, res, "搜索文章成功")
+}
+
+// getUserID 从 JWT 中获取当前用户ID
+func getUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return uuid.Nil, ErrGetUserIDFailed
+	}
+	return userID, nil
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"cms/middleware/roleauth"
+	"cms/models"
+	"cms/models/domain"
+	"cms/utils/cache"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	CacheRoute interface {
+		RegisterRoutes()
+		getStats(c *fiber.Ctx) error
+		flush(c *fiber.Ctx) error
+	}
+	cacheRoute struct {
+		app   fiber.Router
+		cache cache.Cache
+	}
+)
+
+func NewCacheRoute(app fiber.Router, cache cache.Cache) CacheRoute {
+	return &cacheRoute{
+		app:   app,
+		cache: cache,
+	}
+}
+
+// 注册
+func (r *cacheRoute) RegisterRoutes() {
+	r.app.Get("/stats", roleauth.Require(models.PermCacheManage), r.getStats)
+	r.app.Delete("/", roleauth.Require(models.PermCacheManage), r.flush)
+}
+
+// 获取缓存命中统计
+func (r *cacheRoute) getStats(c *fiber.Ctx) error {
+	return domain.SuccessResponse(c, r.cache.Stats(), "获取缓存统计成功")
+}
+
+// 清空缓存，登录失败计数等保存在安全存储中，不受影响
+func (r *cacheRoute) flush(c *fiber.Ctx) error {
+	if err := r.cache.DeletePrefix(""); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "清空缓存失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "清空缓存成功")
+}
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 
@@ -35,9 +37,9 @@ func NewCategoryRoute(app fiber.Router, categoryService services.CategoryService
 // 注册
 func (r *categoryRoute) RegisterRoutes() {
 	r.app.Get("/", r.getCategorys)
-	r.app.Post("/", r.createCategory)
-	r.app.Put("/:id<guid>", r.updateCategory)
-	r.app.Delete("/:id<guid>", r.deleteCategory)
+	r.app.Post("/", roleauth.Require(models.PermCategoryWrite), r.createCategory)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermCategoryWrite), r.updateCategory)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermCategoryWrite), r.deleteCategory)
 }
 
 // 获取分类列表
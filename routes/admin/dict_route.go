@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 
@@ -35,9 +37,9 @@ func NewDictRoute(app fiber.Router, dictService services.DictService, validator
 // 注册
 func (r *dictRoute) RegisterRoutes() {
 	r.app.Get("/", r.getDicts)
-	r.app.Post("/", r.createDict)
-	r.app.Put("/:id<guid>", r.updateDict)
-	r.app.Delete("/:id<guid>", r.deleteDict)
+	r.app.Post("/", roleauth.Require(models.PermDictWrite), r.createDict)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermDictWrite), r.updateDict)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermDictWrite), r.deleteDict)
 }
 
 // 获取字典列表
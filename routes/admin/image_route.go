@@ -1,16 +1,17 @@
 package admin
 
 import (
+	"bytes"
+	"cms/config"
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path"
-	"strings"
+	"mime/multipart"
 
-	"github.com/cespare/xxhash/v2"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -20,6 +21,8 @@ import (
 var (
 	// ErrImageEmpty 图片为空
 	ErrImageEmpty = errors.New("图片为空")
+	// ErrTooManyImages 一次上传的图片过多
+	ErrTooManyImages = errors.New("一次上传的图片过多")
 )
 
 type (
@@ -33,37 +36,23 @@ type (
 		app          fiber.Router
 		imageService services.ImageService
 		validator    *validator.Validate
-		uploadPath   string
+		cfg          *config.ImageConfig
 	}
 )
 
-func NewImageRoute(app fiber.Router, imageService services.ImageService, validator *validator.Validate) ImageRoute {
-	// 判断文件夹是否存在
-	absPath, err := os.Getwd()
-	if err != nil {
-		panic(err)
-	}
-
-	uploadPath := path.Join(absPath, "uploads")
-	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
-		// 创建文件夹
-		if err := os.Mkdir(uploadPath, os.ModePerm); err != nil {
-			panic(err)
-		}
-	}
-
+func NewImageRoute(app fiber.Router, imageService services.ImageService, cfg *config.ImageConfig, validator *validator.Validate) ImageRoute {
 	return &imageRoute{
 		app:          app,
 		imageService: imageService,
 		validator:    validator,
-		uploadPath:   uploadPath,
+		cfg:          cfg,
 	}
 }
 
 func (ir *imageRoute) RegisterRoutes() {
 	ir.app.Get("/", ir.getImages)
-	ir.app.Post("/", ir.createImage)
-	ir.app.Delete("/:id<guid>", ir.deleteImage)
+	ir.app.Post("/", roleauth.Require(models.PermImageUpload), ir.createImage)
+	ir.app.Delete("/:id<guid>", roleauth.Require(models.PermImageDelete), ir.deleteImage)
 }
 
 func (ir *imageRoute) getImages(c *fiber.Ctx) error {
@@ -85,60 +74,107 @@ func (ir *imageRoute) createImage(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "图片为空", ErrImageEmpty)
 	}
 
-	res := make(domain.CreateImageResponse, 0)
+	if ir.cfg.MaxFiles > 0 && len(fhs) > ir.cfg.MaxFiles {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("一次最多上传 %d 张图片", ir.cfg.MaxFiles), ErrTooManyImages)
+	}
+
+	res := make(domain.CreateImageResponse, 0, len(fhs))
+	rejected := 0
 
 	for _, fh := range fhs {
-		if !strings.HasPrefix(fh.Header.Get("Content-Type"), "image/") {
-			log.Infof("%s 图片格式不支持", fh.Filename)
-			continue
-		}
+		result := domain.ImageUploadResult{Filename: fh.Filename}
 
-		file, err := fh.Open()
-		if err != nil {
-			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "打开文件失败", err)
+		data, err := ir.readFile(fh)
+		if err != nil && !errors.Is(err, services.ErrImageTooLarge) {
+			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "读取图片失败", err)
 		}
-		defer file.Close()
-
-		hash := xxhash.New()
-		if _, err := io.Copy(hash, file); err != nil {
-			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "计算哈希值失败", err)
+		var hashSum uint64
+		if err == nil {
+			// 按文件内容校验，删除元数据，SVG 会被清理
+			data, hashSum, err = ir.imageService.ProcessUpload(data)
+		}
+		if err != nil {
+			log.Infof("%s 未通过校验: %v", fh.Filename, err)
+			result.Status = domain.ImageUploadRejected
+			result.Reason = err.Error()
+			res = append(res, result)
+			rejected++
+			continue
 		}
-
-		hashSum := hash.Sum64()
 
 		image, _ := ir.imageService.GetImageByHash(hashSum)
 		if image != nil {
 			log.Infof("%s 图片已存在", fh.Filename)
-			res = append(res, *image)
+			result.Status = domain.ImageUploadExists
+			result.Image = image
+			res = append(res, result)
 			continue
 		}
 
-		if err = c.SaveFile(fh, path.Join(ir.uploadPath, fmt.Sprintf("%v", hashSum))); err != nil {
+		meta, err := ir.imageService.AnalyzeImage(bytes.NewReader(data))
+		if err != nil {
+			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "读取图片信息失败", err)
+		}
+
+		if err := ir.imageService.SaveImage(hashSum, data, meta.MimeType); err != nil {
 			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "保存图片失败", err)
 		}
 
 		params := new(domain.CreateImageParams)
 		params.Title = fh.Filename
 		params.Hash = hashSum
+		params.MimeType = meta.MimeType
+		params.Width = meta.Width
+		params.Height = meta.Height
+		params.Size = meta.Size
+		params.DominantColor = meta.DominantColor
+		params.Placeholder = meta.Placeholder
 
 		image, err = ir.imageService.CreateImage(*params)
 		if err != nil {
 			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "创建图片失败", err)
 		}
 
-		res = append(res, *image)
+		result.Status = domain.ImageUploadCreated
+		result.Image = image
+		res = append(res, result)
 	}
 
+	if rejected == len(res) {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "图片上传失败", &domain.ImageRejectedError{Results: res})
+	}
+	if rejected > 0 {
+		return domain.SuccessResponse(c, res, "部分图片未通过校验")
+	}
 	return domain.SuccessResponse(c, res, "创建图片成功")
 }
 
+// readFile 读取上传的文件，超过大小限制时不再继续读取
+func (ir *imageRoute) readFile(fh *multipart.FileHeader) ([]byte, error) {
+	if ir.cfg.MaxBytes > 0 && fh.Size > ir.cfg.MaxBytes {
+		return nil, services.ErrImageTooLarge
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if ir.cfg.MaxBytes <= 0 {
+		return io.ReadAll(file)
+	}
+	// 多读一个字节，由 ProcessUpload 判断是否超出限制
+	return io.ReadAll(io.LimitReader(file, ir.cfg.MaxBytes+1))
+}
+
 func (ir *imageRoute) deleteImage(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
 	}
 
-	if err := ir.imageService.DeleteImage(id, ir.uploadPath); err != nil {
+	if err := ir.imageService.DeleteImage(id); err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除图片失败", err)
 	}
 
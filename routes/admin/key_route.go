@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"cms/middleware/roleauth"
+	"cms/models"
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	KeyRoute interface {
+		RegisterRoutes()
+		getKeys(c *fiber.Ctx) error
+		rotate(c *fiber.Ctx) error
+	}
+	keyRoute struct {
+		app        fiber.Router
+		keyService services.KeyService
+	}
+)
+
+func NewKeyRoute(app fiber.Router, keyService services.KeyService) KeyRoute {
+	return &keyRoute{
+		app:        app,
+		keyService: keyService,
+	}
+}
+
+// 注册
+func (r *keyRoute) RegisterRoutes() {
+	r.app.Get("/", roleauth.Require(models.PermKeyManage), r.getKeys)
+	r.app.Post("/rotate", roleauth.Require(models.PermKeyManage), r.rotate)
+}
+
+// 获取签名密钥列表
+func (r *keyRoute) getKeys(c *fiber.Ctx) error {
+	list := r.keyService.Keys()
+	res := make([]domain.SigningKeyResponse, 0, len(list))
+	for _, key := range list {
+		res = append(res, domain.SigningKeyResponse{
+			ID:        key.ID,
+			Active:    key.Active(),
+			CreatedAt: key.CreatedAt,
+			RetiredAt: key.RetiredAt,
+		})
+	}
+	return domain.SuccessResponse(c, res, "获取签名密钥成功")
+}
+
+// 立即轮换签名密钥
+func (r *keyRoute) rotate(c *fiber.Ctx) error {
+	if err := r.keyService.Rotate(); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "轮换签名密钥失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "轮换签名密钥成功")
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// MFARoute 当前用户的两步验证设置
+	MFARoute interface {
+		RegisterRoutes()
+		getStatus(c *fiber.Ctx) error
+		enroll(c *fiber.Ctx) error
+		confirm(c *fiber.Ctx) error
+		disable(c *fiber.Ctx) error
+		regenerateRecoveryCodes(c *fiber.Ctx) error
+	}
+	mfaRoute struct {
+		app        fiber.Router
+		validator  *validator.Validate
+		mfaService services.MFAService
+	}
+)
+
+func NewMFARoute(app fiber.Router, mfaService services.MFAService, validator *validator.Validate) MFARoute {
+	return &mfaRoute{
+		app:        app,
+		validator:  validator,
+		mfaService: mfaService,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (r *mfaRoute) RegisterRoutes() {
+	r.app.Get("/", r.getStatus)
+	r.app.Post("/enroll", r.enroll)
+	r.app.Post("/confirm", r.confirm)
+	r.app.Post("/disable", r.disable)
+	r.app.Post("/recoveryCodes", r.regenerateRecoveryCodes)
+}
+
+// 获取两步验证状态
+func (r *mfaRoute) getStatus(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	res, err := r.mfaService.GetStatus(userID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取两步验证状态失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取两步验证状态成功")
+}
+
+// 生成验证器密钥
+func (r *mfaRoute) enroll(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	res, err := r.mfaService.Enroll(userID)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "绑定验证器失败", err)
+	}
+	return domain.SuccessResponse(c, res, "请使用验证器扫码并输入验证码确认")
+}
+
+// 确认绑定并开启两步验证
+func (r *mfaRoute) confirm(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.MFACodeParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	codes, err := r.mfaService.Confirm(userID, params.Code)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "开启两步验证失败", err)
+	}
+	return domain.SuccessResponse(c, domain.RecoveryCodesResponse{RecoveryCodes: codes}, "开启两步验证成功，请妥善保存恢复码")
+}
+
+// 关闭两步验证
+func (r *mfaRoute) disable(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.MFACodeParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := r.mfaService.Disable(userID, params.Code); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "关闭两步验证失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "关闭两步验证成功")
+}
+
+// 重新生成恢复码
+func (r *mfaRoute) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "获取用户ID失败", err)
+	}
+
+	params := new(domain.MFACodeParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	codes, err := r.mfaService.RegenerateRecoveryCodes(userID, params.Code)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "生成恢复码失败", err)
+	}
+	return domain.SuccessResponse(c, domain.RecoveryCodesResponse{RecoveryCodes: codes}, "生成恢复码成功，旧恢复码已失效")
+}
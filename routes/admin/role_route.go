@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"cms/middleware/roleauth"
+	"cms/models"
+	"cms/models/domain"
+	"cms/services"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type (
+	RoleRoute interface {
+		RegisterRoutes()
+		getPermissions(c *fiber.Ctx) error
+		getRoles(c *fiber.Ctx) error
+		createRole(c *fiber.Ctx) error
+		updateRole(c *fiber.Ctx) error
+		deleteRole(c *fiber.Ctx) error
+		setRoleMFA(c *fiber.Ctx) error
+	}
+	roleRoute struct {
+		app         fiber.Router
+		validator   *validator.Validate
+		rbacService services.RBACService
+	}
+)
+
+func NewRoleRoute(app fiber.Router, rbacService services.RBACService, validator *validator.Validate) RoleRoute {
+	return &roleRoute{
+		app:         app,
+		validator:   validator,
+		rbacService: rbacService,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (r *roleRoute) RegisterRoutes() {
+	r.app.Get("/permissions", roleauth.Require(models.PermUserRead), r.getPermissions)
+	r.app.Get("/", roleauth.Require(models.PermUserRead), r.getRoles)
+	r.app.Post("/", roleauth.Require(models.PermRoleWrite), r.createRole)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermRoleWrite), r.updateRole)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermRoleWrite), r.deleteRole)
+	// 只有超级管理员可以要求角色开启两步验证
+	r.app.Put("/:id<guid>/mfa", roleauth.Require(models.PermAll), r.setRoleMFA)
+}
+
+// 获取全部权限
+func (r *roleRoute) getPermissions(c *fiber.Ctx) error {
+	res, err := r.rbacService.GetPermissions()
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取权限列表失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取权限列表成功")
+}
+
+// 获取角色列表
+func (r *roleRoute) getRoles(c *fiber.Ctx) error {
+	res, err := r.rbacService.GetRoles()
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取角色列表失败", err)
+	}
+	return domain.SuccessResponse(c, res, "获取角色列表成功")
+}
+
+// 创建角色
+func (r *roleRoute) createRole(c *fiber.Ctx) error {
+	params := new(domain.CreateRoleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	err := r.rbacService.CreateRole(roleauth.Permissions(c), *params)
+	if errors.Is(err, services.ErrUserProtected) {
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限管理该角色", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "创建角色失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "创建角色成功")
+}
+
+// 更新角色
+func (r *roleRoute) updateRole(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.UpdateRoleParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	err = r.rbacService.UpdateRole(roleauth.Permissions(c), id, *params)
+	if errors.Is(err, services.ErrUserProtected) {
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限管理该角色", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "更新角色失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "更新角色成功")
+}
+
+// 删除角色
+func (r *roleRoute) deleteRole(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	err = r.rbacService.DeleteRole(roleauth.Permissions(c), id)
+	if errors.Is(err, services.ErrUserProtected) {
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限管理该角色", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除角色失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "删除角色成功")
+}
+
+// 设置角色是否要求两步验证
+func (r *roleRoute) setRoleMFA(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.SetRoleMFAParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := r.rbacService.SetRoleMFA(id, *params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "设置两步验证失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "设置两步验证成功")
+}
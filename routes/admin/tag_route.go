@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
 
@@ -35,9 +37,9 @@ func NewTagRoute(app fiber.Router, tagService services.TagService, validator *va
 // 注册
 func (r *tagRoute) RegisterRoutes() {
 	r.app.Get("/", r.getTags)
-	r.app.Post("/", r.createTag)
-	r.app.Put("/:id<guid>", r.updateTag)
-	r.app.Delete("/:id<guid>", r.deleteTag)
+	r.app.Post("/", roleauth.Require(models.PermTagWrite), r.createTag)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermTagWrite), r.updateTag)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermTagWrite), r.deleteTag)
 }
 
 // 获取标签列表
@@ -1,8 +1,11 @@
 package admin
 
 import (
+	"cms/middleware/roleauth"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
+	"errors"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -21,28 +24,69 @@ type (
 		createUser(c *fiber.Ctx) error
 		updateUser(c *fiber.Ctx) error
 		deleteUser(c *fiber.Ctx) error
+		revokeSessions(c *fiber.Ctx) error
+		setUserRoles(c *fiber.Ctx) error
+		unlockUser(c *fiber.Ctx) error
+		resetMFA(c *fiber.Ctx) error
+		deleteAccessTokens(c *fiber.Ctx) error
+		protectUser(c *fiber.Ctx) error
 	}
 	userRoute struct {
-		app         fiber.Router
-		validator   *validator.Validate
-		userService services.UserService
+		app          fiber.Router
+		validator    *validator.Validate
+		userService  services.UserService
+		tokenService services.TokenService
+		rbacService  services.RBACService
+		loginGuard   services.LoginGuard
+		mfaService   services.MFAService
+		tokens       services.AccessTokenService
 	}
 )
 
-func NewUserRoute(app fiber.Router, userService services.UserService, validator *validator.Validate) UserRoute {
+func NewUserRoute(app fiber.Router, userService services.UserService, tokenService services.TokenService, rbacService services.RBACService, loginGuard services.LoginGuard, mfaService services.MFAService, accessTokenService services.AccessTokenService, validator *validator.Validate) UserRoute {
 	return &userRoute{
-		app:         app,
-		validator:   validator,
-		userService: userService,
+		app:          app,
+		validator:    validator,
+		userService:  userService,
+		tokenService: tokenService,
+		rbacService:  rbacService,
+		loginGuard:   loginGuard,
+		mfaService:   mfaService,
+		tokens:       accessTokenService,
 	}
 }
 
 // RegisterRoutes 注册路由
 func (r *userRoute) RegisterRoutes() {
-	r.app.Get("/", r.getUsers)
-	r.app.Post("/", r.createUser)
-	r.app.Put("/:id<guid>", r.updateUser)
-	r.app.Delete("/:id<guid>", r.deleteUser)
+	r.app.Get("/", roleauth.Require(models.PermUserRead), r.getUsers)
+	r.app.Post("/", roleauth.Require(models.PermUserWrite), r.createUser)
+	r.app.Put("/:id<guid>", roleauth.Require(models.PermUserWrite), r.protectUser, r.updateUser)
+	r.app.Delete("/:id<guid>", roleauth.Require(models.PermUserWrite), r.protectUser, r.deleteUser)
+	r.app.Delete("/:id<guid>/sessions", roleauth.Require(models.PermUserWrite), r.protectUser, r.revokeSessions)
+	r.app.Put("/:id<guid>/unlock", roleauth.Require(models.PermUserWrite), r.protectUser, r.unlockUser)
+	r.app.Delete("/:id<guid>/mfa", roleauth.Require(models.PermUserWrite), r.protectUser, r.resetMFA)
+	r.app.Delete("/:id<guid>/accessTokens", roleauth.Require(models.PermUserWrite), r.protectUser, r.deleteAccessTokens)
+	// 分配角色同时需要管理角色的权限，避免自行提升权限
+	r.app.Put("/:id<guid>/roles", roleauth.Require(models.PermUserWrite, models.PermRoleWrite), r.protectUser, r.setUserRoles)
+}
+
+// protectUser 拒绝管理超级管理员和权限比自己多的用户，避免通过修改密码、手机号等接管账号提升权限
+func (r *userRoute) protectUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	err = r.rbacService.CanManageUser(roleauth.Permissions(c), id)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "用户不存在", err)
+	case errors.Is(err, services.ErrUserProtected):
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限管理该用户", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取用户权限失败", err)
+	}
+	return c.Next()
 }
 
 func (r *userRoute) getUsers(c *fiber.Ctx) error {
@@ -82,6 +126,15 @@ func (ur *userRoute) deleteUser(c *fiber.Ctx) error {
 	if err := ur.userService.DeleteUser(id); err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除用户失败", err)
 	}
+
+	if err := ur.tokenService.RevokeUser(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "吊销用户会话失败", err)
+	}
+	if err := ur.tokens.DeleteUser(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除用户访问令牌失败", err)
+	}
+	ur.rbacService.InvalidateUser(id)
+
 	return domain.SuccessResponse(c, nil, "删除用户成功")
 }
 
@@ -104,5 +157,91 @@ func (ur *userRoute) updateUser(c *fiber.Ctx) error {
 	if err := ur.userService.UpdateUser(id, *params); err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "更新用户失败", err)
 	}
+
+	// 修改密码后原有登录全部失效
+	if params.Password != nil {
+		if err := ur.tokenService.RevokeUser(id); err != nil {
+			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "吊销用户会话失败", err)
+		}
+	}
 	return domain.SuccessResponse(c, nil, "更新用户成功")
 }
+
+// 吊销用户的全部会话，用于账号被盗时立即强制下线
+func (ur *userRoute) revokeSessions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	if err := ur.tokenService.RevokeUser(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "吊销用户会话失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "吊销用户会话成功")
+}
+
+// 设置用户角色
+func (ur *userRoute) setUserRoles(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	params := new(domain.SetUserRolesParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	// 不能分配包含自己没有的权限的角色
+	if err := ur.rbacService.CanAssignRoles(roleauth.Permissions(c), params.RoleIDs); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusForbidden, "没有权限分配该角色", err)
+	}
+
+	if err := ur.rbacService.SetUserRoles(id, *params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "设置用户角色失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "设置用户角色成功")
+}
+
+// 解锁因登录失败过多被锁定的账号
+func (ur *userRoute) unlockUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	if err := ur.loginGuard.Unlock(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "解锁账号失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "解锁账号成功")
+}
+
+// 关闭用户的两步验证，用于用户丢失验证器和恢复码时重新绑定
+func (ur *userRoute) resetMFA(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	if err := ur.mfaService.Reset(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "重置两步验证失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "重置两步验证成功")
+}
+
+// 删除用户的全部个人访问令牌
+func (ur *userRoute) deleteAccessTokens(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析ID失败", err)
+	}
+
+	if err := ur.tokens.DeleteUser(id); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "删除访问令牌失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "删除访问令牌成功")
+}
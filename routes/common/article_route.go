@@ -4,6 +4,7 @@ import (
 	"cms/models/domain"
 	"cms/services"
 	"errors"
+	"net/url"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -20,19 +21,23 @@ type (
 		RegisterRoutes()
 		getArticlesByCategoryAlias(c *fiber.Ctx) error
 		getArticleByID(c *fiber.Ctx) error
+		getArticleBySlug(c *fiber.Ctx) error
 		getRelatedArticlesByID(c *fiber.Ctx) error
+		searchArticles(c *fiber.Ctx) error
 	}
 	articleRoute struct {
 		app            fiber.Router
 		articleService services.ArticleService
+		searchService  services.SearchService
 		validator      *validator.Validate
 	}
 )
 
-func NewArticleRoute(app fiber.Router, articleService services.ArticleService, validator *validator.Validate) ArticleRoute {
+func NewArticleRoute(app fiber.Router, articleService services.ArticleService, searchService services.SearchService, validator *validator.Validate) ArticleRoute {
 	return &articleRoute{
 		app,
 		articleService,
+		searchService,
 		validator,
 	}
 }
@@ -41,7 +46,9 @@ func NewArticleRoute(app fiber.Router, articleService services.ArticleService, v
 func (r *articleRoute) RegisterRoutes() {
 	r.app.Get("/getArticlesByCategoryAlias/:alias", r.getArticlesByCategoryAlias)
 	r.app.Get("/getArticleByID/:id", r.getArticleByID)
+	r.app.Get("/getArticleBySlug/:slug", r.getArticleBySlug)
 	r.app.Get("/getRelatedArticlesByID/:id", r.getRelatedArticlesByID)
+	r.app.Get("/search", r.searchArticles)
 }
 
 func (r *articleRoute) getArticlesByCategoryAlias(c *fiber.Ctx) error {
@@ -76,6 +83,18 @@ func (r *articleRoute) getArticleByID(c *fiber.Ctx) error {
 	return domain.SuccessResponse(c, article, "获取文章成功")
 }
 
+// 根据 slug 获取文章，旧 slug 永久重定向到当前 slug
+func (r *articleRoute) getArticleBySlug(c *fiber.Ctx) error {
+	article, err := r.articleService.GetArticleBySlugWithCache(c.Params("slug"))
+	if errors.Is(err, services.ErrArticleSlugMoved) {
+		return c.Redirect("./"+url.PathEscape(article.Slug), fiber.StatusMovedPermanently)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取文章失败", err)
+	}
+	return domain.SuccessResponse(c, article, "获取文章成功")
+}
+
 // 根据ID获取相关文章
 func (r *articleRoute) getRelatedArticlesByID(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -99,6 +118,24 @@ func (r *articleRoute) getRelatedArticlesByID(c *fiber.Ctx) error {
 	return domain.SuccessResponse(c, articles, "获取相关文章成功")
 }
 
+// 搜索文章
+func (r *articleRoute) searchArticles(c *fiber.Ctx) error {
+	params := new(domain.SearchArticlesParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := r.searchService.SearchArticles(*params)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "搜索文章失败", err)
+	}
+	return domain.SuccessResponse(c, res, "搜索文章成功")
+}
+
 // // 获取文章列表
 // func (r *articleRoute) getArticles(c *fiber.Ctx) error {
 // 	params := new(domain.GetArticleListParams)
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"cms/models/domain"
+	"cms/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	CaptchaRoute interface {
+		RegisterRoutes()
+		getCaptcha(c *fiber.Ctx) error
+		getCaptchaRequired(c *fiber.Ctx) error
+	}
+	captchaRoute struct {
+		app            fiber.Router
+		captchaService services.CaptchaService
+		validator      *validator.Validate
+	}
+)
+
+func NewCaptchaRoute(app fiber.Router, captchaService services.CaptchaService, validator *validator.Validate) CaptchaRoute {
+	return &captchaRoute{
+		app:            app,
+		captchaService: captchaService,
+		validator:      validator,
+	}
+}
+
+func (r *captchaRoute) RegisterRoutes() {
+	r.app.Get("/", r.getCaptcha)
+	r.app.Get("/required", r.getCaptchaRequired)
+}
+
+// 获取验证码
+func (r *captchaRoute) getCaptcha(c *fiber.Ctx) error {
+	params := new(domain.GetCaptchaParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	if err := r.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := r.captchaService.Generate(params.Mode)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成验证码失败", err)
+	}
+
+	// 验证码只能使用一次，不允许缓存
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return domain.SuccessResponse(c, res, "获取验证码成功")
+}
+
+// 登录前查询是否需要验证码
+func (r *captchaRoute) getCaptchaRequired(c *fiber.Ctx) error {
+	params := new(domain.CaptchaRequiredParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析查询参数失败", err)
+	}
+
+	res := domain.CaptchaRequiredResponse{
+		Required: r.captchaService.Required(params.Username, c.IP()),
+	}
+	return domain.SuccessResponse(c, res, "获取成功")
+}
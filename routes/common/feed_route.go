@@ -0,0 +1,78 @@
+package common
+
+import (
+	"cms/config"
+	"cms/models/domain"
+	"cms/services"
+	"cms/utils/feed"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	FeedRoute interface {
+		RegisterRoutes()
+		getFeed(c *fiber.Ctx) error
+	}
+	feedRoute struct {
+		app         fiber.Router
+		feedService services.FeedService
+		site        *config.SiteConfig
+	}
+)
+
+func NewFeedRoute(app fiber.Router, feedService services.FeedService, site *config.SiteConfig) FeedRoute {
+	return &feedRoute{
+		app:         app,
+		feedService: feedService,
+		site:        site,
+	}
+}
+
+// 注册
+func (r *feedRoute) RegisterRoutes() {
+	r.app.Get("/:format", r.getFeed)
+	r.app.Get("/:alias/:format", r.getFeed)
+}
+
+// 获取全站或分类订阅源，支持 ETag、Last-Modified 条件请求
+func (r *feedRoute) getFeed(c *fiber.Ctx) error {
+	format := c.Params("format")
+	if feed.ContentType(format) == "" {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "不支持的订阅源格式", feed.ErrUnknownFormat)
+	}
+
+	f, err := r.feedService.GetFeed(c.Params("alias"))
+	if errors.Is(err, services.ErrCategoryNotFound) {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "分类不存在", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取订阅源失败", err)
+	}
+
+	// 缓存中的订阅源是共享的，复制后再设置订阅地址
+	out := *f
+	out.FeedURL = r.site.AbsURL(c.Path())
+
+	body, err := feed.Render(&out, format)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成订阅源失败", err)
+	}
+
+	c.Set(fiber.HeaderETag, fmt.Sprintf(`"%x"`, xxhash.Sum64(body)))
+	if !out.Updated.IsZero() {
+		c.Set(fiber.HeaderLastModified, out.Updated.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	if c.Fresh() {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, feed.ContentType(format))
+	return c.Send(body)
+}
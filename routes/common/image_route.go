@@ -1,11 +1,15 @@
 package common
 
 import (
+	"cms/config"
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
-	"fmt"
-	"os"
-	"path"
+	"cms/utils/imaging"
+	"cms/utils/storage"
+	"errors"
+	"path/filepath"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -20,14 +24,16 @@ type (
 	imageRoute struct {
 		app          fiber.Router
 		imageService services.ImageService
+		cfg          *config.StorageConfig
 		validator    *validator.Validate
 	}
 )
 
-func NewImageRoute(app fiber.Router, imageService services.ImageService, validator *validator.Validate) ImageRoute {
+func NewImageRoute(app fiber.Router, imageService services.ImageService, cfg *config.StorageConfig, validator *validator.Validate) ImageRoute {
 	return &imageRoute{
 		app:          app,
 		imageService: imageService,
+		cfg:          cfg,
 		validator:    validator,
 	}
 }
@@ -47,16 +53,69 @@ func (ir *imageRoute) downloadImageById(c *fiber.Ctx) error {
 		return domain.ErrorResponse(c, fiber.StatusNotFound, "图片不存在", err)
 	}
 
-	// 判断文件夹是否存在
-	absPath, err := os.Getwd()
-	if err != nil {
-		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "获取绝对路径失败", err)
+	params := new(domain.ImageVariantParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求参数失败", err)
+	}
+
+	if err := ir.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	// 没有指定处理参数时返回原图
+	if *params == (domain.ImageVariantParams{}) {
+		return ir.sendOriginal(c, image)
+	}
+
+	variant, contentType, err := ir.imageService.GetVariant(image, *params)
+	switch {
+	case errors.Is(err, services.ErrImageVariantNotAllowed):
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "不支持的图片尺寸或质量", err)
+	case errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrTooManyPixels):
+		return domain.ErrorResponse(c, fiber.StatusUnprocessableEntity, "图片无法转换", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成图片失败", err)
+	}
+
+	// 衍生图片由原图哈希和参数决定，内容不会变化
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set(fiber.HeaderContentType, contentType)
+
+	title := strings.TrimSuffix(image.Title, filepath.Ext(image.Title)) + filepath.Ext(variant)
+	return c.Download(variant, title)
+}
+
+// sendOriginal 返回原图，开启 STORAGE_REDIRECT 且存储支持时重定向到签名链接
+func (ir *imageRoute) sendOriginal(c *fiber.Ctx, image *models.Image) error {
+	if ir.cfg.Redirect {
+		url, err := ir.imageService.SignedURL(image, ir.cfg.SignedURLTTL)
+		if err == nil {
+			// 签名链接会过期，不能被缓存
+			c.Set(fiber.HeaderCacheControl, "no-store")
+			return c.Redirect(url, fiber.StatusFound)
+		}
+		if !errors.Is(err, storage.ErrSignedURLUnsupported) {
+			return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成下载链接失败", err)
+		}
 	}
 
-	uploadPath := path.Join(absPath, "uploads")
-	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
-		return domain.ErrorResponse(c, fiber.StatusNotFound, "uploads 文件夹不存在", err)
+	file, info, err := ir.imageService.OpenImage(image)
+	if errors.Is(err, storage.ErrNotFound) {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "图片文件不存在", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "读取图片失败", err)
 	}
 
-	return c.Download(path.Join(uploadPath, fmt.Sprint(image.Hash)), image.Title)
+	// Attachment 按文件名设置类型，以保存的类型为准
+	c.Attachment(image.Title)
+	contentType := image.MimeType
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if contentType != "" {
+		c.Set(fiber.HeaderContentType, contentType)
+	}
+	// 文件流发送完成后由 fasthttp 关闭
+	return c.SendStream(file, int(info.Size))
 }
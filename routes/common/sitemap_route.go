@@ -0,0 +1,62 @@
+package common
+
+import (
+	"cms/models/domain"
+	"cms/services"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	SitemapRoute interface {
+		RegisterRoutes()
+		getSitemap(c *fiber.Ctx) error
+		getSitemapPage(c *fiber.Ctx) error
+	}
+	sitemapRoute struct {
+		app            fiber.Router
+		sitemapService services.SitemapService
+	}
+)
+
+func NewSitemapRoute(app fiber.Router, sitemapService services.SitemapService) SitemapRoute {
+	return &sitemapRoute{
+		app:            app,
+		sitemapService: sitemapService,
+	}
+}
+
+// 注册
+func (r *sitemapRoute) RegisterRoutes() {
+	r.app.Get("/sitemap.xml", r.getSitemap)
+	r.app.Get("/sitemap-:page.xml", r.getSitemapPage)
+}
+
+// 获取 sitemap 或 sitemap 索引
+func (r *sitemapRoute) getSitemap(c *fiber.Ctx) error {
+	return r.send(c, 0)
+}
+
+// 获取拆分后的 sitemap 文件
+func (r *sitemapRoute) getSitemapPage(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Params("page"))
+	if err != nil || page < 1 {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "sitemap 不存在", services.ErrSitemapNotFound)
+	}
+	return r.send(c, page)
+}
+
+func (r *sitemapRoute) send(c *fiber.Ctx, page int) error {
+	data, err := r.sitemapService.GetSitemap(page)
+	if errors.Is(err, services.ErrSitemapNotFound) {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "sitemap 不存在", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成 sitemap 失败", err)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Send(data)
+}
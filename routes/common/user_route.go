@@ -1,39 +1,68 @@
 package common
 
 import (
+	"cms/models"
 	"cms/models/domain"
 	"cms/services"
-	"crypto/rsa"
-	"time"
+	"errors"
+	"math"
+	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type (
 	AccountRoute interface {
 		RegisterRoutes()
 		login(c *fiber.Ctx) error
+		refresh(c *fiber.Ctx) error
+		enrollMFA(c *fiber.Ctx) error
+		verifyMFA(c *fiber.Ctx) error
+		forgotPassword(c *fiber.Ctx) error
+		verifyPasswordReset(c *fiber.Ctx) error
+		resetPassword(c *fiber.Ctx) error
+		oidcLogin(c *fiber.Ctx) error
+		oidcCallback(c *fiber.Ctx) error
+		oidcToken(c *fiber.Ctx) error
 	}
 	accountRoute struct {
-		app         fiber.Router
-		validator   *validator.Validate
-		userService services.UserService
-		privateKey  *rsa.PrivateKey
+		app          fiber.Router
+		validator    *validator.Validate
+		userService  services.UserService
+		tokenService services.TokenService
+		loginGuard   services.LoginGuard
+		captcha      services.CaptchaService
+		mfaService   services.MFAService
+		resetService services.PasswordResetService
+		oidcService  services.OIDCService
 	}
 )
 
-func NewAccountRoute(app fiber.Router, userService services.UserService, validator *validator.Validate, privateKey *rsa.PrivateKey) AccountRoute {
+func NewAccountRoute(app fiber.Router, userService services.UserService, validator *validator.Validate, tokenService services.TokenService, loginGuard services.LoginGuard, captcha services.CaptchaService, mfaService services.MFAService, resetService services.PasswordResetService, oidcService services.OIDCService) AccountRoute {
 	return &accountRoute{
-		app:         app,
-		validator:   validator,
-		userService: userService,
-		privateKey:  privateKey,
+		app:          app,
+		validator:    validator,
+		userService:  userService,
+		tokenService: tokenService,
+		loginGuard:   loginGuard,
+		captcha:      captcha,
+		mfaService:   mfaService,
+		resetService: resetService,
+		oidcService:  oidcService,
 	}
 }
 func (ur *accountRoute) RegisterRoutes() {
 	ur.app.Post("/login", ur.login)
+	ur.app.Post("/refresh", ur.refresh)
+	ur.app.Post("/mfa/enroll", ur.enrollMFA)
+	ur.app.Post("/mfa/verify", ur.verifyMFA)
+	ur.app.Post("/password/forgot", ur.forgotPassword)
+	ur.app.Post("/password/verify", ur.verifyPasswordReset)
+	ur.app.Post("/password/reset", ur.resetPassword)
+	ur.app.Get("/oidc/login", ur.oidcLogin)
+	ur.app.Get("/oidc/callback", ur.oidcCallback)
+	ur.app.Post("/oidc/token", ur.oidcToken)
 }
 
 func (ur *accountRoute) login(c *fiber.Ctx) error {
@@ -51,35 +80,246 @@ func (ur *accountRoute) login(c *fiber.Ctx) error {
 	// 	return domain.ErrorResponse(c, fiber.StatusUnauthorized, "用户名或密码错误", ErrInvalidCredentials)
 	// }
 
+	// 检查是否需要等待
+	if wait, err := ur.loginGuard.Check(params.Username, c.IP()); err != nil {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return domain.ErrorResponse(c, fiber.StatusTooManyRequests, "登录失败次数过多，请稍后再试", err)
+	}
+
+	// 登录失败次数较多时需要验证码
+	if ur.captcha.Required(params.Username, c.IP()) {
+		if err := ur.captcha.Verify(params.CaptchaID, params.Captcha); err != nil {
+			if errors.Is(err, services.ErrCaptchaRequired) {
+				return domain.ErrorResponse(c, fiber.StatusPreconditionRequired, "请输入验证码", err)
+			}
+			return domain.ErrorResponse(c, fiber.StatusBadRequest, "验证码错误", err)
+		}
+	}
+
 	user, err := ur.userService.Login(*params)
+	if errors.Is(err, services.ErrAccountLocked) {
+		return domain.ErrorResponse(c, fiber.StatusLocked, "账号已被锁定", err)
+	}
 	if err != nil {
+		ur.loginGuard.Fail(params.Username, c.IP())
 		return domain.ErrorResponse(c, fiber.StatusUnauthorized, "用户名或密码错误", err)
 	}
 
-	// 设置 JWT token 的过期时间
-	const tokenExpiration = time.Hour * 1
+	// 开启了两步验证时先返回挑战令牌，验证通过后再签发访问令牌
+	challenge, err := ur.mfaService.Challenge(user)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "登录失败", err)
+	}
+	if challenge != nil {
+		return domain.SuccessResponse(c, challenge, "请完成两步验证")
+	}
+	ur.loginGuard.Succeed(params.Username, c.IP())
+
+	return ur.issue(c, user, nil)
+}
+
+// 单点登录：跳转到身份提供方
+func (ur *accountRoute) oidcLogin(c *fiber.Ctx) error {
+	params := new(domain.OIDCLoginParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求参数失败", err)
+	}
+
+	target, err := ur.oidcService.AuthURL(params.Redirect)
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled):
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "未启用单点登录", err)
+	case errors.Is(err, services.ErrOIDCRedirectInvalid):
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "跳转地址不在允许的范围内", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusBadGateway, "连接身份提供方失败", err)
+	}
+
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// 单点登录：身份提供方回调，跳转回前端并附带一次性登录码
+func (ur *accountRoute) oidcCallback(c *fiber.Ctx) error {
+	params := new(domain.OIDCCallbackParams)
+	if err := c.QueryParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求参数失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	target, err := ur.oidcService.Callback(params.State, params.Code, params.Error)
+	if errors.Is(err, services.ErrOIDCDisabled) {
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "未启用单点登录", err)
+	}
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "单点登录失败", err)
+	}
+
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// 单点登录：使用一次性登录码换取访问令牌，开启了两步验证时返回挑战令牌
+func (ur *accountRoute) oidcToken(c *fiber.Ctx) error {
+	params := new(domain.OIDCTokenParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	user, err := ur.oidcService.Exchange(params.Code)
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled):
+		return domain.ErrorResponse(c, fiber.StatusNotFound, "未启用单点登录", err)
+	case errors.Is(err, services.ErrAccountLocked):
+		return domain.ErrorResponse(c, fiber.StatusLocked, "账号已被锁定", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusUnauthorized, "单点登录失败", err)
+	}
+
+	challenge, err := ur.mfaService.Challenge(user)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "登录失败", err)
+	}
+	if challenge != nil {
+		return domain.SuccessResponse(c, challenge, "请完成两步验证")
+	}
+
+	return ur.issue(c, user, nil)
+}
 
-	// 创建 JWT token 的声明
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(tokenExpiration).Unix(),
+// 登录过程中绑定验证器，用于角色要求两步验证但尚未绑定的用户
+func (ur *accountRoute) enrollMFA(c *fiber.Ctx) error {
+	params := new(domain.MFAChallengeParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
 	}
 
-	// 创建一个新的 JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
 
-	// 使用私钥签名 token
-	// 这里使用了 RS512 签名算法
-	t, err := token.SignedString(ur.privateKey)
+	res, err := ur.mfaService.EnrollChallenge(params.ChallengeToken)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusUnauthorized, "绑定验证器失败", err)
+	}
+	return domain.SuccessResponse(c, res, "请使用验证器扫码并输入验证码")
+}
+
+// 两步验证，通过后签发访问令牌
+func (ur *accountRoute) verifyMFA(c *fiber.Ctx) error {
+	params := new(domain.VerifyMFAParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	user, recoveryCodes, err := ur.mfaService.VerifyChallenge(params.ChallengeToken, params.Code, c.IP())
+	switch {
+	case errors.Is(err, services.ErrLoginTooFrequent):
+		return domain.ErrorResponse(c, fiber.StatusTooManyRequests, "验证失败次数过多，请稍后再试", err)
+	case errors.Is(err, services.ErrAccountLocked):
+		return domain.ErrorResponse(c, fiber.StatusLocked, "账号已被锁定", err)
+	case err != nil:
+		return domain.ErrorResponse(c, fiber.StatusUnauthorized, "两步验证失败", err)
+	}
+
+	return ur.issue(c, user, recoveryCodes)
+}
+
+// issue 创建会话并签发访问令牌和刷新令牌
+func (ur *accountRoute) issue(c *fiber.Ctx, user *models.User, recoveryCodes []string) error {
+	pair, err := ur.tokenService.Issue(user.ID, c.Get(fiber.HeaderUserAgent), c.IP())
 	if err != nil {
 		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "生成 JWT 失败", err)
 	}
 
 	res := domain.LoginResponse{
-		User:  *user,
-		Token: t,
+		User:          *user,
+		TokenPair:     *pair,
+		RecoveryCodes: recoveryCodes,
 	}
 
 	return domain.SuccessResponse(c, res, "登录成功")
+}
+
+// 刷新令牌
+func (ur *accountRoute) refresh(c *fiber.Ctx) error {
+	params := new(domain.RefreshTokenParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
 
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	pair, err := ur.tokenService.Refresh(params.RefreshToken)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusUnauthorized, "刷新令牌失败，请重新登录", err)
+	}
+
+	return domain.SuccessResponse(c, pair, "刷新令牌成功")
+}
+
+// 找回密码：发送验证码
+func (ur *accountRoute) forgotPassword(c *fiber.Ctx) error {
+	params := new(domain.RequestPasswordResetParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := ur.resetService.Request(params.Account, c.IP()); err != nil {
+		if errors.Is(err, services.ErrResetTooFrequent) {
+			return domain.ErrorResponse(c, fiber.StatusTooManyRequests, "获取验证码过于频繁", err)
+		}
+		return domain.ErrorResponse(c, fiber.StatusInternalServerError, "发送验证码失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "如果账号存在，验证码已发送")
+}
+
+// 找回密码：校验验证码
+func (ur *accountRoute) verifyPasswordReset(c *fiber.Ctx) error {
+	params := new(domain.VerifyPasswordResetParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	res, err := ur.resetService.Verify(params.Account, params.Code)
+	if err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "验证码错误", err)
+	}
+	return domain.SuccessResponse(c, res, "验证成功，请设置新密码")
+}
+
+// 找回密码：设置新密码
+func (ur *accountRoute) resetPassword(c *fiber.Ctx) error {
+	params := new(domain.ResetPasswordParams)
+	if err := c.BodyParser(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "解析请求体失败", err)
+	}
+
+	if err := ur.validator.Struct(params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "参数校验失败", err)
+	}
+
+	if err := ur.resetService.Reset(*params); err != nil {
+		return domain.ErrorResponse(c, fiber.StatusBadRequest, "重置密码失败", err)
+	}
+	return domain.SuccessResponse(c, nil, "重置密码成功，请重新登录")
 }
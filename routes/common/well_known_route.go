@@ -0,0 +1,36 @@
+package common
+
+import (
+	"cms/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	WellKnownRoute interface {
+		RegisterRoutes()
+		getJWKS(c *fiber.Ctx) error
+	}
+	wellKnownRoute struct {
+		app        fiber.Router
+		keyService services.KeyService
+	}
+)
+
+func NewWellKnownRoute(app fiber.Router, keyService services.KeyService) WellKnownRoute {
+	return &wellKnownRoute{
+		app:        app,
+		keyService: keyService,
+	}
+}
+
+// 注册
+func (r *wellKnownRoute) RegisterRoutes() {
+	r.app.Get("/jwks.json", r.getJWKS)
+}
+
+// 公开用于验证 token 的公钥，按标准格式直接输出 JWKS
+func (r *wellKnownRoute) getJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(r.keyService.JWKS())
+}
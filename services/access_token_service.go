@@ -0,0 +1,151 @@
+package services
+
+import (
+	"cms/models"
+	"cms/models/domain"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccessTokenPrefix 个人访问令牌前缀，用于和 JWT 区分
+const AccessTokenPrefix = "cms_pat_"
+
+// 最近使用时间的更新间隔，避免每个请求都写数据库
+const accessTokenTouchInterval = time.Minute
+
+var (
+	// ErrAccessTokenNotFound 令牌不存在
+	ErrAccessTokenNotFound = errors.New("访问令牌不存在")
+	// ErrAccessTokenInvalid 令牌无效或已过期
+	ErrAccessTokenInvalid = errors.New("访问令牌无效或已过期")
+	// ErrAccessTokenScopeInvalid 令牌权限超出用户权限
+	ErrAccessTokenScopeInvalid = errors.New("不能授予自己没有的权限")
+)
+
+type (
+	AccessTokenService interface {
+		// Create 创建令牌，明文只在创建时返回一次
+		Create(userID uuid.UUID, params domain.CreateAccessTokenParams) (*domain.CreateAccessTokenResponse, error)
+		GetAccessTokens(userID uuid.UUID) ([]*models.AccessToken, error)
+		// Delete 删除令牌，userID 为空时不检查令牌所属用户
+		Delete(userID *uuid.UUID, id uuid.UUID) error
+		// DeleteUser 删除用户的全部令牌
+		DeleteUser(userID uuid.UUID) error
+		// Authenticate 校验令牌并记录最近使用时间
+		Authenticate(token, ip string) (*models.AccessToken, error)
+	}
+	accessTokenService struct {
+		db          *gorm.DB
+		rbacService RBACService
+	}
+)
+
+func NewAccessTokenService(db *gorm.DB, rbacService RBACService) AccessTokenService {
+	return &accessTokenService{db: db, rbacService: rbacService}
+}
+
+func (s *accessTokenService) Create(userID uuid.UUID, params domain.CreateAccessTokenParams) (*domain.CreateAccessTokenResponse, error) {
+	permissions, err := s.rbacService.GetUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只能授予自己拥有的权限
+	for _, scope := range params.Scopes {
+		if scope == models.PermAll {
+			continue
+		}
+		if !slices.ContainsFunc(models.Permissions, func(p *models.Permission) bool { return p.Code == scope }) {
+			return nil, ErrPermissionNotFound
+		}
+		if !HasPermission(permissions, scope) {
+			return nil, ErrAccessTokenScopeInvalid
+		}
+	}
+
+	random, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	token := AccessTokenPrefix + random
+
+	accessToken := &models.AccessToken{
+		UserID:    userID,
+		Name:      params.Name,
+		Prefix:    token[:len(AccessTokenPrefix)+4],
+		TokenHash: hashToken(token),
+		Scopes:    params.Scopes,
+	}
+	if params.ExpiresInDays != nil {
+		expiresAt := models.CustomTime(time.Now().AddDate(0, 0, *params.ExpiresInDays))
+		accessToken.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(accessToken).Error; err != nil {
+		return nil, err
+	}
+
+	return &domain.CreateAccessTokenResponse{AccessToken: *accessToken, Token: token}, nil
+}
+
+func (s *accessTokenService) GetAccessTokens(userID uuid.UUID) ([]*models.AccessToken, error) {
+	var tokens []*models.AccessToken
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *accessTokenService) Delete(userID *uuid.UUID, id uuid.UUID) error {
+	query := s.db.Where("id = ?", id)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	result := query.Delete(&models.AccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+func (s *accessTokenService) DeleteUser(userID uuid.UUID) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.AccessToken{}).Error
+}
+
+func (s *accessTokenService) Authenticate(token, ip string) (*models.AccessToken, error) {
+	if !strings.HasPrefix(token, AccessTokenPrefix) {
+		return nil, ErrAccessTokenInvalid
+	}
+
+	now := time.Now()
+	accessToken := new(models.AccessToken)
+	// 用户被删除后令牌随即失效
+	if err := s.db.Joins("JOIN users ON users.id = access_tokens.user_id AND users.deleted_at IS NULL").
+		Where("access_tokens.token_hash = ?", hashToken(token)).
+		Where("access_tokens.expires_at IS NULL OR access_tokens.expires_at > ?", now).
+		First(accessToken).Error; err != nil {
+		return nil, ErrAccessTokenInvalid
+	}
+
+	if accessToken.LastUsedAt == nil || now.Sub(time.Time(*accessToken.LastUsedAt)) > accessTokenTouchInterval || accessToken.LastUsedIP != ip {
+		lastUsedAt := models.CustomTime(now)
+		if err := s.db.Model(accessToken).Updates(map[string]any{
+			"last_used_at": &lastUsedAt,
+			"last_used_ip": ip,
+		}).Error; err != nil {
+			log.Errorf("更新访问令牌使用时间失败: %v", err)
+		}
+	}
+
+	return accessToken, nil
+}
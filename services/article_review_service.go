@@ -0,0 +1,292 @@
+package services
+
+import (
+	"cms/models"
+	"cms/models/domain"
+	"cms/models/scopes"
+	"cms/utils/cache"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrArticleStatusInvalid 文章当前状态不允许该操作
+	ErrArticleStatusInvalid = errors.New("文章当前状态不允许该操作")
+	// ErrSelfReviewForbidden 不能审核自己的文章
+	ErrSelfReviewForbidden = errors.New("不能审核自己的文章")
+	// ErrReviewerNotAssigned 文章已指定其他审核人
+	ErrReviewerNotAssigned = errors.New("文章已指定其他审核人")
+	// ErrArticleStatusConflict 文章状态已被并发修改
+	ErrArticleStatusConflict = errors.New("文章状态已变化，请刷新后重试")
+)
+
+type (
+	ArticleReviewService interface {
+		Submit(userID, articleID uuid.UUID, params domain.SubmitArticleReviewParams) error
+		Approve(userID, articleID uuid.UUID, params domain.ApproveArticleParams) error
+		Reject(userID, articleID uuid.UUID, params domain.RejectArticleParams) error
+		Publish(userID, articleID uuid.UUID) error
+		// Withdraw 撤回审核中或已发布的文章，退回草稿后才能修改并重新提交审核
+		Withdraw(userID, articleID uuid.UUID, params domain.WithdrawArticleParams) error
+		GetReviewQueue(userID uuid.UUID, params domain.GetReviewQueueParams) (*domain.LimitResponse[*models.Article], error)
+		GetReviews(userID, articleID uuid.UUID) ([]*models.ArticleReview, error)
+	}
+	articleReviewService struct {
+		db            *gorm.DB
+		cache         cache.Cache
+		workflow      ArticleWorkflow
+		searchService SearchService
+	}
+)
+
+func NewArticleReviewService(db *gorm.DB, cache cache.Cache, workflow ArticleWorkflow, searchService SearchService) ArticleReviewService {
+	return &articleReviewService{db: db, cache: cache, workflow: workflow, searchService: searchService}
+}
+
+func (s *articleReviewService) Submit(userID, articleID uuid.UUID, params domain.SubmitArticleReviewParams) error {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return err
+	}
+
+	if article.Status != models.StatusDraft && article.Status != models.StatusRejected {
+		return ErrArticleStatusInvalid
+	}
+
+	// 只能提交自己的文章
+	if err := s.workflow.CanModify(userID, article); err != nil {
+		return err
+	}
+
+	if params.ReviewerID != nil {
+		// 检查指定的审核人是否有审核权限
+		isReviewer, err := s.workflow.IsReviewer(*params.ReviewerID)
+		if err != nil {
+			return err
+		}
+		if !isReviewer {
+			return ErrNotReviewer
+		}
+	}
+	article.ReviewerID = params.ReviewerID
+
+	return s.transition(userID, article, models.ReviewActionSubmit, models.StatusInReview, params.Comment)
+}
+
+func (s *articleReviewService) Approve(userID, articleID uuid.UUID, params domain.ApproveArticleParams) error {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkReviewer(userID, article); err != nil {
+		return err
+	}
+
+	if article.Status != models.StatusInReview {
+		return ErrArticleStatusInvalid
+	}
+
+	return s.transition(userID, article, models.ReviewActionApprove, models.StatusApproved, params.Comment)
+}
+
+func (s *articleReviewService) Reject(userID, articleID uuid.UUID, params domain.RejectArticleParams) error {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkReviewer(userID, article); err != nil {
+		return err
+	}
+
+	if article.Status != models.StatusInReview && article.Status != models.StatusApproved {
+		return ErrArticleStatusInvalid
+	}
+
+	return s.transition(userID, article, models.ReviewActionReject, models.StatusRejected, params.Comment)
+}
+
+func (s *articleReviewService) Publish(userID, articleID uuid.UUID) error {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return err
+	}
+
+	if s.workflow.Enabled() {
+		if err := s.checkReviewer(userID, article); err != nil {
+			return err
+		}
+
+		if article.Status != models.StatusApproved {
+			return ErrArticleStatusInvalid
+		}
+	} else {
+		// 未开启审核流程时与修改文章状态的检查相同
+		if err := s.workflow.CanModify(userID, article); err != nil {
+			return err
+		}
+		if err := s.workflow.CanSetStatus(userID, models.StatusPublished); err != nil {
+			return err
+		}
+	}
+
+	// 设置了发布时间的文章进入定时发布状态
+	published := *article
+	published.Status = models.StatusPublished
+	if err := resolveArticleStatus(&published, time.Now()); err != nil {
+		return err
+	}
+
+	return s.transition(userID, article, models.ReviewActionPublish, published.Status, "")
+}
+
+func (s *articleReviewService) Withdraw(userID, articleID uuid.UUID, params domain.WithdrawArticleParams) error {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return err
+	}
+
+	if article.Status == models.StatusDraft || article.Status == models.StatusRejected {
+		return ErrArticleStatusInvalid
+	}
+
+	// 作者本人或拥有 article:manage 权限的用户可以撤回
+	if err := s.workflow.CanModify(userID, article); err != nil {
+		return err
+	}
+
+	return s.transition(userID, article, models.ReviewActionWithdraw, models.StatusDraft, params.Comment)
+}
+
+func (s *articleReviewService) GetReviewQueue(userID uuid.UUID, params domain.GetReviewQueueParams) (*domain.LimitResponse[*models.Article], error) {
+	isReviewer, err := s.workflow.IsReviewer(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isReviewer {
+		return nil, ErrNotReviewer
+	}
+
+	var count int64
+	var articles []*models.Article
+
+	// 指定给自己或未指定审核人的待审核文章
+	model := s.db.Model(&models.Article{}).
+		Where("status = ?", models.StatusInReview).
+		Where("reviewer_id = ? OR reviewer_id IS NULL", userID)
+
+	if !s.workflow.AllowSelfReview() {
+		model = model.Where("user_id != ?", userID)
+	}
+
+	if err := model.Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if err := model.Scopes(
+		scopes.PaginationScope(params.Page, params.PageSize),
+	).Order("updated_at ASC").Preload(clause.Associations).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	// 计算总页数
+	totalPages := int(math.Ceil(float64(count) / float64(params.PageSize)))
+
+	return &domain.LimitResponse[*models.Article]{
+		Total: count,
+		Rows:  articles,
+		Pages: totalPages,
+	}, nil
+}
+
+func (s *articleReviewService) GetReviews(userID, articleID uuid.UUID) ([]*models.ArticleReview, error) {
+	article, err := s.getArticle(articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 审核记录包含驳回意见，只有能修改文章的用户和审核人可以查看
+	if err := s.workflow.CanModify(userID, article); err != nil {
+		isReviewer, rerr := s.workflow.IsReviewer(userID)
+		if rerr != nil {
+			return nil, rerr
+		}
+		if !isReviewer {
+			return nil, err
+		}
+	}
+
+	var reviews []*models.ArticleReview
+	if err := s.db.Where("article_id = ?", articleID).Order("created_at DESC").Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (s *articleReviewService) getArticle(id uuid.UUID) (*models.Article, error) {
+	article := new(models.Article)
+	if err := s.db.Where("id = ?", id).First(article).Error; err != nil {
+		return nil, ErrArticleNotFound
+	}
+	return article, nil
+}
+
+// checkReviewer 检查用户能否审核该文章
+func (s *articleReviewService) checkReviewer(userID uuid.UUID, article *models.Article) error {
+	isReviewer, err := s.workflow.IsReviewer(userID)
+	if err != nil {
+		return err
+	}
+	if !isReviewer {
+		return ErrNotReviewer
+	}
+
+	if article.ReviewerID != nil && *article.ReviewerID != userID {
+		return ErrReviewerNotAssigned
+	}
+
+	if article.UserID == userID && !s.workflow.AllowSelfReview() {
+		return ErrSelfReviewForbidden
+	}
+	return nil
+}
+
+// transition 切换文章状态并记录审核日志
+func (s *articleReviewService) transition(userID uuid.UUID, article *models.Article, action models.ReviewAction, to models.ArticleStatus, comment string) error {
+	review := &models.ArticleReview{
+		ArticleID:  article.ID,
+		UserID:     userID,
+		Action:     action,
+		FromStatus: article.Status,
+		ToStatus:   to,
+		Comment:    comment,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 只在状态仍是检查时的状态才更新，避免并发的审核操作互相覆盖
+		res := tx.Model(article).Where("status = ?", article.Status).Updates(map[string]any{
+			"status":      to,
+			"reviewer_id": article.ReviewerID,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrArticleStatusConflict
+		}
+		return tx.Create(review).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	onArticlesChanged(s.cache, s.searchService, article.ID)
+
+	return nil
+}
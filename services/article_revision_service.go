@@ -0,0 +1,159 @@
+package services
+
+import (
+	"cms/models"
+	"cms/models/domain"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrArticleRevisionNotFound 文章修订版本不存在
+	ErrArticleRevisionNotFound = errors.New("文章修订版本不存在")
+)
+
+type (
+	ArticleRevisionService interface {
+		GetRevisions(articleID uuid.UUID) ([]*models.ArticleRevision, error)
+		GetRevision(articleID, revisionID uuid.UUID) (*models.ArticleRevision, error)
+		DiffRevisions(articleID uuid.UUID, params domain.DiffArticleRevisionsParams) (*domain.DiffArticleRevisionsResponse, error)
+		// 将文章恢复到指定版本，恢复结果会作为一个新版本保存
+		RestoreRevision(userID, articleID, revisionID uuid.UUID) error
+	}
+	articleRevisionService struct {
+		db             *gorm.DB
+		articleService ArticleService
+	}
+)
+
+func NewArticleRevisionService(db *gorm.DB, articleService ArticleService) ArticleRevisionService {
+	return &articleRevisionService{db: db, articleService: articleService}
+}
+
+func (s *articleRevisionService) GetRevisions(articleID uuid.UUID) ([]*models.ArticleRevision, error) {
+	// 检查文章是否存在
+	if err := s.db.Where("id = ?", articleID).First(&models.Article{}).Error; err != nil {
+		return nil, ErrArticleNotFound
+	}
+
+	var revisions []*models.ArticleRevision
+	if err := s.db.Omit("content").Where("article_id = ?", articleID).Order("version DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *articleRevisionService) GetRevision(articleID, revisionID uuid.UUID) (*models.ArticleRevision, error) {
+	revision := new(models.ArticleRevision)
+	if err := s.db.Where("id = ? AND article_id = ?", revisionID, articleID).First(revision).Error; err != nil {
+		return nil, ErrArticleRevisionNotFound
+	}
+	return revision, nil
+}
+
+func (s *articleRevisionService) DiffRevisions(articleID uuid.UUID, params domain.DiffArticleRevisionsParams) (*domain.DiffArticleRevisionsResponse, error) {
+	from, err := s.GetRevision(articleID, params.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.GetRevision(articleID, params.To)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*domain.ArticleRevisionFieldDiff, 0)
+	addDiff := func(field string, fromValue, toValue any, changed bool) {
+		if changed {
+			fields = append(fields, &domain.ArticleRevisionFieldDiff{Field: field, From: fromValue, To: toValue})
+		}
+	}
+
+	addDiff("title", from.Title, to.Title, from.Title != to.Title)
+	addDiff("description", from.Description, to.Description, from.Description != to.Description)
+	addDiff("content", from.Content, to.Content, from.Content != to.Content)
+	addDiff("status", from.Status, to.Status, from.Status != to.Status)
+	addDiff("categoryId", from.CategoryID, to.CategoryID, from.CategoryID != to.CategoryID)
+	addDiff("tagIds", from.TagIDs, to.TagIDs, !sameUUIDs(from.TagIDs, to.TagIDs))
+	addDiff("imageIds", from.ImageIDs, to.ImageIDs, !sameUUIDs(from.ImageIDs, to.ImageIDs))
+
+	return &domain.DiffArticleRevisionsResponse{
+		From:   from,
+		To:     to,
+		Fields: fields,
+	}, nil
+}
+
+func (s *articleRevisionService) RestoreRevision(userID, articleID, revisionID uuid.UUID) error {
+	revision, err := s.GetRevision(articleID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	// 状态不随版本恢复，避免绕过发布流程
+	params := domain.UpdateArticleParams{
+		Title:       &revision.Title,
+		Description: &revision.Description,
+		Content:     &revision.Content,
+		CategoryID:  &revision.CategoryID,
+		ImageIds:    append([]uuid.UUID{}, revision.ImageIDs...),
+		TagIds:      append([]uuid.UUID{}, revision.TagIDs...),
+	}
+
+	return s.articleService.UpdateArticle(userID, articleID, params)
+}
+
+// createArticleRevision 为文章当前状态保存一个新的修订版本。
+// 需要与文章的修改在同一个事务中调用，并且事务已锁定文章行，否则并发保存时版本号可能重复
+func createArticleRevision(db *gorm.DB, articleID, userID uuid.UUID) error {
+	article := new(models.Article)
+	if err := db.Preload("Tags").Preload("Images").Where("id = ?", articleID).First(article).Error; err != nil {
+		return ErrArticleNotFound
+	}
+
+	var latest uint
+	if err := db.Model(&models.ArticleRevision{}).Where("article_id = ?", articleID).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	tagIDs := make(models.UUIDs, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	imageIDs := make(models.UUIDs, 0, len(article.Images))
+	for _, image := range article.Images {
+		imageIDs = append(imageIDs, image.ID)
+	}
+
+	revision := &models.ArticleRevision{
+		ArticleID:   article.ID,
+		Version:     latest + 1,
+		Title:       article.Title,
+		Description: article.Description,
+		Content:     article.Content,
+		Status:      article.Status,
+		CategoryID:  article.CategoryID,
+		TagIDs:      tagIDs,
+		ImageIDs:    imageIDs,
+		UserID:      userID,
+	}
+
+	return db.Create(revision).Error
+}
+
+// sameUUIDs 忽略顺序比较两个 UUID 列表
+func sameUUIDs(a, b models.UUIDs) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			return false
+		}
+	}
+	return true
+}
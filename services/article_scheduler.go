@@ -0,0 +1,109 @@
+package services
+
+import (
+	"cms/models"
+	"cms/utils/cache"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	// ArticleScheduler 定时发布、下线文章的后台任务
+	ArticleScheduler interface {
+		Start()
+		Stop()
+		// RunOnce 立即执行一次状态切换
+		RunOnce() error
+	}
+	articleScheduler struct {
+		db            *gorm.DB
+		cache         cache.Cache
+		interval      time.Duration
+		stop          chan struct{}
+		searchService SearchService
+	}
+)
+
+func NewArticleScheduler(db *gorm.DB, cache cache.Cache, searchService SearchService, interval time.Duration) ArticleScheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &articleScheduler{
+		db:            db,
+		cache:         cache,
+		interval:      interval,
+		stop:          make(chan struct{}),
+		searchService: searchService,
+	}
+}
+
+func (s *articleScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.RunOnce(); err != nil {
+				log.Errorf("定时发布文章失败: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *articleScheduler) Stop() {
+	close(s.stop)
+}
+
+func (s *articleScheduler) RunOnce() error {
+	now := time.Now()
+	changed := make([]uuid.UUID, 0)
+
+	// 到达发布时间的文章
+	due := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND (publish_at IS NULL OR publish_at <= ?)", models.StatusScheduled, now).
+			Where("unpublish_at IS NULL OR unpublish_at > ?", now)
+	}
+	var publishIDs []uuid.UUID
+	if err := s.db.Model(&models.Article{}).Scopes(due).Pluck("id", &publishIDs).Error; err != nil {
+		return err
+	}
+	if len(publishIDs) > 0 {
+		// 更新时重复检查状态和时间，查询之后被撤回或改期的文章不会被发布
+		if err := s.db.Model(&models.Article{}).Where("id IN ?", publishIDs).Scopes(due).Update("status", models.StatusPublished).Error; err != nil {
+			return err
+		}
+		changed = append(changed, publishIDs...)
+	}
+
+	// 到达下线时间的文章
+	expired := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status IN ? AND unpublish_at <= ?", []models.ArticleStatus{models.StatusPublished, models.StatusScheduled}, now)
+	}
+	var unpublishIDs []uuid.UUID
+	if err := s.db.Model(&models.Article{}).Scopes(expired).Pluck("id", &unpublishIDs).Error; err != nil {
+		return err
+	}
+	if len(unpublishIDs) > 0 {
+		if err := s.db.Model(&models.Article{}).Where("id IN ?", unpublishIDs).Scopes(expired).Update("status", models.StatusDraft).Error; err != nil {
+			return err
+		}
+		changed = append(changed, unpublishIDs...)
+	}
+
+	if len(changed) > 0 {
+		log.Infof("定时任务切换了 %d 篇文章的发布状态", len(changed))
+		onArticlesChanged(s.cache, s.searchService, changed...)
+	}
+
+	return nil
+}
@@ -4,9 +4,15 @@ import (
 	"cms/models"
 	"cms/models/domain"
 	"cms/models/scopes"
+	"cms/utils"
+	"cms/utils/cache"
+	"database/sql"
 	"errors"
+	"fmt"
 	"math"
+	"time"
 
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -17,26 +23,50 @@ var (
 	ErrArticleNotFound = errors.New("文章不存在")
 	// ErrArticleAlreadyExists 文章已存在时
 	ErrArticleAlreadyExists = errors.New("文章已存在")
+	// ErrArticleScheduleInvalid 下线时间早于发布时间
+	ErrArticleScheduleInvalid = errors.New("下线时间必须晚于发布时间")
+	// ErrArticleSlugAlreadyExists slug 已被使用
+	ErrArticleSlugAlreadyExists = errors.New("slug 已被使用")
+	// ErrArticleSlugInvalid slug 不合法
+	ErrArticleSlugInvalid = errors.New("slug 只能包含字母、数字和连字符")
+	// ErrArticleSlugMoved 请求的是文章的旧 slug，返回的文章中包含当前 slug
+	ErrArticleSlugMoved = errors.New("文章地址已变更")
+)
+
+const (
+	// 单篇文章缓存键前缀
+	articleCacheKeyPrefix = "article:id:"
+	// 文章列表缓存键前缀，任何文章变更都会清除该前缀下的所有缓存
+	articleListCacheKeyPrefix = "article:list:"
 )
 
 type (
 	ArticleService interface {
 		GetArticles(params domain.GetArticleListParams) (*domain.LimitResponse[*models.Article], error)
 		CreateArticle(user_id uuid.UUID, article domain.CreateArticleParams) error
-		UpdateArticle(id uuid.UUID, article domain.UpdateArticleParams) error
-		DeleteArticle(id uuid.UUID) error
+		UpdateArticle(userID, id uuid.UUID, article domain.UpdateArticleParams) error
+		DeleteArticle(userID, id uuid.UUID) error
+		// TransferArticle 将文章转移给其他用户
+		TransferArticle(id uuid.UUID, params domain.TransferArticleParams) error
 		GetArticlesByCategoryAliasWithCache(alias string, params domain.GetArticlesByCategoryAliasWithCacheParams) (*domain.LimitResponse[*models.Article], error)
 		GetArticleByIDWithCache(id uuid.UUID) (*models.Article, error)
+		// 根据 slug 获取文章，请求旧 slug 时同时返回文章和 ErrArticleSlugMoved
+		GetArticleBySlugWithCache(slug string) (*models.Article, error)
+		// 为没有 slug 的文章生成 slug
+		GenerateMissingSlugs() error
 		GetRelatedArticlesByIDWithCache(id uuid.UUID, params domain.GetRelatedArticlesByIDWithCacheParams) (*domain.LimitResponse[*models.Article], error)
 	}
 	articleService struct {
-		db           *gorm.DB
-		articleScope scopes.ArticleScope
+		db            *gorm.DB
+		articleScope  scopes.ArticleScope
+		cache         cache.Cache
+		workflow      ArticleWorkflow
+		searchService SearchService
 	}
 )
 
-func NewArticleService(db *gorm.DB, articleScope scopes.ArticleScope) ArticleService {
-	return &articleService{db: db, articleScope: articleScope}
+func NewArticleService(db *gorm.DB, articleScope scopes.ArticleScope, cache cache.Cache, workflow ArticleWorkflow, searchService SearchService) ArticleService {
+	return &articleService{db: db, articleScope: articleScope, cache: cache, workflow: workflow, searchService: searchService}
 }
 
 func (s *articleService) GetArticles(params domain.GetArticleListParams) (*domain.LimitResponse[*models.Article], error) {
@@ -50,6 +80,7 @@ func (s *articleService) GetArticles(params domain.GetArticleListParams) (*domai
 		s.articleScope.Title(params.Title),
 		s.articleScope.Category(params.CategoryID),
 		s.articleScope.Status(params.Status),
+		s.articleScope.Author(params.UserID),
 	).Count(&count).Error; err != nil {
 		return nil, err
 	}
@@ -59,6 +90,7 @@ func (s *articleService) GetArticles(params domain.GetArticleListParams) (*domai
 		s.articleScope.Title(params.Title),
 		s.articleScope.Category(params.CategoryID),
 		s.articleScope.Status(params.Status),
+		s.articleScope.Author(params.UserID),
 		scopes.PaginationScope(params.Page, params.PageSize),
 	).Order("created_at DESC").Preload(clause.Associations).Find(&articles).Error; err != nil {
 		return nil, err
@@ -75,6 +107,11 @@ func (s *articleService) GetArticles(params domain.GetArticleListParams) (*domai
 }
 
 func (s *articleService) CreateArticle(user_id uuid.UUID, params domain.CreateArticleParams) error {
+	// 检查是否可以直接发布
+	if err := s.workflow.CanSetStatus(user_id, params.Status); err != nil {
+		return err
+	}
+
 	// 检查分类是否存在
 	if err := s.db.Where("id = ?", params.CategoryID).First(&models.Category{}).Error; err != nil {
 		return ErrCategoryNotFound
@@ -91,123 +128,256 @@ func (s *articleService) CreateArticle(user_id uuid.UUID, params domain.CreateAr
 		Content:     params.Content,
 		CategoryID:  params.CategoryID,
 		Status:      params.Status,
+		PublishAt:   nonZeroTime(params.PublishAt),
+		UnpublishAt: nonZeroTime(params.UnpublishAt),
 		UserID:      user_id,
 	}
 
-	if err := s.db.Create(&article).Error; err != nil {
+	if err := resolveArticleStatus(article, time.Now()); err != nil {
 		return err
 	}
 
-	if params.ImageIds != nil {
-		images := make([]*models.Image, 0)
-		for _, id := range params.ImageIds {
-			image := new(models.Image)
-			if err := s.db.Where("id = ?", id).First(image).Error; err != nil {
-				continue
+	slug, err := s.resolveSlug(params.Slug, params.Title, uuid.Nil)
+	if err != nil {
+		return err
+	}
+	article.Slug = slug
+
+	// 文章与初始版本一起保存
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&article).Error; err != nil {
+			return err
+		}
+
+		if params.ImageIds != nil {
+			images := make([]*models.Image, 0)
+			for _, id := range params.ImageIds {
+				image := new(models.Image)
+				if err := tx.Where("id = ?", id).First(image).Error; err != nil {
+					continue
+				}
+				images = append(images, image)
+			}
+			if err := tx.Model(&article).Association("Images").Append(images); err != nil {
+				return err
 			}
-			images = append(images, image)
 		}
-		s.db.Model(&article).Association("Images").Append(images)
-	}
 
-	if params.TagIds != nil {
-		tags := make([]*models.Tag, 0)
-		for _, id := range params.TagIds {
-			tag := new(models.Tag)
-			// 检查标签是否存在
-			if err := s.db.Where("id = ?", id).First(tag).Error; err != nil {
-				continue // 如果标签不存在，跳过
+		if params.TagIds != nil {
+			tags := make([]*models.Tag, 0)
+			for _, id := range params.TagIds {
+				tag := new(models.Tag)
+				// 检查标签是否存在
+				if err := tx.Where("id = ?", id).First(tag).Error; err != nil {
+					continue // 如果标签不存在，跳过
+				}
+				tags = append(tags, tag)
+			}
+			if err := tx.Model(&article).Association("Tags").Append(tags); err != nil {
+				return err
 			}
-			tags = append(tags, tag)
 		}
-		s.db.Model(&article).Association("Tags").Append(tags)
+
+		// 保存初始版本
+		return createArticleRevision(tx, article.ID, user_id)
+	})
+	if err != nil {
+		return err
 	}
 
+	s.onChanged(article.ID)
+
 	return nil
 }
 
-func (s *articleService) UpdateArticle(id uuid.UUID, params domain.UpdateArticleParams) error {
-	article := new(models.Article)
-	// 检查分类是否存在
-	if err := s.db.Where("id = ?", id).First(article).Error; err != nil {
-		return ErrArticleNotFound
-	}
+func (s *articleService) UpdateArticle(userID, id uuid.UUID, params domain.UpdateArticleParams) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		article := new(models.Article)
+		// 先锁定文章，再基于锁定后的数据检查权限和合并修改，并发的审核、转移或定时发布不会被覆盖，
+		// 修订版本与保存的内容一致且版本号不会重复
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(article).Error; err != nil {
+			return ErrArticleNotFound
+		}
 
-	if params.Title != nil && article.Title != *params.Title {
-		// 检查文章标题是否已存在
-		if err := s.db.Where("title = ?", *params.Title).First(&models.Article{}).Error; err == nil {
-			return ErrArticleAlreadyExists
+		// 检查是否是文章作者
+		if err := s.workflow.CanModify(userID, article); err != nil {
+			return err
 		}
 
-		article.Title = *params.Title
-	}
+		// 检查文章所处的审核状态是否允许修改
+		if err := s.workflow.CanEdit(userID, article); err != nil {
+			return err
+		}
 
-	if params.Description != nil && article.Description != *params.Description {
-		article.Description = *params.Description
-	}
+		if params.Title != nil && article.Title != *params.Title {
+			// 检查文章标题是否已存在
+			if err := tx.Where("title = ?", *params.Title).First(&models.Article{}).Error; err == nil {
+				return ErrArticleAlreadyExists
+			}
 
-	if params.Content != nil && article.Content != *params.Content {
-		article.Content = *params.Content
-	}
+			article.Title = *params.Title
+
+			// 未指定 slug 时根据新标题重新生成
+			if params.Slug == nil {
+				slug, err := s.resolveSlug(nil, article.Title, article.ID)
+				if err != nil {
+					return err
+				}
+				if err := s.changeSlug(tx, article, slug); err != nil {
+					return err
+				}
+			}
+		}
 
-	if params.CategoryID != nil && article.CategoryID != *params.CategoryID {
-		// 检查分类是否存在
-		if err := s.db.Where("id = ?", *params.CategoryID).First(&models.Category{}).Error; err != nil {
-			return ErrCategoryNotFound
+		if params.Slug != nil {
+			slug, err := s.resolveSlug(params.Slug, article.Title, article.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.changeSlug(tx, article, slug); err != nil {
+				return err
+			}
 		}
-		article.CategoryID = *params.CategoryID
-	}
 
-	if params.Status != nil && article.Status != *params.Status {
-		article.Status = *params.Status
-	}
+		if params.Description != nil && article.Description != *params.Description {
+			article.Description = *params.Description
+		}
+
+		if params.Content != nil && article.Content != *params.Content {
+			article.Content = *params.Content
+		}
 
-	if params.ImageIds != nil {
-		images := make([]*models.Image, 0)
-		for _, id := range params.ImageIds {
-			image := new(models.Image)
-			// 检查图片是否存在
-			if err := s.db.Where("id = ?", id).First(image).Error; err != nil {
-				continue
+		if params.CategoryID != nil && article.CategoryID != *params.CategoryID {
+			// 检查分类是否存在
+			if err := tx.Where("id = ?", *params.CategoryID).First(&models.Category{}).Error; err != nil {
+				return ErrCategoryNotFound
 			}
-			images = append(images, image)
+			article.CategoryID = *params.CategoryID
 		}
-		s.db.Model(&article).Association("Images").Replace(images)
-	}
 
-	if params.TagIds != nil {
-		tags := make([]*models.Tag, 0)
-		for _, id := range params.TagIds {
-			tag := new(models.Tag)
-			// 检查标签是否存在
-			if err := s.db.Where("id = ?", id).First(tag).Error; err != nil {
-				continue
+		if params.Status != nil && article.Status != *params.Status {
+			if err := s.workflow.CanSetStatus(userID, *params.Status); err != nil {
+				return err
 			}
-			tags = append(tags, tag)
+			article.Status = *params.Status
+		}
+
+		if params.PublishAt != nil {
+			article.PublishAt = nonZeroTime(params.PublishAt)
+		}
+
+		if params.UnpublishAt != nil {
+			article.UnpublishAt = nonZeroTime(params.UnpublishAt)
+		}
+
+		if err := resolveArticleStatus(article, time.Now()); err != nil {
+			return err
 		}
-		s.db.Model(&article).Association("Tags").Replace(tags)
+
+		if params.ImageIds != nil {
+			images := make([]*models.Image, 0)
+			for _, id := range params.ImageIds {
+				image := new(models.Image)
+				// 检查图片是否存在
+				if err := tx.Where("id = ?", id).First(image).Error; err != nil {
+					continue
+				}
+				images = append(images, image)
+			}
+			if err := tx.Model(&article).Association("Images").Replace(images); err != nil {
+				return err
+			}
+		}
+
+		if params.TagIds != nil {
+			tags := make([]*models.Tag, 0)
+			for _, id := range params.TagIds {
+				tag := new(models.Tag)
+				// 检查标签是否存在
+				if err := tx.Where("id = ?", id).First(tag).Error; err != nil {
+					continue
+				}
+				tags = append(tags, tag)
+			}
+			if err := tx.Model(&article).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(article).Error; err != nil {
+			return err
+		}
+
+		// 保存修改后的版本
+		return createArticleRevision(tx, article.ID, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.onChanged(id)
+
+	return nil
+}
+
+func (s *articleService) DeleteArticle(userID, id uuid.UUID) error {
+	article := new(models.Article)
+	// 检查文章是否存在
+	if err := s.db.Where("id = ?", id).First(article).Error; err != nil {
+		return ErrArticleNotFound
+	}
+
+	// 检查是否是文章作者
+	if err := s.workflow.CanModify(userID, article); err != nil {
+		return err
 	}
 
-	return s.db.Save(article).Error
+	if err := s.db.Delete(article).Error; err != nil {
+		return err
+	}
+
+	s.onChanged(article.ID)
+
+	return nil
 }
 
-func (s *articleService) DeleteArticle(id uuid.UUID) error {
+func (s *articleService) TransferArticle(id uuid.UUID, params domain.TransferArticleParams) error {
 	article := new(models.Article)
 	// 检查文章是否存在
 	if err := s.db.Where("id = ?", id).First(article).Error; err != nil {
 		return ErrArticleNotFound
 	}
 
-	return s.db.Delete(article).Error
+	// 检查用户是否存在
+	if err := s.db.Where("id = ?", params.UserID).First(&models.User{}).Error; err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.db.Model(article).Update("user_id", params.UserID).Error; err != nil {
+		return err
+	}
+
+	s.onChanged(article.ID)
+
+	return nil
 }
 
 // GetArticlesByCategoryAliasWithCache 根据分类别名获取文章列表，带缓存
 func (s *articleService) GetArticlesByCategoryAliasWithCache(alias string, params domain.GetArticlesByCategoryAliasWithCacheParams) (*domain.LimitResponse[*models.Article], error) {
+	key := fmt.Sprintf("%scategory:%s:%d:%d", articleListCacheKeyPrefix, alias, params.Page, params.PageSize)
+	return cache.RememberWithTTL(s.cache, key, func() (*domain.LimitResponse[*models.Article], time.Duration, error) {
+		now := time.Now()
+		res, err := s.getArticlesByCategoryAlias(alias, params, now)
+		return res, scheduleTTL(s.db, s.cache, now), err
+	})
+}
+
+func (s *articleService) getArticlesByCategoryAlias(alias string, params domain.GetArticlesByCategoryAliasWithCacheParams, now time.Time) (*domain.LimitResponse[*models.Article], error) {
 	var count int64
 	var articles []*models.Article
 
 	// 基础查询
-	model := s.db.Model(&models.Article{}).Where("category_id = (SELECT id FROM categories WHERE alias = ?) AND status = ?", alias, models.StatusPublished)
+	model := s.db.Model(&models.Article{}).Where("category_id = (SELECT id FROM categories WHERE alias = ?)", alias).Scopes(s.articleScope.Visible(now))
 	// 统计总数
 	if err := model.Count(&count).Error; err != nil {
 		return nil, err
@@ -231,29 +401,111 @@ func (s *articleService) GetArticlesByCategoryAliasWithCache(alias string, param
 }
 
 func (s *articleService) GetArticleByIDWithCache(id uuid.UUID) (*models.Article, error) {
+	article, err := cache.Remember(s.cache, articleCacheKeyPrefix+id.String(), 0, func() (*models.Article, error) {
+		return s.getArticleByID(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存中的文章可能已超出发布窗口
+	if !article.IsVisible(time.Now()) {
+		return nil, ErrArticleNotFound
+	}
+
+	return article, nil
+}
+
+func (s *articleService) getArticleByID(id uuid.UUID) (*models.Article, error) {
 	article := new(models.Article)
 	// 检查文章是否存在
-	if err := s.db.Preload(clause.Associations).Where("id = ? AND status = ?", id, models.StatusPublished).First(article).Error; err != nil {
+	if err := s.db.Preload(clause.Associations).Scopes(s.articleScope.Visible(time.Now())).Where("id = ?", id).First(article).Error; err != nil {
 		return nil, ErrArticleNotFound
 	}
 
 	return article, nil
 }
 
+func (s *articleService) GetArticleBySlugWithCache(slug string) (*models.Article, error) {
+	article, err := cache.Remember(s.cache, articleListCacheKeyPrefix+"slug:"+slug, 0, func() (*models.Article, error) {
+		article := new(models.Article)
+		if err := s.db.Preload(clause.Associations).Where("slug = ?", slug).First(article).Error; err == nil {
+			return article, nil
+		}
+
+		// 查找旧 slug
+		history := new(models.ArticleSlug)
+		if err := s.db.Where("slug = ?", slug).First(history).Error; err != nil {
+			return nil, ErrArticleNotFound
+		}
+		if err := s.db.Preload(clause.Associations).Where("id = ?", history.ArticleID).First(article).Error; err != nil {
+			return nil, ErrArticleNotFound
+		}
+		return article, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !article.IsVisible(time.Now()) {
+		return nil, ErrArticleNotFound
+	}
+
+	if article.Slug != slug {
+		return article, ErrArticleSlugMoved
+	}
+
+	return article, nil
+}
+
+func (s *articleService) GenerateMissingSlugs() error {
+	var articles []*models.Article
+	if err := s.db.Where("slug IS NULL OR slug = ''").Find(&articles).Error; err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		slug, err := s.resolveSlug(nil, article.Title, article.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.db.Model(article).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(articles) > 0 {
+		log.Infof("已为 %d 篇文章生成 slug", len(articles))
+		invalidateArticleCache(s.cache)
+	}
+	return nil
+}
+
 func (s *articleService) GetRelatedArticlesByIDWithCache(id uuid.UUID, params domain.GetRelatedArticlesByIDWithCacheParams) (*domain.LimitResponse[*models.Article], error) {
+	key := fmt.Sprintf("%srelated:%s:%d:%d", articleListCacheKeyPrefix, id, params.Page, params.PageSize)
+	return cache.RememberWithTTL(s.cache, key, func() (*domain.LimitResponse[*models.Article], time.Duration, error) {
+		now := time.Now()
+		res, err := s.getRelatedArticlesByID(id, params, now)
+		return res, scheduleTTL(s.db, s.cache, now), err
+	})
+}
+
+func (s *articleService) getRelatedArticlesByID(id uuid.UUID, params domain.GetRelatedArticlesByIDWithCacheParams, now time.Time) (*domain.LimitResponse[*models.Article], error) {
 	article := new(models.Article)
 	// 检查文章是否存在
-	if err := s.db.Preload(clause.Associations).Where("id = ? AND status = ?", id, models.StatusPublished).First(article).Error; err != nil {
+	if err := s.db.Preload(clause.Associations).Scopes(s.articleScope.Visible(now)).Where("id = ?", id).First(article).Error; err != nil {
 		return nil, ErrArticleNotFound
 	}
 
 	var count int64
 	var relatedArticles []*models.Article
 
-	// 基础查询
+	// 基础查询：同分类或有相同标签的公开文章
 	model := s.db.Model(&models.Article{}).
-		Where("category_id = ? AND id != ? AND status = ?", article.CategoryID, id, models.StatusPublished).
-		Or("id IN (SELECT article_id FROM article_tags WHERE tag_id IN (SELECT tag_id FROM article_tags WHERE article_id = ?)) AND id != ?", id, id)
+		Scopes(s.articleScope.Visible(now)).
+		Where("id != ?", id).
+		Where(s.db.Where("category_id = ?", article.CategoryID).
+			Or("id IN (SELECT article_id FROM article_tags WHERE tag_id IN (SELECT tag_id FROM article_tags WHERE article_id = ?))", id))
 
 	// 统计总数
 	if err := model.Count(&count).Error; err != nil {
@@ -276,3 +528,134 @@ func (s *articleService) GetRelatedArticlesByIDWithCache(id uuid.UUID, params do
 		Pages: totalPages,
 	}, nil
 }
+
+// onChanged 文章变更后清除缓存并更新搜索索引
+func (s *articleService) onChanged(ids ...uuid.UUID) {
+	onArticlesChanged(s.cache, s.searchService, ids...)
+}
+
+// resolveSlug 确定文章的 slug：指定时检查是否可用，未指定时根据标题生成不重复的 slug
+func (s *articleService) resolveSlug(explicit *string, title string, articleID uuid.UUID) (string, error) {
+	if explicit != nil && *explicit != "" {
+		slug := utils.Slugify(*explicit)
+		if slug == "" {
+			return "", ErrArticleSlugInvalid
+		}
+		if s.slugTaken(slug, articleID) {
+			return "", ErrArticleSlugAlreadyExists
+		}
+		return slug, nil
+	}
+
+	base := utils.Slugify(title)
+	if base == "" {
+		base = "article"
+	}
+
+	slug := base
+	for i := 2; s.slugTaken(slug, articleID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug, nil
+}
+
+// slugTaken 检查 slug 是否已被其他文章使用（包括其他文章的旧 slug）
+func (s *articleService) slugTaken(slug string, articleID uuid.UUID) bool {
+	var count int64
+	// 软删除的文章仍然占用唯一索引
+	s.db.Unscoped().Model(&models.Article{}).Where("slug = ? AND id != ?", slug, articleID).Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	s.db.Model(&models.ArticleSlug{}).Where("slug = ? AND article_id != ?", slug, articleID).Count(&count)
+	return count > 0
+}
+
+// changeSlug 修改文章 slug，旧 slug 保留用于跳转。需要与文章的保存在同一个事务中调用，
+// 保存失败时跳转记录一起回滚
+func (s *articleService) changeSlug(tx *gorm.DB, article *models.Article, slug string) error {
+	if article.Slug == slug {
+		return nil
+	}
+
+	if article.Slug != "" {
+		if err := tx.Create(&models.ArticleSlug{Slug: article.Slug, ArticleID: article.ID}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 改回曾经使用过的 slug 时删除对应的历史记录
+	if err := tx.Where("slug = ? AND article_id = ?", slug, article.ID).Delete(&models.ArticleSlug{}).Error; err != nil {
+		return err
+	}
+
+	article.Slug = slug
+	return nil
+}
+
+// scheduleTTL 计算列表缓存的过期时间，不超过下一次定时发布或下线的时间点
+func scheduleTTL(db *gorm.DB, c cache.Cache, now time.Time) time.Duration {
+	var nextPublish, nextUnpublish sql.NullTime
+	db.Model(&models.Article{}).
+		Where("status = ? AND publish_at > ?", models.StatusScheduled, now).
+		Select("MIN(publish_at)").Row().Scan(&nextPublish)
+	db.Model(&models.Article{}).
+		Where("status IN ? AND unpublish_at > ?", []models.ArticleStatus{models.StatusPublished, models.StatusScheduled}, now).
+		Select("MIN(unpublish_at)").Row().Scan(&nextUnpublish)
+
+	// 默认过期时间不大于 0 时表示永不过期
+	ttl := c.TTL()
+	for _, t := range []sql.NullTime{nextPublish, nextUnpublish} {
+		if t.Valid && (ttl <= 0 || t.Time.Sub(now) < ttl) {
+			ttl = max(t.Time.Sub(now), time.Second)
+		}
+	}
+	return ttl
+}
+
+// invalidateArticleCache 清除文章详情缓存以及所有文章列表缓存
+func invalidateArticleCache(c cache.Cache, ids ...uuid.UUID) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, articleCacheKeyPrefix+id.String())
+	}
+
+	if err := c.Delete(keys...); err != nil {
+		log.Errorf("清除文章缓存失败: %v", err)
+	}
+	if err := c.DeletePrefix(articleListCacheKeyPrefix); err != nil {
+		log.Errorf("清除文章列表缓存失败: %v", err)
+	}
+}
+
+// resolveArticleStatus 根据发布、下线时间修正文章状态
+func resolveArticleStatus(article *models.Article, now time.Time) error {
+	if article.PublishAt != nil && article.UnpublishAt != nil &&
+		!time.Time(*article.UnpublishAt).After(time.Time(*article.PublishAt)) {
+		return ErrArticleScheduleInvalid
+	}
+
+	if article.Status != models.StatusPublished && article.Status != models.StatusScheduled {
+		return nil
+	}
+
+	switch {
+	case article.UnpublishAt != nil && !time.Time(*article.UnpublishAt).After(now):
+		// 已过下线时间
+		article.Status = models.StatusDraft
+	case article.PublishAt != nil && time.Time(*article.PublishAt).After(now):
+		article.Status = models.StatusScheduled
+	default:
+		article.Status = models.StatusPublished
+	}
+	return nil
+}
+
+// nonZeroTime 将零值时间转换为 nil
+func nonZeroTime(t *models.CustomTime) *models.CustomTime {
+	if t == nil || time.Time(*t).IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrArticleStatusForbidden 没有权限将文章设置为该状态
+	ErrArticleStatusForbidden = errors.New("没有权限将文章设置为该状态")
+	// ErrArticleLocked 文章已进入审核流程
+	ErrArticleLocked = errors.New("文章已进入审核流程，只有审核人可以修改")
+	// ErrArticlePublished 已发布的文章需要撤回后修改
+	ErrArticlePublished = errors.New("文章已发布，请先撤回再修改")
+	// ErrNotReviewer 不是审核人
+	ErrNotReviewer = errors.New("没有审核权限")
+	// ErrArticleNotOwner 不是文章作者
+	ErrArticleNotOwner = errors.New("只能修改自己的文章")
+)
+
+type (
+	// ArticleWorkflow 文章审核流程规则
+	ArticleWorkflow interface {
+		Enabled() bool
+		AllowSelfReview() bool
+		IsReviewer(userID uuid.UUID) (bool, error)
+		// CanSetStatus 检查用户能否通过创建或修改文章直接设置状态，
+		// 开启审核流程时只能设置为草稿，发布需要经过 提交 → 通过 → 发布
+		CanSetStatus(userID uuid.UUID, status models.ArticleStatus) error
+		// CanEdit 检查用户能否修改处于当前状态的文章，开启审核流程时已发布的文章
+		// 任何人都不能直接修改，需要撤回为草稿后重新提交审核
+		CanEdit(userID uuid.UUID, article *models.Article) error
+		// CanModify 检查用户能否修改、删除文章：作者本人、拥有 article:manage 权限的用户，
+		// 以及审核过程中的审核人
+		CanModify(userID uuid.UUID, article *models.Article) error
+	}
+	articleWorkflow struct {
+		db          *gorm.DB
+		cfg         *config.WorkflowConfig
+		rbacService RBACService
+	}
+)
+
+func NewArticleWorkflow(db *gorm.DB, cfg *config.WorkflowConfig, rbacService RBACService) ArticleWorkflow {
+	return &articleWorkflow{db: db, cfg: cfg, rbacService: rbacService}
+}
+
+func (w *articleWorkflow) Enabled() bool {
+	return w.cfg.Enabled
+}
+
+func (w *articleWorkflow) AllowSelfReview() bool {
+	return w.cfg.AllowSelfReview
+}
+
+func (w *articleWorkflow) IsReviewer(userID uuid.UUID) (bool, error) {
+	user := new(models.User)
+	if err := w.db.Where("id = ?", userID).First(user).Error; err != nil {
+		return false, ErrUserNotFound
+	}
+
+	// WORKFLOW_REVIEWERS 中的用户始终可以审核
+	if slices.Contains(w.cfg.Reviewers, user.Username) {
+		return true, nil
+	}
+
+	return w.rbacService.HasPermission(userID, models.PermArticleReview)
+}
+
+func (w *articleWorkflow) CanSetStatus(userID uuid.UUID, status models.ArticleStatus) error {
+	if status == models.StatusDraft {
+		return nil
+	}
+
+	// 开启审核流程时其他状态只能通过审核接口切换，审核人也不能跳过审核直接发布
+	if w.cfg.Enabled {
+		return ErrArticleStatusForbidden
+	}
+
+	// 未开启审核流程时，拥有发布权限的用户可以直接发布
+	if status != models.StatusPublished && status != models.StatusScheduled {
+		return nil
+	}
+
+	canPublish, err := w.rbacService.HasPermission(userID, models.PermArticlePublish)
+	if err != nil {
+		return err
+	}
+	if !canPublish {
+		return ErrArticleStatusForbidden
+	}
+	return nil
+}
+
+func (w *articleWorkflow) CanEdit(userID uuid.UUID, article *models.Article) error {
+	if !w.cfg.Enabled || article.Status == models.StatusDraft || article.Status == models.StatusRejected {
+		return nil
+	}
+
+	if article.Status == models.StatusPublished || article.Status == models.StatusScheduled {
+		return ErrArticlePublished
+	}
+
+	isReviewer, err := w.IsReviewer(userID)
+	if err != nil {
+		return err
+	}
+	if !isReviewer {
+		return ErrArticleLocked
+	}
+	return nil
+}
+
+func (w *articleWorkflow) CanModify(userID uuid.UUID, article *models.Article) error {
+	if article.UserID == userID {
+		return nil
+	}
+
+	canManage, err := w.rbacService.HasPermission(userID, models.PermArticleManage)
+	if err != nil {
+		return err
+	}
+	if canManage {
+		return nil
+	}
+
+	// 审核人需要修改审核中的文章
+	if w.cfg.Enabled && (article.Status == models.StatusInReview || article.Status == models.StatusApproved) {
+		isReviewer, err := w.IsReviewer(userID)
+		if err != nil {
+			return err
+		}
+		if isReviewer {
+			return nil
+		}
+	}
+
+	return ErrArticleNotOwner
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models/domain"
+	"cms/utils/cache"
+	"cms/utils/captcha"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// 验证码缓存前缀
+const captchaCacheKeyPrefix = "captcha:"
+
+var (
+	// ErrCaptchaRequired 需要验证码
+	ErrCaptchaRequired = errors.New("请输入验证码")
+	// ErrCaptchaInvalid 验证码错误或已过期
+	ErrCaptchaInvalid = errors.New("验证码错误或已过期")
+)
+
+type (
+	CaptchaService interface {
+		Generate(mode string) (*domain.CaptchaResponse, error)
+		// Verify 校验验证码，无论是否正确验证码都会失效
+		Verify(id, answer string) error
+		// Required 同一用户名或 IP 登录失败次数达到阈值后需要验证码
+		Required(username, ip string) bool
+	}
+	captchaService struct {
+		cache      cache.Cache
+		loginGuard LoginGuard
+		cfg        *config.CaptchaConfig
+	}
+)
+
+// NewCaptchaService 验证码答案保存在普通缓存中，被淘汰或清空时只会导致验证失败；
+// 是否需要验证码由 loginGuard 的失败计数决定
+func NewCaptchaService(cache cache.Cache, loginGuard LoginGuard, cfg *config.CaptchaConfig) CaptchaService {
+	return &captchaService{cache: cache, loginGuard: loginGuard, cfg: cfg}
+}
+
+func (s *captchaService) Generate(mode string) (*domain.CaptchaResponse, error) {
+	if mode == "" {
+		mode = s.cfg.Mode
+	}
+
+	c, err := captcha.New(mode, s.cfg.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Set(captchaCacheKeyPrefix+c.ID, []byte(c.Answer), s.cfg.TTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.CaptchaResponse{
+		ID:        c.ID,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(c.Image),
+		ExpiresIn: int64(s.cfg.TTL.Seconds()),
+	}, nil
+}
+
+func (s *captchaService) Verify(id, answer string) error {
+	if id == "" || answer == "" {
+		return ErrCaptchaRequired
+	}
+
+	key := captchaCacheKeyPrefix + id
+	expected, ok := s.cache.Get(key)
+	if !ok {
+		return ErrCaptchaInvalid
+	}
+	// 验证码只能使用一次
+	if err := s.cache.Delete(key); err != nil {
+		log.Errorf("删除验证码失败: %v", err)
+	}
+
+	if string(expected) != strings.TrimSpace(answer) {
+		return ErrCaptchaInvalid
+	}
+	return nil
+}
+
+func (s *captchaService) Required(username, ip string) bool {
+	if !s.cfg.Enabled {
+		return false
+	}
+	return s.loginGuard.Failures(username, ip) >= int64(s.cfg.AfterFailures)
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"cms/models"
 	"cms/models/domain"
+	"cms/utils/cache"
 	"errors"
 
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,6 +23,9 @@ var (
 	ErrCategoryHasArticles = errors.New("分类下存在文章")
 )
 
+// 分类缓存键前缀
+const categoryCacheKeyPrefix = "category:"
+
 type (
 	CategoryService interface {
 		GetCategorys() ([]*models.Category, error)
@@ -32,12 +37,13 @@ type (
 		GetCategoryByIDWithCache(articleID uuid.UUID) (*models.Category, error)
 	}
 	categoryService struct {
-		db *gorm.DB
+		db    *gorm.DB
+		cache cache.Cache
 	}
 )
 
-func NewCategoryService(db *gorm.DB) CategoryService {
-	return &categoryService{db: db}
+func NewCategoryService(db *gorm.DB, cache cache.Cache) CategoryService {
+	return &categoryService{db: db, cache: cache}
 }
 
 func (s *categoryService) GetCategorys() ([]*models.Category, error) {
@@ -66,7 +72,13 @@ func (s *categoryService) CreateCategory(params domain.CreateCategoryParams) err
 		Description: params.Description,
 	}
 
-	return s.db.Create(&categoryModel).Error
+	if err := s.db.Create(&categoryModel).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *categoryService) UpdateCategory(id uuid.UUID, params domain.UpdateCategoryParams) error {
@@ -101,7 +113,13 @@ func (s *categoryService) UpdateCategory(id uuid.UUID, params domain.UpdateCateg
 		category.Description = *params.Description
 	}
 
-	return s.db.Save(category).Error
+	if err := s.db.Save(category).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *categoryService) DeleteCategory(id uuid.UUID) error {
@@ -115,29 +133,45 @@ func (s *categoryService) DeleteCategory(id uuid.UUID) error {
 		return ErrCategoryHasArticles
 	}
 
-	return s.db.Delete(category).Error
+	if err := s.db.Delete(category).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *categoryService) GetCategorysWithCache() ([]*models.Category, error) {
-	var categories []*models.Category
-	if err := s.db.Order("sort DESC, created_at ASC").Find(&categories).Error; err != nil {
-		return nil, err
-	}
-	return categories, nil
+	return cache.Remember(s.cache, categoryCacheKeyPrefix+"list", 0, s.GetCategorys)
 }
 
 func (s *categoryService) GetCategoryByAliasWithCache(alias string) (*models.Category, error) {
-	var category models.Category
-	if err := s.db.Where("alias = ?", alias).First(&category).Error; err != nil {
-		return nil, err
-	}
-	return &category, nil
+	return cache.Remember(s.cache, categoryCacheKeyPrefix+"alias:"+alias, 0, func() (*models.Category, error) {
+		var category models.Category
+		if err := s.db.Where("alias = ?", alias).First(&category).Error; err != nil {
+			return nil, err
+		}
+		return &category, nil
+	})
 }
 
 func (s *categoryService) GetCategoryByIDWithCache(articleID uuid.UUID) (*models.Category, error) {
-	var category models.Category
-	if err := s.db.Where("id = ?", articleID).First(&category).Error; err != nil {
-		return nil, err
+	return cache.Remember(s.cache, categoryCacheKeyPrefix+"id:"+articleID.String(), 0, func() (*models.Category, error) {
+		var category models.Category
+		if err := s.db.Where("id = ?", articleID).First(&category).Error; err != nil {
+			return nil, err
+		}
+		return &category, nil
+	})
+}
+
+// invalidate 清除分类缓存，分类别名变化会影响按别名查询的文章列表，一并清除
+func (s *categoryService) invalidate() {
+	if err := s.cache.DeletePrefix(categoryCacheKeyPrefix); err != nil {
+		log.Errorf("清除分类缓存失败: %v", err)
+	}
+	if err := s.cache.DeletePrefix(articleListCacheKeyPrefix); err != nil {
+		log.Errorf("清除文章列表缓存失败: %v", err)
 	}
-	return &category, nil
 }
@@ -3,8 +3,10 @@ package services
 import (
 	"cms/models"
 	"cms/models/domain"
+	"cms/utils/cache"
 	"errors"
 
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -20,6 +22,9 @@ var (
 	ErrDictCodeNotFound = errors.New("字典code不存在")
 )
 
+// 字典缓存键前缀，子字典列表依赖父字典，任何变更都清除整个前缀
+const dictCacheKeyPrefix = "dict:"
+
 type (
 	DictService interface {
 		GetDicts() ([]*models.Dict, error)
@@ -31,12 +36,13 @@ type (
 		GetDictByCodeWithCache(code string) (*models.Dict, error)
 	}
 	dictService struct {
-		db *gorm.DB
+		db    *gorm.DB
+		cache cache.Cache
 	}
 )
 
-func NewDictService(db *gorm.DB) DictService {
-	return &dictService{db: db}
+func NewDictService(db *gorm.DB, cache cache.Cache) DictService {
+	return &dictService{db: db, cache: cache}
 }
 
 func (s *dictService) GetDicts() ([]*models.Dict, error) {
@@ -73,7 +79,13 @@ func (s *dictService) CreateDict(params domain.CreateDictParams) error {
 		dictModel.ImageID = params.ImageID
 	}
 
-	return s.db.Create(&dictModel).Error
+	if err := s.db.Create(&dictModel).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *dictService) UpdateDict(id uuid.UUID, params domain.UpdateDictParams) error {
@@ -119,7 +131,13 @@ func (s *dictService) UpdateDict(id uuid.UUID, params domain.UpdateDictParams) e
 	// 	dictModel.ParentID = params.ParentID
 	// }
 
-	return s.db.Save(dict).Error
+	if err := s.db.Save(dict).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *dictService) DeleteDict(id uuid.UUID) error {
@@ -134,26 +152,40 @@ func (s *dictService) DeleteDict(id uuid.UUID) error {
 		return errors.New("字典有子字典，无法删除")
 	}
 
-	return s.db.Delete(dict).Error
+	if err := s.db.Delete(dict).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
 }
 
 func (s *dictService) GetDictExtraByCodeWithCache(code string) (string, error) {
-	dict := new(models.Dict)
-	if err := s.db.Where("code = ?", code).First(dict).Error; err != nil {
+	dict, err := s.GetDictByCodeWithCache(code)
+	if err != nil {
 		return "", err
 	}
 	return dict.Extra, nil
 }
 
 func (s *dictService) GetDictByCodeWithCache(code string) (*models.Dict, error) {
-	dict := new(models.Dict)
-	if err := s.db.Where("code = ?", code).First(dict).Error; err != nil {
-		return nil, err
-	}
-	return dict, nil
+	return cache.Remember(s.cache, dictCacheKeyPrefix+"code:"+code, 0, func() (*models.Dict, error) {
+		dict := new(models.Dict)
+		if err := s.db.Where("code = ?", code).First(dict).Error; err != nil {
+			return nil, err
+		}
+		return dict, nil
+	})
 }
 
 func (s *dictService) GetSubDictsByCodeWithCache(code string) ([]*models.Dict, error) {
+	return cache.Remember(s.cache, dictCacheKeyPrefix+"sub:"+code, 0, func() ([]*models.Dict, error) {
+		return s.getSubDictsByCode(code)
+	})
+}
+
+func (s *dictService) getSubDictsByCode(code string) ([]*models.Dict, error) {
 	dict := new(models.Dict)
 	// 检查字典是否存在
 	if err := s.db.Where("code = ?", code).First(dict).Error; err != nil {
@@ -171,3 +203,10 @@ func (s *dictService) GetSubDictsByCodeWithCache(code string) ([]*models.Dict, e
 	// 将子字典列表添加到字典列表中
 	return subDicts, nil
 }
+
+// invalidate 清除字典缓存
+func (s *dictService) invalidate() {
+	if err := s.cache.DeletePrefix(dictCacheKeyPrefix); err != nil {
+		log.Errorf("清除字典缓存失败: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/scopes"
+	"cms/utils/cache"
+	"cms/utils/feed"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	FeedService interface {
+		// GetFeed 获取最新公开文章的订阅源，alias 为空时返回全站订阅源
+		GetFeed(alias string) (*feed.Feed, error)
+	}
+	feedService struct {
+		db           *gorm.DB
+		cache        cache.Cache
+		articleScope scopes.ArticleScope
+		site         *config.SiteConfig
+	}
+)
+
+func NewFeedService(db *gorm.DB, cache cache.Cache, articleScope scopes.ArticleScope, site *config.SiteConfig) FeedService {
+	return &feedService{
+		db:           db,
+		cache:        cache,
+		articleScope: articleScope,
+		site:         site,
+	}
+}
+
+func (s *feedService) GetFeed(alias string) (*feed.Feed, error) {
+	return cache.RememberWithTTL(s.cache, articleListCacheKeyPrefix+"feed:"+alias, func() (*feed.Feed, time.Duration, error) {
+		now := time.Now()
+		f, err := s.buildFeed(alias, now)
+		return f, scheduleTTL(s.db, s.cache, now), err
+	})
+}
+
+func (s *feedService) buildFeed(alias string, now time.Time) (*feed.Feed, error) {
+	f := &feed.Feed{
+		Title:       s.site.Title,
+		Link:        s.site.URL,
+		Description: s.site.Description,
+		Language:    s.site.Language,
+		Items:       make([]*feed.Item, 0),
+	}
+
+	model := s.db.Model(&models.Article{}).Scopes(s.articleScope.Visible(now))
+
+	if alias != "" {
+		category := new(models.Category)
+		if err := s.db.Where("alias = ?", alias).First(category).Error; err != nil {
+			return nil, ErrCategoryNotFound
+		}
+
+		f.Title = fmt.Sprintf("%s - %s", category.Name, s.site.Title)
+		f.Link = s.site.CategoryURL(category.Alias)
+		if category.Description != "" {
+			f.Description = category.Description
+		}
+		model = model.Where("category_id = ?", category.ID)
+	}
+
+	var articles []*models.Article
+	if err := model.
+		Preload("Tags").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Order("COALESCE(publish_at, created_at) DESC").
+		Limit(s.site.FeedSize).
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	// 作者昵称
+	userIDs := make([]uuid.UUID, 0, len(articles))
+	for _, article := range articles {
+		userIDs = append(userIDs, article.UserID)
+	}
+	var users []*models.User
+	if len(userIDs) > 0 {
+		if err := s.db.Select("id", "nickname").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	nicknames := make(map[uuid.UUID]string, len(users))
+	for _, user := range users {
+		nicknames[user.ID] = user.Nickname
+	}
+
+	for _, article := range articles {
+		item := &feed.Item{
+			ID:          "urn:uuid:" + article.ID.String(),
+			Title:       article.Title,
+			Link:        s.site.ArticleURL(article.Slug),
+			Description: article.Description,
+			Author:      nicknames[article.UserID],
+			Categories:  make([]string, 0, len(article.Tags)),
+			Published:   time.Time(article.CreatedAt),
+			Updated:     time.Time(article.UpdatedAt),
+		}
+		if article.PublishAt != nil {
+			item.Published = time.Time(*article.PublishAt)
+		}
+		for _, tag := range article.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		if len(article.Images) > 0 {
+			item.Enclosure = s.newEnclosure(article.Images[0])
+		}
+
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		if item.Published.After(f.Updated) {
+			f.Updated = item.Published
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+// newEnclosure 将图片转换为订阅源附件
+func (s *feedService) newEnclosure(image *models.Image) *feed.Enclosure {
+	// 使用上传时根据文件内容识别的类型，标题只是用户填写的名称
+	enclosure := &feed.Enclosure{
+		URL:  s.site.AbsURL("api/common/image/download/" + image.ID.String()),
+		Type: image.MimeType,
+	}
+	// 未补充信息的旧图片类型为空、大小为 0，见 cmd/imagemeta，文件大小仅用于提示
+	if enclosure.Type == "" {
+		enclosure.Type = "application/octet-stream"
+	}
+	enclosure.Length = image.Size
+
+	return enclosure
+}
@@ -1,13 +1,25 @@
 package services
 
 import (
+	"bytes"
+	"cms/config"
 	"cms/models"
 	"cms/models/domain"
+	"cms/utils/cache"
+	"cms/utils/imaging"
+	"cms/utils/storage"
 	"errors"
 	"fmt"
+	"image"
+	"io"
 	"os"
-	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -25,6 +37,18 @@ var (
 
 	// ErrDictInUseByUser 图片正在被字典使用中
 	ErrDictInUseByUser = errors.New("图片正在被字典使用中")
+
+	// ErrImageVariantNotAllowed 请求的尺寸或质量不在允许的范围内
+	ErrImageVariantNotAllowed = errors.New("不支持的图片尺寸或质量")
+
+	// ErrImageTooLarge 图片文件过大
+	ErrImageTooLarge = errors.New("图片文件过大")
+	// ErrImageFormatNotAllowed 文件不是允许上传的图片格式
+	ErrImageFormatNotAllowed = errors.New("不支持的图片格式")
+	// ErrImageDimensionsTooLarge 图片宽高或像素数超过限制
+	ErrImageDimensionsTooLarge = errors.New("图片尺寸超过限制")
+	// ErrImageCorrupt 图片无法解码
+	ErrImageCorrupt = errors.New("图片已损坏或无法解码")
 )
 
 type (
@@ -33,15 +57,43 @@ type (
 		CreateImage(image domain.CreateImageParams) (*models.Image, error)
 		GetImageByHash(hash uint64) (*models.Image, error)
 		GetImageById(id uuid.UUID) (*models.Image, error)
-		DeleteImage(id uuid.UUID, uploadPath string) error
+		DeleteImage(id uuid.UUID) error
+		// ValidateImage 根据文件头判断格式并检查大小和尺寸，返回可以保存的内容，SVG 返回清理后的内容
+		ValidateImage(data []byte) ([]byte, error)
+		// ProcessUpload 上传处理流程：校验、应用 EXIF 方向、删除元数据，返回需要保存的内容和哈希，见 ImageHash
+		ProcessUpload(data []byte) ([]byte, uint64, error)
+		// AnalyzeImage 读取图片的 MIME 类型、尺寸、大小、主色和占位符
+		AnalyzeImage(r io.Reader) (*imaging.Metadata, error)
+		// SaveImage 将 ProcessUpload 的结果保存到存储，文件名见 ImageKey
+		SaveImage(hash uint64, data []byte, contentType string) error
+		// OpenImage 读取原图，调用方负责关闭
+		OpenImage(image *models.Image) (io.ReadCloser, *storage.ObjectInfo, error)
+		// SignedURL 原图的下载链接，存储不支持时返回 storage.ErrSignedURLUnsupported
+		SignedURL(image *models.Image, ttl time.Duration) (string, error)
+		// BackfillMetadata 为缺少信息的图片补充 AnalyzeImage 的结果，all 为 true 时重新计算全部图片，返回更新的数量
+		BackfillMetadata(all bool) (int, error)
+		// GetVariant 获取缩放或转换格式后的图片，首次请求时生成并缓存到本地磁盘，返回文件路径和 MIME 类型
+		GetVariant(image *models.Image, params domain.ImageVariantParams) (string, string, error)
 	}
 	imageService struct {
-		db *gorm.DB
+		db          *gorm.DB
+		cfg         *config.ImageConfig
+		storage     storage.Storage
+		cache       cache.Cache
+		variantPath string
 	}
 )
 
-func NewImageService(db *gorm.DB) ImageService {
-	return &imageService{db: db}
+func NewImageService(db *gorm.DB, cfg *config.ImageConfig, store storage.Storage, cache cache.Cache) (ImageService, error) {
+	variantPath, err := filepath.Abs(cfg.VariantPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(variantPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &imageService{db: db, cfg: cfg, storage: store, cache: cache, variantPath: variantPath}, nil
 }
 
 func (s *imageService) GetImages() ([]*models.Image, error) {
@@ -54,8 +106,14 @@ func (s *imageService) GetImages() ([]*models.Image, error) {
 
 func (s *imageService) CreateImage(image domain.CreateImageParams) (*models.Image, error) {
 	imageModel := &models.Image{
-		Title: image.Title,
-		Hash:  image.Hash,
+		Title:         image.Title,
+		Hash:          image.Hash,
+		MimeType:      image.MimeType,
+		Width:         image.Width,
+		Height:        image.Height,
+		Size:          image.Size,
+		DominantColor: image.DominantColor,
+		Placeholder:   image.Placeholder,
 	}
 
 	if err := s.db.Create(imageModel).Error; err != nil {
@@ -81,7 +139,7 @@ func (s *imageService) GetImageById(id uuid.UUID) (*models.Image, error) {
 	return &image, nil
 }
 
-func (s *imageService) DeleteImage(id uuid.UUID, uploadPath string) error {
+func (s *imageService) DeleteImage(id uuid.UUID) error {
 	image := new(models.Image)
 
 	// 检查图片是否存在
@@ -104,10 +162,267 @@ func (s *imageService) DeleteImage(id uuid.UUID, uploadPath string) error {
 		return ErrDictInUseByUser
 	}
 
-	// 删除本地文件
-	if err := os.Remove(path.Join(uploadPath, fmt.Sprintf("%v", image.Hash))); err != nil {
+	// 删除原图
+	if err := s.storage.Delete(ImageKey(image.Hash)); err != nil {
 		return err
 	}
 
+	// 删除缓存的衍生图片
+	variants, _ := filepath.Glob(filepath.Join(s.variantPath, fmt.Sprintf("%d_*", image.Hash)))
+	for _, variant := range variants {
+		if err := os.Remove(variant); err != nil {
+			log.Errorf("删除衍生图片失败: %v", err)
+		}
+	}
+
 	return s.db.Delete(image).Error
 }
+
+func (s *imageService) ValidateImage(data []byte) ([]byte, error) {
+	if s.cfg.MaxBytes > 0 && int64(len(data)) > s.cfg.MaxBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	// 不信任客户端提供的 Content-Type 和文件名
+	format := imaging.Sniff(data)
+	if format == "" || !slices.Contains(s.cfg.AllowedFormats, format) {
+		return nil, ErrImageFormatNotAllowed
+	}
+
+	if format == imaging.FormatSVG {
+		clean, err := imaging.SanitizeSVG(data)
+		if err != nil {
+			return nil, ErrImageCorrupt
+		}
+		return clean, nil
+	}
+
+	// 解码前检查尺寸，防止解压炸弹
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageCorrupt
+	}
+	if (s.cfg.MaxWidth > 0 && cfg.Width > s.cfg.MaxWidth) ||
+		(s.cfg.MaxHeight > 0 && cfg.Height > s.cfg.MaxHeight) ||
+		(s.cfg.MaxPixels > 0 && cfg.Width*cfg.Height > s.cfg.MaxPixels) {
+		return nil, ErrImageDimensionsTooLarge
+	}
+
+	// 完整解码一次，拒绝文件头正确但内容被截断或篡改的文件
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return nil, ErrImageCorrupt
+	}
+
+	return data, nil
+}
+
+func (s *imageService) ProcessUpload(data []byte) ([]byte, uint64, error) {
+	data, err := s.ValidateImage(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err = imaging.Clean(data, imaging.Sniff(data), imaging.CleanOptions{
+		StripMetadata: s.cfg.StripMetadata,
+		AutoOrient:    s.cfg.AutoOrient,
+		KeepTags:      s.cfg.KeepMetadata,
+		Quality:       s.cfg.OrientQuality,
+	})
+	if err != nil {
+		return nil, 0, ErrImageCorrupt
+	}
+
+	return data, ImageHash(data), nil
+}
+
+// ImageHash 图片的哈希，即 models.Image.Hash，存储中的文件名由它生成，见 ImageKey。
+// 对处理后实际保存的文件内容计算 xxHash64，处理过程是确定的，同一文件重复上传得到相同的哈希
+func ImageHash(data []byte) uint64 {
+	return xxhash.Sum64(data)
+}
+
+// ImageKey 原图在存储中的文件名
+func ImageKey(hash uint64) string {
+	return strconv.FormatUint(hash, 10)
+}
+
+func (s *imageService) SaveImage(hash uint64, data []byte, contentType string) error {
+	return s.storage.Put(ImageKey(hash), bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+func (s *imageService) OpenImage(image *models.Image) (io.ReadCloser, *storage.ObjectInfo, error) {
+	info, err := s.storage.Stat(ImageKey(image.Hash))
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := s.storage.Get(ImageKey(image.Hash))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+func (s *imageService) SignedURL(image *models.Image, ttl time.Duration) (string, error) {
+	return s.storage.SignedURL(ImageKey(image.Hash), ttl, image.Title)
+}
+
+// readImage 读取整个原图
+func (s *imageService) readImage(image *models.Image) ([]byte, error) {
+	file, err := s.storage.Get(ImageKey(image.Hash))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (s *imageService) AnalyzeImage(r io.Reader) (*imaging.Metadata, error) {
+	return imaging.Analyze(r, s.cfg.MaxPixels)
+}
+
+func (s *imageService) BackfillMetadata(all bool) (int, error) {
+	model := s.db.Model(&models.Image{})
+	if !all {
+		model = model.Where("mime_type = '' OR mime_type IS NULL")
+	}
+
+	var images []*models.Image
+	if err := model.Order("created_at ASC").Find(&images).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	updatedIDs := make([]uuid.UUID, 0)
+	// 文章缓存中包含图片的类型、尺寸和占位符
+	defer func() {
+		if len(updatedIDs) == 0 {
+			return
+		}
+		var articleIDs []uuid.UUID
+		if err := s.db.Table("article_images").Where("image_id IN ?", updatedIDs).Distinct().Pluck("article_id", &articleIDs).Error; err != nil {
+			log.Errorf("查询图片所属文章失败: %v", err)
+			return
+		}
+		if len(articleIDs) > 0 {
+			invalidateArticleCache(s.cache, articleIDs...)
+		}
+	}()
+
+	for _, image := range images {
+		data, err := s.readImage(image)
+		if err != nil {
+			log.Errorf("读取图片 %s 失败: %v", image.ID, err)
+			continue
+		}
+		meta, err := s.AnalyzeImage(bytes.NewReader(data))
+		if err != nil {
+			log.Errorf("分析图片 %s 失败: %v", image.ID, err)
+			continue
+		}
+
+		if err := s.db.Model(image).Updates(map[string]any{
+			"mime_type":      meta.MimeType,
+			"width":          meta.Width,
+			"height":         meta.Height,
+			"size":           meta.Size,
+			"dominant_color": meta.DominantColor,
+			"placeholder":    meta.Placeholder,
+		}).Error; err != nil {
+			return updated, err
+		}
+		updated++
+		updatedIDs = append(updatedIDs, image.ID)
+	}
+
+	return updated, nil
+}
+
+func (s *imageService) GetVariant(image *models.Image, params domain.ImageVariantParams) (string, string, error) {
+	// 只允许预设的尺寸，避免任意参数组合占满磁盘
+	if (params.Width > 0 || params.Height > 0) &&
+		!slices.Contains(s.cfg.Presets, config.ImageSize{Width: params.Width, Height: params.Height}) {
+		return "", "", ErrImageVariantNotAllowed
+	}
+
+	// 原图可能在远程存储中，衍生图片已存在时不读取，需要时只读取一次
+	var original []byte
+	format := params.Format
+	if format == "" && image.MimeType != "" {
+		var err error
+		if format, err = imaging.DefaultFormatOf(image.MimeType); err != nil {
+			return "", "", err
+		}
+	}
+	// 没有保存类型的旧图片读取文件头判断
+	if format == "" {
+		var err error
+		if original, err = s.readImage(image); err != nil {
+			return "", "", err
+		}
+		if format, err = imaging.DefaultFormat(bytes.NewReader(original)); err != nil {
+			return "", "", err
+		}
+	}
+
+	// 质量只对 JPEG 有效，缩放方式只在同时指定宽高时有效，统一后避免生成相同的文件
+	quality := 0
+	if format == imaging.FormatJPEG {
+		quality = params.Quality
+		if quality == 0 {
+			quality = s.cfg.DefaultQuality
+		} else if !slices.Contains(s.cfg.Qualities, quality) {
+			return "", "", ErrImageVariantNotAllowed
+		}
+	}
+	fit := imaging.FitContain
+	if params.Fit == imaging.FitCover && params.Width > 0 && params.Height > 0 {
+		fit = imaging.FitCover
+	}
+
+	name := fmt.Sprintf("%d_%dx%d_%s_q%d.%s", image.Hash, params.Width, params.Height, fit, quality, format)
+	target := filepath.Join(s.variantPath, name)
+	contentType := imaging.ContentType(format)
+
+	if _, err := os.Stat(target); err == nil {
+		return target, contentType, nil
+	}
+
+	if original == nil {
+		var err error
+		if original, err = s.readImage(image); err != nil {
+			return "", "", err
+		}
+	}
+
+	data, _, err := imaging.Process(bytes.NewReader(original), imaging.Options{
+		Width:     params.Width,
+		Height:    params.Height,
+		Fit:       fit,
+		Quality:   quality,
+		Format:    format,
+		MaxPixels: s.cfg.MaxPixels,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// 先写入临时文件再重命名，并发请求不会读到不完整的文件
+	tmp, err := os.CreateTemp(s.variantPath, ".tmp-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", "", err
+	}
+
+	return target, contentType, nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"cms/config"
+	"cms/utils/keys"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// 验证时遇到未知 kid 重新加载密钥的最小间隔
+const keyReloadCooldown = 10 * time.Second
+
+var (
+	// ErrSigningKeyNotFound token 的 kid 不存在或已过期
+	ErrSigningKeyNotFound = errors.New("签名密钥不存在或已过期")
+	// ErrSigningMethodInvalid token 的签名算法不正确
+	ErrSigningMethodInvalid = errors.New("签名算法不正确")
+)
+
+type (
+	KeyService interface {
+		// Sign 使用当前密钥签名，并在头部写入 kid
+		Sign(claims jwt.Claims) (string, error)
+		// KeyFunc 根据 token 头部的 kid 返回验证用公钥，供 JWT 中间件使用
+		KeyFunc(token *jwt.Token) (any, error)
+		// JWKS 返回仍可用于验证的全部公钥
+		JWKS() *keys.JWKS
+		// Keys 返回仍可用于验证的全部密钥，按创建时间倒序
+		Keys() []*keys.Key
+		// Rotate 生成新密钥并下线当前密钥，下线的密钥在宽限期内仍可用于验证
+		Rotate() error
+		// Start 启动定时重新加载与轮换
+		Start()
+		Stop()
+	}
+	keyService struct {
+		mu         sync.RWMutex
+		store      keys.Store
+		cfg        *config.KeyConfig
+		keys       map[string]*keys.Key
+		active     *keys.Key
+		lastReload time.Time
+		stop       chan struct{}
+	}
+)
+
+func NewKeyService(db *gorm.DB, cfg *config.KeyConfig) (KeyService, error) {
+	var store keys.Store
+	switch cfg.Store {
+	case "", keys.StoreFile:
+		var err error
+		if store, err = keys.NewFileStore(cfg.Dir); err != nil {
+			return nil, err
+		}
+	case keys.StoreDatabase:
+		store = keys.NewDatabaseStore(db)
+	default:
+		return nil, keys.ErrUnknownStore
+	}
+
+	s := &keyService{
+		store: store,
+		cfg:   cfg,
+		keys:  make(map[string]*keys.Key),
+		stop:  make(chan struct{}),
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	if err := s.rotateIfDue(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *keyService) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	active := s.active
+	s.mu.RUnlock()
+
+	if active == nil {
+		return "", ErrSigningKeyNotFound
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.Private)
+}
+
+func (s *keyService) KeyFunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != jwt.SigningMethodRS512.Alg() {
+		return nil, ErrSigningMethodInvalid
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrSigningKeyNotFound
+	}
+
+	if key := s.lookup(kid); key != nil {
+		return key.Public(), nil
+	}
+
+	// 可能是其他实例刚轮换出的密钥
+	s.mu.RLock()
+	stale := time.Since(s.lastReload) > keyReloadCooldown
+	s.mu.RUnlock()
+	if stale {
+		if err := s.reload(); err != nil {
+			log.Errorf("重新加载签名密钥失败: %v", err)
+		}
+		if key := s.lookup(kid); key != nil {
+			return key.Public(), nil
+		}
+	}
+
+	return nil, ErrSigningKeyNotFound
+}
+
+func (s *keyService) JWKS() *keys.JWKS {
+	return keys.NewJWKS(jwt.SigningMethodRS512.Alg(), s.Keys())
+}
+
+func (s *keyService) Keys() []*keys.Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	list := make([]*keys.Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !s.expired(key, now) {
+			list = append(list, key)
+		}
+	}
+	slices.SortFunc(list, func(a, b *keys.Key) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return list
+}
+
+func (s *keyService) Rotate() error {
+	key, err := keys.Generate(s.cfg.Bits)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Save(key); err != nil {
+		return err
+	}
+
+	// 下线其他仍在签名的密钥
+	now := time.Now()
+	s.mu.RLock()
+	retiring := make([]*keys.Key, 0)
+	for _, old := range s.keys {
+		if old.Active() {
+			retiring = append(retiring, old)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, old := range retiring {
+		retired := *old
+		retired.RetiredAt = &now
+		if err := s.store.Save(&retired); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("已轮换签名密钥，当前 kid: %s", key.ID)
+	return s.reload()
+}
+
+func (s *keyService) Start() {
+	if s.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.reload(); err != nil {
+					log.Errorf("重新加载签名密钥失败: %v", err)
+					continue
+				}
+				if err := s.rotateIfDue(); err != nil {
+					log.Errorf("轮换签名密钥失败: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *keyService) Stop() {
+	close(s.stop)
+}
+
+// reload 从存储中加载密钥，并删除超过宽限期的旧密钥
+func (s *keyService) reload() error {
+	list, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	loaded := make(map[string]*keys.Key, len(list))
+	var active *keys.Key
+	for _, key := range list {
+		if s.expired(key, now) {
+			if err := s.store.Delete(key.ID); err != nil {
+				log.Errorf("删除过期签名密钥失败: %v", err)
+			}
+			continue
+		}
+
+		loaded[key.ID] = key
+		// 多个实例同时轮换时可能存在多个可用密钥，使用最新的签名
+		if key.Active() && (active == nil || key.CreatedAt.After(active.CreatedAt)) {
+			active = key
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = loaded
+	s.active = active
+	s.lastReload = now
+	return nil
+}
+
+// rotateIfDue 没有可用密钥或当前密钥已到轮换时间时轮换
+func (s *keyService) rotateIfDue() error {
+	s.mu.RLock()
+	active := s.active
+	s.mu.RUnlock()
+
+	if active != nil && (s.cfg.RotateInterval <= 0 || time.Since(active.CreatedAt) < s.cfg.RotateInterval) {
+		return nil
+	}
+	return s.Rotate()
+}
+
+func (s *keyService) lookup(kid string) *keys.Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[kid]
+	if !ok || s.expired(key, time.Now()) {
+		return nil
+	}
+	return key
+}
+
+// expired 判断下线的密钥是否已超过宽限期
+func (s *keyService) expired(key *keys.Key, now time.Time) bool {
+	return key.RetiredAt != nil && !key.RetiredAt.Add(s.cfg.GracePeriod).After(now)
+}
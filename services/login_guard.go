@@ -0,0 +1,176 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/utils/cache"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 登录失败计数缓存前缀
+const loginGuardCacheKeyPrefix = "login:"
+
+var (
+	// ErrLoginTooFrequent 登录失败后需要等待
+	ErrLoginTooFrequent = errors.New("登录失败次数过多，请稍后再试")
+	// ErrAccountLocked 账号已被锁定
+	ErrAccountLocked = errors.New("账号已被锁定，请稍后再试或联系管理员解锁")
+)
+
+type (
+	// LoginGuard 按用户名和 IP 统计登录失败，防止暴力破解。
+	// 计数保存在 cache.NewSecurityStore 创建的存储中，不会被其他缓存挤出或随清空缓存删除；账号锁定状态保存在数据库中
+	LoginGuard interface {
+		// Check 登录前检查，需要等待时返回 ErrLoginTooFrequent 和剩余等待时间
+		Check(username, ip string) (time.Duration, error)
+		// Fail 记录一次登录失败，达到上限时锁定账号
+		Fail(username, ip string)
+		// Failures 统计窗口内用户名和 IP 的失败次数中较大的一个
+		Failures(username, ip string) int64
+		// Succeed 登录成功后清除用户名的失败记录
+		Succeed(username, ip string)
+		// Unlock 解锁账号并清除失败记录
+		Unlock(userID uuid.UUID) error
+	}
+	loginGuard struct {
+		db    *gorm.DB
+		cache cache.Cache
+		cfg   *config.LoginConfig
+	}
+)
+
+func NewLoginGuard(db *gorm.DB, cache cache.Cache, cfg *config.LoginConfig) LoginGuard {
+	return &loginGuard{db: db, cache: cache, cfg: cfg}
+}
+
+func (g *loginGuard) Check(username, ip string) (time.Duration, error) {
+	now := time.Now()
+	wait := max(g.waitUntil(g.userKey(username)).Sub(now), g.waitUntil(g.ipKey(ip)).Sub(now))
+	if wait > 0 {
+		return wait, ErrLoginTooFrequent
+	}
+	return 0, nil
+}
+
+func (g *loginGuard) Fail(username, ip string) {
+	now := time.Now()
+
+	// 用户名：指数退避，达到上限后锁定账号
+	userKey := g.userKey(username)
+	failures, err := g.cache.Incr(userKey+":count", g.cfg.FailureWindow)
+	if err != nil {
+		log.Errorf("记录登录失败次数失败: %v", err)
+		return
+	}
+
+	if g.cfg.MaxFailures > 0 && failures >= int64(g.cfg.MaxFailures) {
+		lockedUntil := models.CustomTime(now.Add(g.cfg.LockDuration))
+		if err := g.db.Model(&models.User{}).Where("username = ?", username).Update("locked_until", &lockedUntil).Error; err != nil {
+			log.Errorf("锁定账号失败: %v", err)
+		}
+		log.Warnf("用户名 %s 连续登录失败 %d 次，已锁定至 %s", username, failures, time.Time(lockedUntil).Format(time.DateTime))
+		g.clear(userKey)
+	} else {
+		g.setWaitUntil(userKey, now.Add(g.backoff(failures)))
+	}
+
+	// IP：失败次数达到上限后暂时禁止登录
+	ipKey := g.ipKey(ip)
+	ipFailures, err := g.cache.Incr(ipKey+":count", g.cfg.FailureWindow)
+	if err != nil {
+		log.Errorf("记录登录失败次数失败: %v", err)
+		return
+	}
+	if g.cfg.IPMaxFailures > 0 && ipFailures >= int64(g.cfg.IPMaxFailures) {
+		log.Warnf("IP %s 登录失败 %d 次，暂时禁止登录", ip, ipFailures)
+		g.setWaitUntil(ipKey, now.Add(g.cfg.IPBlockDuration))
+		if err := g.cache.Delete(ipKey + ":count"); err != nil {
+			log.Errorf("清除登录失败记录失败: %v", err)
+		}
+	}
+}
+
+func (g *loginGuard) Failures(username, ip string) int64 {
+	return max(g.count(g.userKey(username)), g.count(g.ipKey(ip)))
+}
+
+func (g *loginGuard) Succeed(username, ip string) {
+	g.clear(g.userKey(username))
+}
+
+func (g *loginGuard) Unlock(userID uuid.UUID) error {
+	user := new(models.User)
+	// 检查用户是否存在
+	if err := g.db.Where("id = ?", userID).First(user).Error; err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := g.db.Model(user).Update("locked_until", nil).Error; err != nil {
+		return err
+	}
+
+	g.clear(g.userKey(user.Username))
+	return nil
+}
+
+// backoff 计算第 failures 次失败后需要等待的时间
+func (g *loginGuard) backoff(failures int64) time.Duration {
+	wait := g.cfg.BackoffBase
+	for i := int64(1); i < failures && wait < g.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, g.cfg.BackoffMax)
+}
+
+func (g *loginGuard) count(key string) int64 {
+	data, ok := g.cache.Get(key + ":count")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func (g *loginGuard) waitUntil(key string) time.Time {
+	data, ok := g.cache.Get(key + ":next")
+	if !ok {
+		return time.Time{}
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
+
+func (g *loginGuard) setWaitUntil(key string, t time.Time) {
+	ttl := max(time.Until(t), time.Second)
+	if err := g.cache.Set(key+":next", strconv.AppendInt(nil, t.UnixMilli(), 10), ttl); err != nil {
+		log.Errorf("记录登录等待时间失败: %v", err)
+	}
+}
+
+func (g *loginGuard) clear(key string) {
+	if err := g.cache.Delete(key+":count", key+":next"); err != nil {
+		log.Errorf("清除登录失败记录失败: %v", err)
+	}
+}
+
+// 数据库默认排序规则不区分大小写，用户名统一转为小写
+func (g *loginGuard) userKey(username string) string {
+	return loginGuardCacheKeyPrefix + "user:" + strings.ToLower(username)
+}
+
+func (g *loginGuard) ipKey(ip string) string {
+	return loginGuardCacheKeyPrefix + "ip:" + ip
+}
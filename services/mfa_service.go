@@ -0,0 +1,429 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/domain"
+	"cms/utils/cache"
+	"cms/utils/totp"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 两步验证挑战缓存前缀
+const mfaChallengeCacheKeyPrefix = "mfa:challenge:"
+
+// 恢复码字符集，去掉容易混淆的字符
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+var (
+	// ErrMFAChallengeInvalid 两步验证已过期
+	ErrMFAChallengeInvalid = errors.New("两步验证已过期，请重新登录")
+	// ErrMFACodeInvalid 验证码错误
+	ErrMFACodeInvalid = errors.New("验证码错误")
+	// ErrMFANotEnrolled 尚未绑定验证器
+	ErrMFANotEnrolled = errors.New("请先绑定验证器")
+	// ErrMFAAlreadyEnabled 已开启两步验证
+	ErrMFAAlreadyEnabled = errors.New("已开启两步验证")
+	// ErrMFANotEnabled 未开启两步验证
+	ErrMFANotEnabled = errors.New("未开启两步验证")
+	// ErrMFARequired 角色要求开启两步验证
+	ErrMFARequired = errors.New("当前角色要求开启两步验证，不能关闭")
+)
+
+type (
+	// MFAService 基于 TOTP 的两步验证。
+	// 密码验证通过后，开启了两步验证或角色要求两步验证的用户需要使用挑战令牌完成验证才能获得访问令牌
+	MFAService interface {
+		GetStatus(userID uuid.UUID) (*domain.MFAStatusResponse, error)
+		// Enroll 生成新的密钥，确认后才生效
+		Enroll(userID uuid.UUID) (*domain.MFAEnrollResponse, error)
+		// Confirm 使用验证码确认绑定，返回恢复码
+		Confirm(userID uuid.UUID, code string) ([]string, error)
+		Disable(userID uuid.UUID, code string) error
+		// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+		RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+		// Reset 管理员为丢失验证器的用户关闭两步验证
+		Reset(userID uuid.UUID) error
+
+		// Challenge 密码验证通过后调用，不需要两步验证时返回 nil
+		Challenge(user *models.User) (*domain.MFAChallengeResponse, error)
+		// EnrollChallenge 角色要求两步验证但尚未绑定时，在登录过程中绑定验证器
+		EnrollChallenge(token string) (*domain.MFAEnrollResponse, error)
+		// VerifyChallenge 校验验证码或恢复码，登录过程中完成绑定时同时返回恢复码
+		VerifyChallenge(token, code, ip string) (*models.User, []string, error)
+	}
+	mfaService struct {
+		db         *gorm.DB
+		cache      cache.Cache
+		loginGuard LoginGuard
+		cfg        *config.MFAConfig
+	}
+)
+
+func NewMFAService(db *gorm.DB, cache cache.Cache, loginGuard LoginGuard, cfg *config.MFAConfig) MFAService {
+	return &mfaService{db: db, cache: cache, loginGuard: loginGuard, cfg: cfg}
+}
+
+func (s *mfaService) GetStatus(userID uuid.UUID) (*domain.MFAStatusResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.required(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	return &domain.MFAStatusResponse{
+		Enabled:       user.MFAEnabled,
+		Required:      required,
+		RecoveryCodes: count,
+	}, nil
+}
+
+func (s *mfaService) Enroll(userID uuid.UUID) (*domain.MFAEnrollResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enroll(user)
+}
+
+func (s *mfaService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.confirm(user, code)
+}
+
+func (s *mfaService) Disable(userID uuid.UUID, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	required, err := s.required(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	ok, err := s.verifyCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMFACodeInvalid
+	}
+
+	return s.Reset(userID)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	ok, err := s.verifyCode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		codes, err = s.generateRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+func (s *mfaService) Reset(userID uuid.UUID) error {
+	if _, err := s.getUser(userID); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"totp_secret":    "",
+			"mfa_enabled":    false,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+func (s *mfaService) Challenge(user *models.User) (*domain.MFAChallengeResponse, error) {
+	required, err := s.required(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled && !required {
+		return nil, nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(mfaChallengeCacheKeyPrefix+hashToken(token), []byte(user.ID.String()), s.cfg.ChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int64(s.cfg.ChallengeTTL.Seconds()),
+		EnrollRequired: !user.MFAEnabled,
+	}, nil
+}
+
+func (s *mfaService) EnrollChallenge(token string) (*domain.MFAEnrollResponse, error) {
+	user, err := s.getChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.enroll(user)
+}
+
+func (s *mfaService) VerifyChallenge(token, code, ip string) (*models.User, []string, error) {
+	user, err := s.getChallenge(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 验证码同样计入登录失败次数，防止暴力猜测
+	if _, err := s.loginGuard.Check(user.Username, ip); err != nil {
+		return nil, nil, err
+	}
+	if user.LockedUntil != nil && time.Time(*user.LockedUntil).After(time.Now()) {
+		return nil, nil, ErrAccountLocked
+	}
+
+	var codes []string
+	if user.MFAEnabled {
+		var ok bool
+		if ok, err = s.verifyCode(user, code); err == nil && !ok {
+			err = ErrMFACodeInvalid
+		}
+	} else {
+		// 登录过程中绑定验证器
+		codes, err = s.confirm(user, code)
+	}
+
+	if errors.Is(err, ErrMFACodeInvalid) {
+		s.loginGuard.Fail(user.Username, ip)
+		s.failChallenge(token)
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.loginGuard.Succeed(user.Username, ip)
+	s.deleteChallenge(token)
+	return user, codes, nil
+}
+
+func (s *mfaService) enroll(user *models.User) (*domain.MFAEnrollResponse, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Update("totp_secret", secret).Error; err != nil {
+		return nil, err
+	}
+
+	return &domain.MFAEnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.Issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *mfaService) confirm(user *models.User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), s.cfg.Skew)
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]any{
+			"mfa_enabled":    true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = s.generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyCode 校验验证器中的验证码或恢复码，两者都只能使用一次
+func (s *mfaService) verifyCode(user *models.User, code string) (bool, error) {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), s.cfg.Skew); ok {
+		// 只接受比上次更新的时间步，并发请求中只有一个能成功
+		result := s.db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", models.CustomTime(time.Now()))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// generateRecoveryCodes 替换用户的全部恢复码
+func (s *mfaService) generateRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, s.cfg.RecoveryCodes)
+	records := make([]*models.RecoveryCode, s.cfg.RecoveryCodes)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = &models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))}
+	}
+
+	if len(records) > 0 {
+		if err := tx.Create(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// required 用户的任一角色要求两步验证
+func (s *mfaService) required(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND roles.require_mfa = ?", userID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *mfaService) getUser(id uuid.UUID) (*models.User, error) {
+	user := new(models.User)
+	if err := s.db.Where("id = ?", id).First(user).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *mfaService) getChallenge(token string) (*models.User, error) {
+	data, ok := s.cache.Get(mfaChallengeCacheKeyPrefix + hashToken(token))
+	if !ok {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	userID, err := uuid.ParseBytes(data)
+	if err != nil {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, ErrMFAChallengeInvalid
+	}
+	return user, nil
+}
+
+// failChallenge 记录一次验证失败，次数用完后挑战失效，需要重新输入密码
+func (s *mfaService) failChallenge(token string) {
+	key := mfaChallengeCacheKeyPrefix + hashToken(token)
+	attempts, err := s.cache.Incr(key+":attempts", s.cfg.ChallengeTTL)
+	if err != nil {
+		log.Errorf("记录两步验证失败次数失败: %v", err)
+		return
+	}
+	if attempts >= int64(s.cfg.MaxAttempts) {
+		s.deleteChallenge(token)
+	}
+}
+
+func (s *mfaService) deleteChallenge(token string) {
+	key := mfaChallengeCacheKeyPrefix + hashToken(token)
+	if err := s.cache.Delete(key, key+":attempts"); err != nil {
+		log.Errorf("删除两步验证挑战失败: %v", err)
+	}
+}
+
+// newRecoveryCode 生成 xxxxx-xxxxx 格式的恢复码
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = recoveryCodeAlphabet[n.Int64()]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/utils/cache"
+	"cms/utils/oidc"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 单点登录缓存前缀
+const oidcCacheKeyPrefix = "oidc:"
+
+// 单点登录创建的用户没有本地密码，该值不是有效的哈希，无法通过密码登录
+const oidcNoPassword = "!oidc"
+
+var (
+	// ErrOIDCDisabled 未启用单点登录
+	ErrOIDCDisabled = errors.New("未启用单点登录")
+	// ErrOIDCRedirectInvalid 跳转地址不在允许的范围内
+	ErrOIDCRedirectInvalid = errors.New("跳转地址不在允许的范围内")
+	// ErrOIDCStateInvalid 登录状态无效或已过期
+	ErrOIDCStateInvalid = errors.New("登录状态无效或已过期，请重新登录")
+	// ErrOIDCCodeInvalid 登录码无效或已过期
+	ErrOIDCCodeInvalid = errors.New("登录码无效或已过期，请重新登录")
+	// ErrOIDCUserNotFound 没有关联的用户且未开启自动创建
+	ErrOIDCUserNotFound = errors.New("该账号未关联 CMS 用户，请联系管理员")
+	// ErrOIDCClaimMissing 身份提供方没有返回用户名
+	ErrOIDCClaimMissing = errors.New("身份提供方没有返回用户名")
+	// ErrOIDCDenied 身份提供方拒绝了登录请求
+	ErrOIDCDenied = errors.New("身份提供方拒绝了登录请求")
+	// ErrOIDCLoginFailed 单点登录失败，详细原因只记录在日志中
+	ErrOIDCLoginFailed = errors.New("单点登录失败，请稍后再试")
+)
+
+type (
+	// OIDCService 通过 OpenID Connect 身份提供方登录。
+	// 回调成功后跳转回前端并附带一次性登录码，前端使用登录码换取访问令牌，令牌不会出现在地址栏中
+	OIDCService interface {
+		// AuthURL 生成跳转到身份提供方的授权地址
+		AuthURL(redirect string) (string, error)
+		// Callback 处理身份提供方回调，返回跳转回前端的地址。
+		// 登录状态无效时返回错误，其他错误通过跳转地址的 error 参数告知前端
+		Callback(state, code, idpError string) (string, error)
+		// Exchange 使用一次性登录码获取用户
+		Exchange(code string) (*models.User, error)
+	}
+	oidcService struct {
+		db          *gorm.DB
+		cache       cache.Cache
+		rbacService RBACService
+		provider    *oidc.Provider
+		cfg         *config.OIDCConfig
+	}
+
+	// oidcState 发起登录时保存的状态
+	oidcState struct {
+		Verifier string `json:"verifier"`
+		Nonce    string `json:"nonce"`
+		Redirect string `json:"redirect"`
+	}
+)
+
+func NewOIDCService(db *gorm.DB, cache cache.Cache, rbacService RBACService, cfg *config.OIDCConfig) OIDCService {
+	s := &oidcService{db: db, cache: cache, rbacService: rbacService, cfg: cfg}
+	if cfg.Enabled {
+		s.provider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.Issuer,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		})
+	}
+	return s
+}
+
+func (s *oidcService) AuthURL(redirect string) (string, error) {
+	if s.provider == nil {
+		return "", ErrOIDCDisabled
+	}
+
+	if redirect == "" && len(s.cfg.AllowedRedirects) > 0 {
+		redirect = s.cfg.AllowedRedirects[0]
+	}
+	if !slices.Contains(s.cfg.AllowedRedirects, redirect) {
+		return "", ErrOIDCRedirectInvalid
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(oidcState{Verifier: verifier, Nonce: nonce, Redirect: redirect})
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(oidcCacheKeyPrefix+"state:"+hashToken(state), data, s.cfg.StateTTL); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+func (s *oidcService) Callback(state, code, idpError string) (string, error) {
+	if s.provider == nil {
+		return "", ErrOIDCDisabled
+	}
+
+	// 登录状态只能使用一次
+	key := oidcCacheKeyPrefix + "state:" + hashToken(state)
+	data, ok := s.cache.Get(key)
+	if !ok {
+		return "", ErrOIDCStateInvalid
+	}
+	if err := s.cache.Delete(key); err != nil {
+		return "", err
+	}
+
+	var st oidcState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return "", ErrOIDCStateInvalid
+	}
+
+	loginCode, err := s.callback(st, code, idpError)
+	if err != nil {
+		log.Warnf("单点登录失败: %v", err)
+		// 只把用户能够处理的错误告知前端
+		if !errors.Is(err, ErrOIDCDenied) && !errors.Is(err, ErrOIDCUserNotFound) &&
+			!errors.Is(err, ErrOIDCClaimMissing) && !errors.Is(err, ErrUsernameExists) {
+			err = ErrOIDCLoginFailed
+		}
+		return appendQuery(st.Redirect, "error", err.Error()), nil
+	}
+	return appendQuery(st.Redirect, "code", loginCode), nil
+}
+
+func (s *oidcService) Exchange(code string) (*models.User, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	key := oidcCacheKeyPrefix + "login:" + hashToken(code)
+	data, ok := s.cache.Get(key)
+	if !ok {
+		return nil, ErrOIDCCodeInvalid
+	}
+	if err := s.cache.Delete(key); err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.ParseBytes(data)
+	if err != nil {
+		return nil, ErrOIDCCodeInvalid
+	}
+
+	user := new(models.User)
+	if err := s.db.Where("id = ?", userID).First(user).Error; err != nil {
+		return nil, ErrOIDCCodeInvalid
+	}
+	if user.LockedUntil != nil && time.Time(*user.LockedUntil).After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+	return user, nil
+}
+
+// callback 换取并校验令牌，找到或创建用户后生成一次性登录码
+func (s *oidcService) callback(st oidcState, code, idpError string) (string, error) {
+	if idpError != "" {
+		return "", fmt.Errorf("%w: %s", ErrOIDCDenied, idpError)
+	}
+	if code == "" {
+		return "", ErrOIDCCodeInvalid
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	token, err := s.provider.Exchange(ctx, code, st.Verifier)
+	if err != nil {
+		return "", err
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, token.IDToken, st.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	// 部分身份提供方只在用户信息端点返回用户组等声明
+	info, err := s.provider.UserInfo(ctx, token.AccessToken)
+	if err != nil {
+		return "", err
+	}
+	if info != nil {
+		if info.String("sub") != claims.String("sub") {
+			return "", fmt.Errorf("%w: 用户信息 sub 不匹配", oidc.ErrUserInfo)
+		}
+		for name, value := range info {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+
+	user, err := s.resolveUser(claims)
+	if err != nil {
+		return "", err
+	}
+
+	loginCode, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(oidcCacheKeyPrefix+"login:"+hashToken(loginCode), []byte(user.ID.String()), s.cfg.CodeTTL); err != nil {
+		return "", err
+	}
+	return loginCode, nil
+}
+
+// resolveUser 按签发方和 sub 查找用户，首次登录时关联或创建用户，并按用户组设置角色
+func (s *oidcService) resolveUser(claims oidc.Claims) (*models.User, error) {
+	issuer, subject := claims.String("iss"), claims.String("sub")
+	email := claims.String(s.cfg.EmailClaim)
+	now := models.CustomTime(time.Now())
+
+	user, created, err := s.findUser(issuer, subject, email, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &models.UserIdentity{UserID: user.ID, Issuer: issuer, Subject: subject}
+	if err := s.db.Where("issuer = ? AND subject = ?", issuer, subject).FirstOrCreate(identity).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(identity).Updates(map[string]any{"email": email, "last_login_at": &now}).Error; err != nil {
+		return nil, err
+	}
+
+	if created || s.cfg.SyncRoles {
+		if err := s.syncRoles(user, claims.Strings(s.cfg.GroupsClaim), created); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// findUser 返回用户以及是否为新创建的用户
+func (s *oidcService) findUser(issuer, subject, email string, claims oidc.Claims) (*models.User, bool, error) {
+	identity := new(models.UserIdentity)
+	err := s.db.Where("issuer = ? AND subject = ?", issuer, subject).First(identity).Error
+	if err == nil {
+		user := new(models.User)
+		if err := s.db.Where("id = ?", identity.UserID).First(user).Error; err == nil {
+			return user, false, nil
+		}
+		// 关联的用户已被删除
+		if err := s.db.Delete(identity).Error; err != nil {
+			return nil, false, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	// 本地邮箱没有经过验证，默认不按邮箱关联；超级管理员只能通过已关联的身份登录
+	if s.cfg.LinkByEmail && email != "" && claims.Bool("email_verified") {
+		user := new(models.User)
+		if err := s.db.Where("email = ? AND is_super = ?", email, false).First(user).Error; err == nil {
+			return user, false, nil
+		}
+	}
+
+	if !s.cfg.AutoCreate {
+		return nil, false, ErrOIDCUserNotFound
+	}
+
+	user, err := s.createUser(issuer, subject, email, claims)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// createUser 根据声明创建用户，手机号和邮箱被占用时不使用
+func (s *oidcService) createUser(issuer, subject, email string, claims oidc.Claims) (*models.User, error) {
+	username := claims.String(s.cfg.UsernameClaim)
+	if username == "" {
+		return nil, ErrOIDCClaimMissing
+	}
+	// 已删除的用户仍然占用用户名
+	if err := s.db.Unscoped().Where("username = ?", username).First(&models.User{}).Error; err == nil {
+		return nil, ErrUsernameExists
+	}
+
+	nickname := claims.String(s.cfg.NicknameClaim)
+	if nickname == "" {
+		nickname = username
+	}
+
+	// 手机号不能为空且唯一，没有时使用由签发方和 sub 生成的占位值
+	phone := claims.String(s.cfg.PhoneClaim)
+	if phone == "" || s.db.Unscoped().Where("phone = ?", phone).First(&models.User{}).Error == nil {
+		sum := sha256.Sum256([]byte(issuer + "\x00" + subject))
+		phone = "oidc:" + hex.EncodeToString(sum[:8])
+	}
+
+	user := &models.User{
+		Nickname: nickname,
+		Phone:    phone,
+		Username: username,
+		Password: oidcNoPassword,
+	}
+	if email != "" && claims.Bool("email_verified") &&
+		s.db.Unscoped().Where("email = ?", email).First(&models.User{}).Error != nil {
+		user.Email = &email
+	}
+
+	if err := s.db.Create(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncRoles 按用户组设置角色，没有匹配的用户组时使用默认角色。
+// 未配置角色映射时只在创建用户时分配默认角色，不修改已有用户的角色
+func (s *oidcService) syncRoles(user *models.User, groups []string, created bool) error {
+	if user.IsSuper || (len(s.cfg.RoleMapping) == 0 && !created) {
+		return nil
+	}
+
+	var names []string
+	for _, group := range groups {
+		for _, name := range s.cfg.RoleMapping[group] {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = s.cfg.DefaultRoles
+	}
+
+	roles := make([]*models.Role, 0, len(names))
+	if len(names) > 0 {
+		if err := s.db.Where("name IN ?", names).Find(&roles).Error; err != nil {
+			return err
+		}
+	}
+	if len(roles) != len(names) {
+		log.Warnf("单点登录角色映射中有不存在的角色: %s", strings.Join(names, ","))
+	}
+
+	if err := s.db.Model(user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+	s.rbacService.InvalidateUser(user.ID)
+	return nil
+}
+
+// appendQuery 在地址后追加查询参数
+func appendQuery(rawURL, name, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
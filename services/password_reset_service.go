@@ -0,0 +1,233 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/domain"
+	"cms/utils/cache"
+	"cms/utils/notify"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 找回密码缓存前缀
+const passwordResetCacheKeyPrefix = "reset:"
+
+var (
+	// ErrResetTooFrequent 获取验证码过于频繁
+	ErrResetTooFrequent = errors.New("获取验证码过于频繁，请稍后再试")
+	// ErrResetCodeInvalid 验证码错误或已过期
+	ErrResetCodeInvalid = errors.New("验证码错误或已过期")
+	// ErrResetTokenInvalid 重置令牌无效
+	ErrResetTokenInvalid = errors.New("重置密码已超时，请重新获取验证码")
+)
+
+type (
+	// PasswordResetService 通过一次性验证码找回密码。
+	// 账号不存在时与正常情况返回相同的结果，避免被用来探测账号
+	PasswordResetService interface {
+		// Request 根据用户名或手机号发送验证码
+		Request(account, ip string) error
+		// Verify 校验验证码，通过后返回用于设置新密码的重置令牌
+		Verify(account, code string) (*domain.PasswordResetTokenResponse, error)
+		// Reset 设置新密码，吊销全部会话并解除账号锁定
+		Reset(params domain.ResetPasswordParams) error
+	}
+	passwordResetService struct {
+		db           *gorm.DB
+		cache        cache.Cache
+		notifier     notify.Notifier
+		userService  UserService
+		tokenService TokenService
+		loginGuard   LoginGuard
+		cfg          *config.PasswordResetConfig
+	}
+)
+
+func NewPasswordResetService(db *gorm.DB, cache cache.Cache, notifier notify.Notifier, userService UserService, tokenService TokenService, loginGuard LoginGuard, cfg *config.PasswordResetConfig) PasswordResetService {
+	return &passwordResetService{
+		db:           db,
+		cache:        cache,
+		notifier:     notifier,
+		userService:  userService,
+		tokenService: tokenService,
+		loginGuard:   loginGuard,
+		cfg:          cfg,
+	}
+}
+
+func (s *passwordResetService) Request(account, ip string) error {
+	requests, err := s.cache.Incr(passwordResetCacheKeyPrefix+"ip:"+ip, s.cfg.IPWindow)
+	if err != nil {
+		return err
+	}
+	if s.cfg.IPMaxRequests > 0 && requests > int64(s.cfg.IPMaxRequests) {
+		return ErrResetTooFrequent
+	}
+
+	// 按账号限制发送间隔。同一用户的用户名和手机号共用一个计数；
+	// 账号不存在时按输入限制，与存在时的表现一致，避免被用来探测账号
+	user, err := s.findUser(account)
+	sentKey := passwordResetCacheKeyPrefix + "sent:"
+	if err != nil {
+		sentKey += "unknown:" + hashToken(strings.ToLower(account))
+	} else {
+		sentKey += user.ID.String()
+	}
+	if _, ok := s.cache.Get(sentKey); ok {
+		return ErrResetTooFrequent
+	}
+	if err := s.cache.Set(sentKey, []byte{1}, s.cfg.ResendInterval); err != nil {
+		return err
+	}
+	if user == nil {
+		log.Infof("找回密码的账号不存在: %s", account)
+		return nil
+	}
+
+	code, err := randomDigits(s.cfg.CodeLength)
+	if err != nil {
+		return err
+	}
+
+	key := s.codeKey(user.ID)
+	if err := s.cache.Set(key, []byte(hashToken(code)), s.cfg.CodeTTL); err != nil {
+		return err
+	}
+	if err := s.cache.Delete(key + ":attempts"); err != nil {
+		return err
+	}
+
+	to := notify.Recipient{Name: user.Nickname, Phone: user.Phone}
+	if user.Email != nil {
+		to.Email = *user.Email
+	}
+	msg := notify.Message{
+		Subject: "找回密码验证码",
+		Body:    fmt.Sprintf("您正在找回账号 %s 的密码，验证码为 %s，%d 分钟内有效。如非本人操作，请忽略。", user.Username, code, int(s.cfg.CodeTTL.Minutes())),
+	}
+	// 异步发送，响应时间和发送结果都不会暴露账号是否存在
+	go func() {
+		if err := s.notifier.Send(to, msg); err != nil {
+			log.Errorf("发送找回密码验证码失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *passwordResetService) Verify(account, code string) (*domain.PasswordResetTokenResponse, error) {
+	user, err := s.findUser(account)
+	if err != nil {
+		return nil, ErrResetCodeInvalid
+	}
+
+	key := s.codeKey(user.ID)
+	expected, ok := s.cache.Get(key)
+	if !ok {
+		return nil, ErrResetCodeInvalid
+	}
+
+	// 错误次数与验证码一起保存在安全存储中，不会因缓存淘汰或清空而重置
+	attempts, err := s.cache.Incr(key+":attempts", s.cfg.CodeTTL)
+	if err != nil {
+		return nil, err
+	}
+	// 超过次数后验证码失效
+	if attempts > int64(s.cfg.MaxAttempts) {
+		s.deleteCode(key)
+		return nil, ErrResetCodeInvalid
+	}
+
+	if subtle.ConstantTimeCompare(expected, []byte(hashToken(strings.TrimSpace(code)))) != 1 {
+		return nil, ErrResetCodeInvalid
+	}
+	// 验证码只能使用一次
+	s.deleteCode(key)
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(s.tokenKey(token), []byte(user.ID.String()), s.cfg.TokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.PasswordResetTokenResponse{
+		ResetToken: token,
+		ExpiresIn:  int64(s.cfg.TokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *passwordResetService) Reset(params domain.ResetPasswordParams) error {
+	key := s.tokenKey(params.ResetToken)
+	data, ok := s.cache.Get(key)
+	if !ok {
+		return ErrResetTokenInvalid
+	}
+	userID, err := uuid.ParseBytes(data)
+	if err != nil {
+		return ErrResetTokenInvalid
+	}
+
+	// 密码不符合策略时令牌仍然有效，可以重新填写
+	if err := s.userService.ResetPassword(userID, params.NewPassword); err != nil {
+		return err
+	}
+
+	if err := s.cache.Delete(key); err != nil {
+		log.Errorf("删除重置令牌失败: %v", err)
+	}
+
+	if err := s.tokenService.RevokeUser(userID); err != nil {
+		return err
+	}
+	return s.loginGuard.Unlock(userID)
+}
+
+// findUser 根据用户名或手机号查找用户
+func (s *passwordResetService) findUser(account string) (*models.User, error) {
+	user := new(models.User)
+	// 用户名优先，避免与其他用户的手机号相同时找错用户
+	if err := s.db.Where("username = ?", account).First(user).Error; err == nil {
+		return user, nil
+	}
+	if err := s.db.Where("phone = ?", account).First(user).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *passwordResetService) deleteCode(key string) {
+	if err := s.cache.Delete(key, key+":attempts"); err != nil {
+		log.Errorf("删除找回密码验证码失败: %v", err)
+	}
+}
+
+func (s *passwordResetService) codeKey(userID uuid.UUID) string {
+	return passwordResetCacheKeyPrefix + "code:" + userID.String()
+}
+
+func (s *passwordResetService) tokenKey(token string) string {
+	return passwordResetCacheKeyPrefix + "token:" + hashToken(token)
+}
+
+// randomDigits 生成指定位数的数字验证码
+func randomDigits(length int) (string, error) {
+	var b strings.Builder
+	for range length {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + n.Int64()))
+	}
+	return b.String(), nil
+}
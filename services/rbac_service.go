@@ -0,0 +1,421 @@
+package services
+
+import (
+	"cms/models"
+	"cms/models/domain"
+	"cms/utils/cache"
+	"errors"
+	"slices"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 用户权限缓存前缀
+const rbacUserCacheKeyPrefix = "rbac:user:"
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("角色不存在")
+	// ErrRoleAlreadyExists 角色已存在
+	ErrRoleAlreadyExists = errors.New("角色已存在")
+	// ErrPermissionNotFound 权限不存在
+	ErrPermissionNotFound = errors.New("权限不存在")
+	// ErrUserProtected 目标用户是超级管理员，或目标用户、角色拥有当前用户没有的权限
+	ErrUserProtected = errors.New("没有权限管理该用户")
+)
+
+type (
+	RBACService interface {
+		// GetPermissions 获取全部权限
+		GetPermissions() ([]*models.Permission, error)
+		GetRoles() ([]*models.Role, error)
+		// CreateRole 创建角色，permissions 为当前用户的权限，角色中不能包含当前用户没有的权限
+		CreateRole(permissions []string, params domain.CreateRoleParams) error
+		// UpdateRole 修改角色，修改前后角色中都不能包含当前用户没有的权限
+		UpdateRole(permissions []string, id uuid.UUID, params domain.UpdateRoleParams) error
+		// DeleteRole 删除角色，角色中不能包含当前用户没有的权限
+		DeleteRole(permissions []string, id uuid.UUID) error
+		// SetRoleMFA 设置拥有该角色的用户是否必须开启两步验证，下次登录时生效
+		SetRoleMFA(id uuid.UUID, params domain.SetRoleMFAParams) error
+		// SetUserRoles 设置用户的角色
+		SetUserRoles(userID uuid.UUID, params domain.SetUserRolesParams) error
+		// GetUserPermissions 获取用户拥有的权限编码，超级管理员返回 *，结果按用户缓存
+		GetUserPermissions(userID uuid.UUID) ([]string, error)
+		// HasPermission 检查用户是否拥有权限
+		HasPermission(userID uuid.UUID, permission string) (bool, error)
+		// CanManageUser 检查拥有 permissions 的用户能否修改、删除目标用户：
+		// 超级管理员和拥有 permissions 之外权限的用户只有拥有全部权限（*）时才能管理
+		CanManageUser(permissions []string, userID uuid.UUID) error
+		// CanAssignRoles 检查拥有 permissions 的用户能否分配这些角色，角色中不能包含 permissions 之外的权限
+		CanAssignRoles(permissions []string, roleIDs []uuid.UUID) error
+		// InvalidateUser 用户被修改或删除后清除其权限缓存
+		InvalidateUser(userID uuid.UUID)
+		// Seed 写入内置权限，首次启动时创建默认角色并分配给已有用户
+		Seed() error
+	}
+	rbacService struct {
+		db    *gorm.DB
+		cache cache.Cache
+	}
+)
+
+func NewRBACService(db *gorm.DB, cache cache.Cache) RBACService {
+	return &rbacService{db: db, cache: cache}
+}
+
+func (s *rbacService) GetPermissions() ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	if err := s.db.Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (s *rbacService) GetRoles() ([]*models.Role, error) {
+	var roles []*models.Role
+	if err := s.db.Preload("Permissions").Order("created_at ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *rbacService) CreateRole(permissions []string, params domain.CreateRoleParams) error {
+	if err := canGrant(permissions, params.Permissions); err != nil {
+		return err
+	}
+
+	// 检查角色名是否已存在
+	if err := s.db.Where("name = ?", params.Name).First(&models.Role{}).Error; err == nil {
+		return ErrRoleAlreadyExists
+	}
+
+	rolePermissions, err := s.findPermissions(params.Permissions)
+	if err != nil {
+		return err
+	}
+
+	role := &models.Role{
+		Name:        params.Name,
+		Permissions: rolePermissions,
+	}
+	if params.Description != nil {
+		role.Description = *params.Description
+	}
+
+	if err := s.db.Create(role).Error; err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func (s *rbacService) UpdateRole(permissions []string, id uuid.UUID, params domain.UpdateRoleParams) error {
+	role := new(models.Role)
+	// 检查角色是否存在
+	if err := s.db.Preload("Permissions").Where("id = ?", id).First(role).Error; err != nil {
+		return ErrRoleNotFound
+	}
+
+	// 不能修改比自己权限多的角色，也不能给角色添加自己没有的权限
+	if err := canGrant(permissions, roleCodes(role)); err != nil {
+		return err
+	}
+	if err := canGrant(permissions, params.Permissions); err != nil {
+		return err
+	}
+
+	if params.Name != nil && role.Name != *params.Name {
+		// 检查角色名是否已存在
+		if err := s.db.Where("name = ?", *params.Name).First(&models.Role{}).Error; err == nil {
+			return ErrRoleAlreadyExists
+		}
+		role.Name = *params.Name
+	}
+
+	if params.Description != nil {
+		role.Description = *params.Description
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+
+		if params.Permissions != nil {
+			rolePermissions, err := s.findPermissions(params.Permissions)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(role).Association("Permissions").Replace(rolePermissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func (s *rbacService) DeleteRole(permissions []string, id uuid.UUID) error {
+	role := new(models.Role)
+	// 检查角色是否存在
+	if err := s.db.Preload("Permissions").Where("id = ?", id).First(role).Error; err != nil {
+		return ErrRoleNotFound
+	}
+
+	if err := canGrant(permissions, roleCodes(role)); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(role).Association("Users").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func (s *rbacService) SetRoleMFA(id uuid.UUID, params domain.SetRoleMFAParams) error {
+	result := s.db.Model(&models.Role{}).Where("id = ?", id).Update("require_mfa", params.RequireMFA)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 值未变化时也不会更新任何行
+		if err := s.db.Where("id = ?", id).First(&models.Role{}).Error; err != nil {
+			return ErrRoleNotFound
+		}
+	}
+	return nil
+}
+
+func (s *rbacService) SetUserRoles(userID uuid.UUID, params domain.SetUserRolesParams) error {
+	user := new(models.User)
+	// 检查用户是否存在
+	if err := s.db.Where("id = ?", userID).First(user).Error; err != nil {
+		return ErrUserNotFound
+	}
+
+	roles := make([]*models.Role, 0, len(params.RoleIDs))
+	if len(params.RoleIDs) > 0 {
+		if err := s.db.Where("id IN ?", params.RoleIDs).Find(&roles).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(params.RoleIDs) {
+			return ErrRoleNotFound
+		}
+	}
+
+	if err := s.db.Model(user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+
+	s.InvalidateUser(userID)
+	return nil
+}
+
+func (s *rbacService) GetUserPermissions(userID uuid.UUID) ([]string, error) {
+	return cache.Remember(s.cache, rbacUserCacheKeyPrefix+userID.String(), 0, func() ([]string, error) {
+		user := new(models.User)
+		if err := s.db.Preload("Roles.Permissions").Where("id = ?", userID).First(user).Error; err != nil {
+			return nil, ErrUserNotFound
+		}
+
+		if user.IsSuper {
+			return []string{models.PermAll}, nil
+		}
+
+		permissions := make([]string, 0)
+		for _, role := range user.Roles {
+			for _, permission := range role.Permissions {
+				if !slices.Contains(permissions, permission.Code) {
+					permissions = append(permissions, permission.Code)
+				}
+			}
+		}
+		return permissions, nil
+	})
+}
+
+func (s *rbacService) HasPermission(userID uuid.UUID, permission string) (bool, error) {
+	permissions, err := s.GetUserPermissions(userID)
+	if err != nil {
+		return false, err
+	}
+	return HasPermission(permissions, permission), nil
+}
+
+func (s *rbacService) CanManageUser(permissions []string, userID uuid.UUID) error {
+	if slices.Contains(permissions, models.PermAll) {
+		return nil
+	}
+
+	// 超级管理员的权限为 *，同样会被拒绝
+	target, err := s.GetUserPermissions(userID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range target {
+		if !HasPermission(permissions, permission) {
+			return ErrUserProtected
+		}
+	}
+	return nil
+}
+
+func (s *rbacService) CanAssignRoles(permissions []string, roleIDs []uuid.UUID) error {
+	if slices.Contains(permissions, models.PermAll) || len(roleIDs) == 0 {
+		return nil
+	}
+
+	var roles []*models.Role
+	if err := s.db.Preload("Permissions").Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := canGrant(permissions, roleCodes(role)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *rbacService) InvalidateUser(userID uuid.UUID) {
+	if err := s.cache.Delete(rbacUserCacheKeyPrefix + userID.String()); err != nil {
+		log.Errorf("清除用户权限缓存失败: %v", err)
+	}
+}
+
+func (s *rbacService) Seed() error {
+	if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(models.Permissions).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Role{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// 升级前非超级管理员可以管理文章、图片和标签，默认分配作者角色，只能修改自己的文章；
+	// 作者没有发布权限，文章需要由审核或编辑发布
+	author := &models.Role{
+		Name:        "作者",
+		Description: "编辑自己的文章，管理图片和标签",
+		Permissions: permissionsOf(models.PermArticleWrite, models.PermArticleDelete,
+			models.PermImageUpload, models.PermImageDelete, models.PermTagWrite),
+	}
+	editor := &models.Role{
+		Name:        "编辑",
+		Description: "编辑所有文章，管理图片和标签",
+		Permissions: permissionsOf(models.PermArticleWrite, models.PermArticleDelete, models.PermArticlePublish, models.PermArticleManage,
+			models.PermImageUpload, models.PermImageDelete, models.PermTagWrite),
+	}
+	reviewer := &models.Role{
+		Name:        "审核",
+		Description: "审核并发布文章",
+		Permissions: permissionsOf(models.PermArticleReview, models.PermArticlePublish),
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(author).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(editor).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(reviewer).Error; err != nil {
+			return err
+		}
+
+		var users []*models.User
+		if err := tx.Where("is_super = ?", false).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := tx.Model(user).Association("Roles").Append(author); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findPermissions 根据编码查找权限
+func (s *rbacService) findPermissions(codes []string) ([]*models.Permission, error) {
+	permissions := make([]*models.Permission, 0, len(codes))
+	if len(codes) == 0 {
+		return permissions, nil
+	}
+
+	if err := s.db.Where("code IN ?", codes).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(slices.Compact(slices.Sorted(slices.Values(codes)))) {
+		return nil, ErrPermissionNotFound
+	}
+	return permissions, nil
+}
+
+// invalidate 角色变化后清除所有用户的权限缓存
+func (s *rbacService) invalidate() {
+	if err := s.cache.DeletePrefix(rbacUserCacheKeyPrefix); err != nil {
+		log.Errorf("清除用户权限缓存失败: %v", err)
+	}
+}
+
+// HasPermission 检查权限集合中是否包含指定权限
+func HasPermission(permissions []string, permission string) bool {
+	return slices.Contains(permissions, models.PermAll) || slices.Contains(permissions, permission)
+}
+
+// canGrant 检查拥有 permissions 的用户能否授予 codes 中的权限，只能授予自己拥有的权限
+func canGrant(permissions []string, codes []string) error {
+	for _, code := range codes {
+		if !HasPermission(permissions, code) {
+			return ErrUserProtected
+		}
+	}
+	return nil
+}
+
+// roleCodes 角色的权限编码，角色需要预加载 Permissions
+func roleCodes(role *models.Role) []string {
+	codes := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		codes = append(codes, permission.Code)
+	}
+	return codes
+}
+
+// permissionsOf 根据编码构造权限，用于关联已存在的权限
+func permissionsOf(codes ...string) []*models.Permission {
+	permissions := make([]*models.Permission, 0, len(codes))
+	for _, code := range codes {
+		for _, permission := range models.Permissions {
+			if permission.Code == code {
+				permissions = append(permissions, &models.Permission{Code: permission.Code, Name: permission.Name})
+			}
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/domain"
+	"cms/models/scopes"
+	"cms/utils/cache"
+	"cms/utils/search"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 搜索结果正文片段长度
+const searchSnippetLength = 120
+
+type (
+	SearchService interface {
+		// SearchArticles 搜索公开文章
+		SearchArticles(params domain.SearchArticlesParams) (*domain.LimitResponse[*domain.ArticleSearchHit], error)
+		// AdminSearchArticles 搜索所有文章
+		AdminSearchArticles(params domain.AdminSearchArticlesParams) (*domain.LimitResponse[*domain.ArticleSearchHit], error)
+		// IndexArticles 根据文章当前状态更新索引，文章不存在时从索引中删除
+		IndexArticles(ids ...uuid.UUID) error
+		// Rebuild 重建全部索引
+		Rebuild() error
+	}
+	searchService struct {
+		db        *gorm.DB
+		cache     cache.Cache
+		engine    search.Engine
+		tokenizer *search.Tokenizer
+	}
+)
+
+func NewSearchService(db *gorm.DB, cache cache.Cache, articleScope scopes.ArticleScope, cfg *config.SearchConfig) (SearchService, error) {
+	tokenizer, err := search.NewTokenizer(cfg.DictPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var engine search.Engine
+	switch cfg.Driver {
+	case "", search.DriverMemory:
+		engine = search.NewMemoryEngine(tokenizer)
+	case search.DriverMySQL:
+		if engine, err = search.NewMySQLEngine(db, articleScope.Visible); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, search.ErrUnknownDriver
+	}
+
+	return &searchService{
+		db:        db,
+		cache:     cache,
+		engine:    engine,
+		tokenizer: tokenizer,
+	}, nil
+}
+
+func (s *searchService) SearchArticles(params domain.SearchArticlesParams) (*domain.LimitResponse[*domain.ArticleSearchHit], error) {
+	key := fmt.Sprintf("%ssearch:%s:%v:%v:%d:%d", articleListCacheKeyPrefix, params.Q, params.CategoryID, params.TagID, params.Page, params.PageSize)
+	return cache.Remember(s.cache, key, 0, func() (*domain.LimitResponse[*domain.ArticleSearchHit], error) {
+		now := time.Now()
+		return s.search(&search.Query{
+			Text:       params.Q,
+			CategoryID: params.CategoryID,
+			TagID:      params.TagID,
+			VisibleAt:  &now,
+			Offset:     (params.Page - 1) * params.PageSize,
+			Limit:      params.PageSize,
+		}, params.PageSize)
+	})
+}
+
+func (s *searchService) AdminSearchArticles(params domain.AdminSearchArticlesParams) (*domain.LimitResponse[*domain.ArticleSearchHit], error) {
+	query := &search.Query{
+		Text:       params.Q,
+		CategoryID: params.CategoryID,
+		TagID:      params.TagID,
+		Offset:     (params.Page - 1) * params.PageSize,
+		Limit:      params.PageSize,
+	}
+
+	if params.Status != nil {
+		status := uint8(*params.Status)
+		query.Status = &status
+	}
+
+	return s.search(query, params.PageSize)
+}
+
+func (s *searchService) IndexArticles(ids ...uuid.UUID) error {
+	var errs []error
+	for _, id := range ids {
+		article := new(models.Article)
+		if err := s.db.Preload("Tags").Where("id = ?", id).First(article).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				errs = append(errs, s.engine.Delete(id))
+			} else {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		errs = append(errs, s.engine.Index(newSearchDocument(article)))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *searchService) Rebuild() error {
+	if err := s.engine.Reset(); err != nil {
+		return err
+	}
+
+	var articles []*models.Article
+	return s.db.Preload("Tags").FindInBatches(&articles, 200, func(tx *gorm.DB, batch int) error {
+		for _, article := range articles {
+			if err := s.engine.Index(newSearchDocument(article)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// search 执行检索，并加载文章生成高亮片段
+func (s *searchService) search(query *search.Query, pageSize int) (*domain.LimitResponse[*domain.ArticleSearchHit], error) {
+	result, err := s.engine.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+
+	var articles []*models.Article
+	if len(ids) > 0 {
+		if err := s.db.Preload("Tags").Preload("Images").Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	articleMap := make(map[uuid.UUID]*models.Article, len(articles))
+	for _, article := range articles {
+		articleMap[article.ID] = article
+	}
+
+	terms := s.tokenizer.Terms(query.Text)
+	rows := make([]*domain.ArticleSearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		article, ok := articleMap[hit.ID]
+		if !ok {
+			continue
+		}
+
+		content := search.StripHTML(article.Content)
+		if content == "" {
+			content = article.Description
+		}
+
+		rows = append(rows, &domain.ArticleSearchHit{
+			Article: article,
+			Score:   hit.Score,
+			Title:   search.Highlight(article.Title, terms),
+			Snippet: search.Snippet(content, terms, searchSnippetLength),
+		})
+	}
+
+	// 计算总页数
+	totalPages := int(math.Ceil(float64(result.Total) / float64(pageSize)))
+
+	return &domain.LimitResponse[*domain.ArticleSearchHit]{
+		Total: result.Total,
+		Rows:  rows,
+		Pages: totalPages,
+	}, nil
+}
+
+// newSearchDocument 将文章转换为索引文档
+func newSearchDocument(article *models.Article) *search.Document {
+	doc := &search.Document{
+		ID:          article.ID,
+		Title:       article.Title,
+		Description: article.Description,
+		Content:     article.Content,
+		CategoryID:  article.CategoryID,
+		Status:      uint8(article.Status),
+		Public:      article.Status == models.StatusPublished || article.Status == models.StatusScheduled,
+		CreatedAt:   time.Time(article.CreatedAt),
+	}
+
+	for _, tag := range article.Tags {
+		doc.Tags = append(doc.Tags, tag.Name)
+		doc.TagIDs = append(doc.TagIDs, tag.ID)
+	}
+
+	if article.PublishAt != nil {
+		t := time.Time(*article.PublishAt)
+		doc.PublishAt = &t
+	}
+	if article.UnpublishAt != nil {
+		t := time.Time(*article.UnpublishAt)
+		doc.UnpublishAt = &t
+	}
+
+	return doc
+}
+
+// onArticlesChanged 文章变更后清除缓存并更新搜索索引
+func onArticlesChanged(c cache.Cache, searchService SearchService, ids ...uuid.UUID) {
+	invalidateArticleCache(c, ids...)
+
+	if err := searchService.IndexArticles(ids...); err != nil {
+		log.Errorf("更新文章索引失败: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/scopes"
+	"cms/utils/cache"
+	"cms/utils/sitemap"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSitemapNotFound sitemap 分页不存在
+	ErrSitemapNotFound = errors.New("sitemap 不存在")
+)
+
+type (
+	SitemapService interface {
+		// GetSitemap 获取 sitemap。page 为 0 时表示 /sitemap.xml：URL 数量未超过上限时直接返回 urlset，
+		// 否则返回指向 /sitemap-{page}.xml 的索引
+		GetSitemap(page int) ([]byte, error)
+	}
+	sitemapService struct {
+		db           *gorm.DB
+		cache        cache.Cache
+		articleScope scopes.ArticleScope
+		site         *config.SiteConfig
+		cfg          *config.SitemapConfig
+	}
+)
+
+func NewSitemapService(db *gorm.DB, cache cache.Cache, articleScope scopes.ArticleScope, site *config.SiteConfig, cfg *config.SitemapConfig) SitemapService {
+	return &sitemapService{
+		db:           db,
+		cache:        cache,
+		articleScope: articleScope,
+		site:         site,
+		cfg:          cfg,
+	}
+}
+
+func (s *sitemapService) GetSitemap(page int) ([]byte, error) {
+	if data, ok := s.cache.Get(sitemapCacheKey(page)); ok {
+		return data, nil
+	}
+	// 文件数量仍在缓存中时，不存在的分页直接返回，不重新生成
+	if data, ok := s.cache.Get(articleListCacheKeyPrefix + "sitemap:count"); ok {
+		if count, err := strconv.Atoi(string(data)); err == nil && (page < 0 || page >= count) {
+			return nil, ErrSitemapNotFound
+		}
+	}
+
+	now := time.Now()
+	files, err := s.render(now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 一次生成全部文件，按分页分别缓存原始内容
+	ttl := scheduleTTL(s.db, s.cache, now)
+	for i, data := range files {
+		s.cache.Set(sitemapCacheKey(i), data, ttl)
+	}
+	s.cache.Set(articleListCacheKeyPrefix+"sitemap:count", []byte(strconv.Itoa(len(files))), ttl)
+
+	if page < 0 || page >= len(files) {
+		return nil, ErrSitemapNotFound
+	}
+	return files[page], nil
+}
+
+func sitemapCacheKey(page int) string {
+	return fmt.Sprintf("%ssitemap:%d", articleListCacheKeyPrefix, page)
+}
+
+// render 生成全部 sitemap 文件，下标 0 为 /sitemap.xml，URL 数量超过上限时为索引，
+// 下标 1 起依次为 /sitemap-{page}.xml
+func (s *sitemapService) render(now time.Time) ([][]byte, error) {
+	urls, err := s.urls(now)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := (len(urls) + s.cfg.MaxURLs - 1) / s.cfg.MaxURLs
+
+	// 未拆分时只提供 /sitemap.xml
+	if pages <= 1 {
+		data, err := sitemap.URLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{data}, nil
+	}
+
+	files := make([][]byte, 1, pages+1)
+	entries := make([]sitemap.IndexEntry, 0, pages)
+	for i := range pages {
+		chunk := urls[i*s.cfg.MaxURLs : min((i+1)*s.cfg.MaxURLs, len(urls))]
+		data, err := sitemap.URLSet(chunk)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, data)
+		entries = append(entries, sitemap.IndexEntry{
+			Loc:     s.site.AbsURL(fmt.Sprintf("sitemap-%d.xml", i+1)),
+			LastMod: lastModified(chunk),
+		})
+	}
+
+	index, err := sitemap.Index(entries)
+	if err != nil {
+		return nil, err
+	}
+	files[0] = index
+	return files, nil
+}
+
+// urls 收集首页、分类页以及所有公开文章的地址
+func (s *sitemapService) urls(now time.Time) ([]sitemap.URL, error) {
+	urls := []sitemap.URL{{
+		Loc:        s.site.URL + "/",
+		ChangeFreq: s.cfg.HomeChangeFreq,
+		Priority:   s.cfg.HomePriority,
+	}}
+
+	var categories []*models.Category
+	if err := s.db.Order("sort ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		urls = append(urls, sitemap.URL{
+			Loc:        s.site.CategoryURL(category.Alias),
+			LastMod:    time.Time(category.UpdatedAt),
+			ChangeFreq: s.cfg.CategoryChangeFreq,
+			Priority:   s.cfg.CategoryPriority,
+		})
+	}
+
+	var articles []*models.Article
+	if err := s.db.Model(&models.Article{}).
+		Select("id", "slug", "updated_at").
+		Scopes(s.articleScope.Visible(now)).
+		Order("created_at DESC").
+		FindInBatches(&articles, 1000, func(tx *gorm.DB, batch int) error {
+			for _, article := range articles {
+				urls = append(urls, sitemap.URL{
+					Loc:        s.site.ArticleURL(article.Slug),
+					LastMod:    time.Time(article.UpdatedAt),
+					ChangeFreq: s.cfg.ArticleChangeFreq,
+					Priority:   s.cfg.ArticlePriority,
+				})
+			}
+			return nil
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// lastModified 返回一组 URL 中最新的修改时间
+func lastModified(urls []sitemap.URL) time.Time {
+	var last time.Time
+	for _, u := range urls {
+		if u.LastMod.After(last) {
+			last = u.LastMod
+		}
+	}
+	return last
+}
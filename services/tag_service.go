@@ -3,6 +3,7 @@ package services
 import (
 	"cms/models"
 	"cms/models/domain"
+	"cms/utils/cache"
 	"errors"
 
 	"github.com/google/uuid"
@@ -27,13 +28,17 @@ type (
 		DeleteTag(id uuid.UUID) error
 	}
 	tagService struct {
-		db *gorm.DB
+		db            *gorm.DB
+		cache         cache.Cache
+		searchService SearchService
 	}
 )
 
-func NewTagService(db *gorm.DB) TagService {
+func NewTagService(db *gorm.DB, cache cache.Cache, searchService SearchService) TagService {
 	return &tagService{
-		db: db,
+		db:            db,
+		cache:         cache,
+		searchService: searchService,
 	}
 }
 func (s *tagService) GetTags() ([]*models.Tag, error) {
@@ -79,7 +84,19 @@ func (s *tagService) UpdateTag(id uuid.UUID, params domain.UpdateTagParams) erro
 		tag.Description = *params.Description
 	}
 
-	return s.db.Save(tag).Error
+	if err := s.db.Save(tag).Error; err != nil {
+		return err
+	}
+
+	// 文章详情、列表缓存和搜索索引中包含标签名称
+	var articleIDs []uuid.UUID
+	if err := s.db.Table("article_tags").Where("tag_id = ?", id).Pluck("article_id", &articleIDs).Error; err != nil {
+		return err
+	}
+	if len(articleIDs) > 0 {
+		onArticlesChanged(s.cache, s.searchService, articleIDs...)
+	}
+	return nil
 }
 func (s *tagService) DeleteTag(id uuid.UUID) error {
 	tag := new(models.Tag)
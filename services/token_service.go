@@ -0,0 +1,282 @@
+package services
+
+import (
+	"cms/config"
+	"cms/models"
+	"cms/models/domain"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRefreshTokenInvalid 刷新令牌不存在、已过期或会话已吊销
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+	// ErrRefreshTokenReused 刷新令牌被重复使用，会话已吊销
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，会话已吊销，请重新登录")
+	// ErrTokenRevoked 访问令牌已吊销
+	ErrTokenRevoked = errors.New("登录状态已失效")
+	// ErrSessionNotFound 会话不存在
+	ErrSessionNotFound = errors.New("会话不存在")
+)
+
+type (
+	TokenService interface {
+		// Issue 登录成功后创建会话并签发令牌
+		Issue(userID uuid.UUID, userAgent, ip string) (*domain.TokenPair, error)
+		// Refresh 使用刷新令牌换取新的令牌，旧刷新令牌随即失效
+		Refresh(refreshToken string) (*domain.TokenPair, error)
+		// Validate 检查访问令牌是否已吊销，供 JWT 中间件之后使用
+		Validate(claims jwt.MapClaims) error
+		// Revoke 吊销访问令牌及其所属会话
+		Revoke(claims jwt.MapClaims) error
+		// RevokeOthers 吊销访问令牌所属用户的其他会话，保留当前会话
+		RevokeOthers(claims jwt.MapClaims) error
+		// RevokeUser 吊销用户的全部会话，已签发的访问令牌立即失效
+		RevokeUser(userID uuid.UUID) error
+		// GetSessions 获取用户的有效会话
+		GetSessions(userID uuid.UUID) ([]*models.Session, error)
+	}
+	tokenService struct {
+		db         *gorm.DB
+		keyService KeyService
+		cfg        *config.TokenConfig
+	}
+)
+
+func NewTokenService(db *gorm.DB, keyService KeyService, cfg *config.TokenConfig) TokenService {
+	return &tokenService{
+		db:         db,
+		keyService: keyService,
+		cfg:        cfg,
+	}
+}
+
+func (s *tokenService) Issue(userID uuid.UUID, userAgent, ip string) (*domain.TokenPair, error) {
+	now := time.Now()
+	s.purge(now)
+
+	session := &models.Session{
+		UserID:     userID,
+		UserAgent:  truncate(userAgent, 255),
+		IP:         ip,
+		ExpiresAt:  models.CustomTime(now.Add(s.cfg.RefreshTokenTTL)),
+		LastUsedAt: models.CustomTime(now),
+	}
+
+	var pair *domain.TokenPair
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = s.issue(tx, session, now)
+		return err
+	})
+	return pair, err
+}
+
+func (s *tokenService) Refresh(refreshToken string) (*domain.TokenPair, error) {
+	now := time.Now()
+
+	var pair *domain.TokenPair
+	var reused bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		token := new(models.RefreshToken)
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(token).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		session := new(models.Session)
+		if err := tx.Where("id = ? AND revoked_at IS NULL", token.SessionID).First(session).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		if token.UsedAt != nil {
+			reused = true
+			return ErrRefreshTokenReused
+		}
+
+		if !time.Time(token.ExpiresAt).After(now) {
+			return ErrRefreshTokenInvalid
+		}
+
+		// 以条件更新标记使用，防止并发请求同时使用同一个令牌
+		res := tx.Model(token).Where("used_at IS NULL").Update("used_at", models.CustomTime(now))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return ErrRefreshTokenReused
+		}
+
+		session.ExpiresAt = models.CustomTime(now.Add(s.cfg.RefreshTokenTTL))
+		session.LastUsedAt = models.CustomTime(now)
+		if err := tx.Model(session).Select("expires_at", "last_used_at").Updates(session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = s.issue(tx, session, now)
+		return err
+	})
+
+	// 旧令牌被再次使用，说明令牌可能已泄露，吊销整个会话
+	if reused {
+		token := new(models.RefreshToken)
+		if err := s.db.Where("token_hash = ?", hashToken(refreshToken)).First(token).Error; err == nil {
+			log.Warnf("检测到刷新令牌重复使用，吊销会话 %s", token.SessionID)
+			if err := s.revokeSessions(s.db.Where("id = ?", token.SessionID)); err != nil {
+				log.Errorf("吊销会话失败: %v", err)
+			}
+		}
+	}
+
+	return pair, err
+}
+
+func (s *tokenService) Validate(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	if jti == "" || sid == "" {
+		return ErrTokenRevoked
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTokenRevoked
+	}
+
+	if err := s.db.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", sid).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+func (s *tokenService) Revoke(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return ErrTokenRevoked
+	}
+
+	if err := s.db.Save(&models.RevokedToken{JTI: jti, ExpiresAt: models.CustomTime(exp.Time)}).Error; err != nil {
+		return err
+	}
+	return s.revokeSessions(s.db.Where("id = ?", sid))
+}
+
+func (s *tokenService) RevokeOthers(claims jwt.MapClaims) error {
+	userID, _ := claims["user_id"].(string)
+	sid, _ := claims["sid"].(string)
+	if userID == "" || sid == "" {
+		return ErrTokenRevoked
+	}
+	return s.revokeSessions(s.db.Where("user_id = ? AND id != ?", userID, sid))
+}
+
+func (s *tokenService) RevokeUser(userID uuid.UUID) error {
+	return s.revokeSessions(s.db.Where("user_id = ?", userID))
+}
+
+func (s *tokenService) GetSessions(userID uuid.UUID) ([]*models.Session, error) {
+	var sessions []*models.Session
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// issue 为会话签发访问令牌和新的刷新令牌
+func (s *tokenService) issue(tx *gorm.DB, session *models.Session, now time.Time) (*domain.TokenPair, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.keyService.Sign(jwt.MapClaims{
+		"user_id": session.UserID,
+		"sid":     session.ID,
+		"jti":     uuid.NewString(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.cfg.AccessTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeSessions 吊销满足条件的会话
+func (s *tokenService) revokeSessions(query *gorm.DB) error {
+	return query.Model(&models.Session{}).Where("revoked_at IS NULL").Update("revoked_at", models.CustomTime(time.Now())).Error
+}
+
+// purge 删除已过期的会话、刷新令牌以及吊销记录
+func (s *tokenService) purge(now time.Time) {
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		log.Errorf("清理吊销记录失败: %v", err)
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+		log.Errorf("清理刷新令牌失败: %v", err)
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.Session{}).Error; err != nil {
+		log.Errorf("清理会话失败: %v", err)
+	}
+}
+
+// randomToken 生成 256 位随机刷新令牌
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -1,13 +1,16 @@
 package services
 
 import (
+	"cms/config"
 	"cms/models"
 	"cms/models/domain"
-	"cms/utils"
+	"cms/utils/passwd"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -16,12 +19,18 @@ var (
 	ErrUsernameExists = errors.New("用户名已存在")
 	// ErrPhoneExists 手机号已存在
 	ErrPhoneExists = errors.New("手机号已存在")
+	// ErrEmailExists 邮箱已存在
+	ErrEmailExists = errors.New("邮箱已存在")
 	// ErrUserNotFound 用户不存在
 	ErrUserNotFound = errors.New("用户不存在")
 	// ErrUsernameNotFound 用户名不存在
 	ErrUsernameNotFound = errors.New("用户名不存在")
 	// ErrPasswordIncorrect 密码错误
 	ErrPasswordIncorrect = errors.New("密码错误")
+	// ErrPasswordUnchanged 新密码与原密码相同
+	ErrPasswordUnchanged = errors.New("新密码不能与原密码相同")
+	// ErrInitialPasswordMissing 没有设置初始管理员密码
+	ErrInitialPasswordMissing = errors.New("数据库中没有用户，请设置 INIT_ADMIN_PASSWORD 创建初始管理员")
 )
 
 type (
@@ -32,21 +41,44 @@ type (
 		DeleteUser(id uuid.UUID) error
 		Login(params domain.LoginParams) (*models.User, error)
 		CreateInitialUser(initialUsername, initialPassword string) error
-		// 根据用户id获取是否是超级管理员
-		GetUserIsSuper(id uuid.UUID) (bool, error)
+		// GetProfile 获取当前用户的资料
+		GetProfile(id uuid.UUID) (*models.User, error)
+		// UpdateProfile 修改当前用户的昵称和头像
+		UpdateProfile(id uuid.UUID, params domain.UpdateProfileParams) error
+		// ChangePassword 校验原密码后修改密码
+		ChangePassword(id uuid.UUID, params domain.ChangePasswordParams) error
+		// ResetPassword 找回密码时设置新密码，同样检查密码策略
+		ResetPassword(id uuid.UUID, password string) error
 	}
 	userService struct {
-		db *gorm.DB
+		db      *gorm.DB
+		hasher  *passwd.Hasher
+		policy  *passwd.Policy
+		history int
 	}
 )
 
-func NewUserService(db *gorm.DB) UserService {
-	return &userService{db: db}
+func NewUserService(db *gorm.DB, cfg *config.PasswordConfig) (UserService, error) {
+	hasher, err := passwd.NewHasher(cfg.HashAlgorithm, cfg.BcryptCost, passwd.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := passwd.NewPolicy(cfg.MinLength, cfg.CharacterClasses, cfg.DenylistPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userService{db: db, hasher: hasher, policy: policy, history: cfg.History}, nil
 }
 
 func (s *userService) GetUsers() ([]*models.User, error) {
 	var users []*models.User
-	if err := s.db.Order("created_at DESC").Find(&users).Error; err != nil {
+	if err := s.db.Preload("Roles").Order("created_at DESC").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
@@ -63,8 +95,17 @@ func (s *userService) CreateUser(params domain.CreateUserParams) error {
 		return ErrPhoneExists
 	}
 
+	email, err := s.resolveEmail(uuid.Nil, params.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkPassword(nil, params.Username, params.Password); err != nil {
+		return err
+	}
+
 	// 对密码进行加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(params.Password)
 	if err != nil {
 		return err
 	}
@@ -73,7 +114,8 @@ func (s *userService) CreateUser(params domain.CreateUserParams) error {
 		Nickname: params.Nickname,
 		Phone:    params.Phone,
 		Username: params.Username,
-		Password: string(hashedPassword),
+		Email:    email,
+		Password: hashedPassword,
 	}
 
 	if params.ImageID != nil {
@@ -84,7 +126,12 @@ func (s *userService) CreateUser(params domain.CreateUserParams) error {
 		categoryModel.ImageID = params.ImageID
 	}
 
-	return s.db.Create(&categoryModel).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&categoryModel).Error; err != nil {
+			return err
+		}
+		return s.recordPassword(tx, categoryModel.ID, hashedPassword)
+	})
 }
 
 func (s *userService) UpdateUser(id uuid.UUID, params domain.UpdateUserParams) error {
@@ -111,17 +158,29 @@ func (s *userService) UpdateUser(id uuid.UUID, params domain.UpdateUserParams) e
 		user.Phone = *params.Phone
 	}
 
+	if params.Email != nil {
+		email, err := s.resolveEmail(user.ID, params.Email)
+		if err != nil {
+			return err
+		}
+		user.Email = email
+	}
+
 	if params.Nickname != nil && user.Nickname != *params.Nickname {
 		user.Nickname = *params.Nickname
 	}
 
 	if params.Password != nil {
+		if err := s.checkPassword(user, user.Username, *params.Password); err != nil {
+			return err
+		}
+
 		// 对密码进行加密
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*params.Password), bcrypt.DefaultCost)
+		hashedPassword, err := s.hasher.Hash(*params.Password)
 		if err != nil {
 			return err
 		}
-		user.Password = string(hashedPassword)
+		user.Password = hashedPassword
 	}
 
 	if params.Nickname != nil && user.Nickname != *params.Nickname {
@@ -136,7 +195,15 @@ func (s *userService) UpdateUser(id uuid.UUID, params domain.UpdateUserParams) e
 		user.ImageID = params.ImageID
 	}
 
-	return s.db.Save(user).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		if params.Password != nil {
+			return s.recordPassword(tx, user.ID, user.Password)
+		}
+		return nil
+	})
 }
 
 func (s *userService) DeleteUser(id uuid.UUID) error {
@@ -155,11 +222,25 @@ func (s *userService) Login(params domain.LoginParams) (*models.User, error) {
 		return nil, ErrUsernameNotFound
 	}
 
+	// 锁定期间不再验证密码
+	if user.LockedUntil != nil && time.Time(*user.LockedUntil).After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+
 	// 验证密码
-	if err := utils.VerifyPassword(user.Password, params.Password); err != nil {
+	if ok, err := s.hasher.Verify(user.Password, params.Password); err != nil || !ok {
 		return nil, ErrPasswordIncorrect
 	}
 
+	// 哈希算法或参数变化后使用新配置重新哈希
+	if s.hasher.NeedsRehash(user.Password) {
+		if hash, err := s.hasher.Hash(params.Password); err != nil {
+			log.Errorf("重新哈希密码失败: %v", err)
+		} else if err := s.db.Model(&user).Update("password", hash).Error; err != nil {
+			log.Errorf("更新密码哈希失败: %v", err)
+		}
+	}
+
 	return &user, nil
 }
 
@@ -173,8 +254,17 @@ func (s *userService) CreateInitialUser(initialUsername, initialPassword string)
 		return nil
 	}
 
+	// 不提供默认密码，避免使用公开的默认密码上线
+	if initialPassword == "" {
+		return ErrInitialPasswordMissing
+	}
+
+	if err := s.checkPassword(nil, initialUsername, initialPassword); err != nil {
+		return fmt.Errorf("初始管理员密码不符合密码策略: %w", err)
+	}
+
 	// 创建初始用户
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(initialPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(initialPassword)
 	if err != nil {
 		return err
 	}
@@ -183,19 +273,191 @@ func (s *userService) CreateInitialUser(initialUsername, initialPassword string)
 		Nickname: "ask",
 		Phone:    "18333435634",
 		Username: initialUsername,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		IsSuper:  true,
 	}
 
-	return s.db.Create(user).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return s.recordPassword(tx, user.ID, hashedPassword)
+	})
 }
 
-func (s *userService) GetUserIsSuper(id uuid.UUID) (bool, error) {
+func (s *userService) GetProfile(id uuid.UUID) (*models.User, error) {
+	user := new(models.User)
+	if err := s.db.Where("id = ?", id).Preload("Roles").First(user).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *userService) UpdateProfile(id uuid.UUID, params domain.UpdateProfileParams) error {
 	user := new(models.User)
 	// 检查用户是否存在
 	if err := s.db.Where("id = ?", id).First(user).Error; err != nil {
-		return false, ErrUserNotFound
+		return ErrUserNotFound
+	}
+
+	updates := map[string]any{}
+	if params.Nickname != nil {
+		updates["nickname"] = *params.Nickname
+	}
+
+	if params.Email != nil {
+		email, err := s.resolveEmail(user.ID, params.Email)
+		if err != nil {
+			return err
+		}
+		updates["email"] = email
 	}
 
-	return user.IsSuper, nil
+	if params.ImageID != nil {
+		// 检查图片是否存在
+		if err := s.db.Where("id = ?", *params.ImageID).First(&models.Image{}).Error; err != nil {
+			return ErrImageNotFound
+		}
+		updates["image_id"] = *params.ImageID
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.db.Model(user).Updates(updates).Error
+}
+
+func (s *userService) ChangePassword(id uuid.UUID, params domain.ChangePasswordParams) error {
+	user := new(models.User)
+	// 检查用户是否存在
+	if err := s.db.Where("id = ?", id).First(user).Error; err != nil {
+		return ErrUserNotFound
+	}
+
+	// 验证原密码
+	if ok, err := s.hasher.Verify(user.Password, params.OldPassword); err != nil || !ok {
+		return ErrPasswordIncorrect
+	}
+
+	if params.NewPassword == params.OldPassword {
+		return ErrPasswordUnchanged
+	}
+
+	if err := s.checkPassword(user, user.Username, params.NewPassword); err != nil {
+		return err
+	}
+
+	// 对密码进行加密
+	hashedPassword, err := s.hasher.Hash(params.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return s.recordPassword(tx, user.ID, hashedPassword)
+	})
+}
+
+func (s *userService) ResetPassword(id uuid.UUID, password string) error {
+	user := new(models.User)
+	// 检查用户是否存在
+	if err := s.db.Where("id = ?", id).First(user).Error; err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.checkPassword(user, user.Username, password); err != nil {
+		return err
+	}
+
+	// 对密码进行加密
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return s.recordPassword(tx, user.ID, hashedPassword)
+	})
+}
+
+// resolveEmail 空字符串表示清除邮箱，其他值检查是否被其他用户使用
+func (s *userService) resolveEmail(userID uuid.UUID, email *string) (*string, error) {
+	if email == nil || *email == "" {
+		return nil, nil
+	}
+
+	if err := s.db.Where("email = ? AND id != ?", *email, userID).First(&models.User{}).Error; err == nil {
+		return nil, ErrEmailExists
+	}
+	return email, nil
+}
+
+// checkPassword 检查密码策略，user 不为空时同时检查最近使用过的密码
+func (s *userService) checkPassword(user *models.User, username, password string) error {
+	violations := s.policy.Check(password, username)
+
+	if user != nil && s.history > 0 {
+		reused, err := s.passwordReused(user, password)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, passwd.Violation{
+				Rule:    passwd.RuleHistory,
+				Message: fmt.Sprintf("不能使用最近 %d 次使用过的密码", s.history),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &passwd.PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// passwordReused 密码与当前密码或最近 history 个历史密码相同
+func (s *userService) passwordReused(user *models.User, password string) (bool, error) {
+	hashes := []string{user.Password}
+
+	var histories []*models.PasswordHistory
+	if err := s.db.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(s.history).Find(&histories).Error; err != nil {
+		return false, err
+	}
+	for _, history := range histories {
+		hashes = append(hashes, history.Password)
+	}
+
+	for _, hash := range hashes {
+		if ok, _ := s.hasher.Verify(hash, password); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPassword 开启历史记录时保存新密码的哈希，只保留最近 history 条
+func (s *userService) recordPassword(tx *gorm.DB, userID uuid.UUID, hash string) error {
+	if s.history <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, Password: hash}).Error; err != nil {
+		return err
+	}
+
+	var ids []uuid.UUID
+	if err := tx.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("created_at DESC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) > s.history {
+		return tx.Where("id IN ?", ids[s.history:]).Delete(&models.PasswordHistory{}).Error
+	}
+	return nil
 }
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"cms/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DriverMemory 进程内 LRU 缓存
+	DriverMemory = "memory"
+	// DriverRedis Redis 兼容缓存
+	DriverRedis = "redis"
+)
+
+var (
+	// ErrUnknownDriver 未知的缓存驱动
+	ErrUnknownDriver = errors.New("未知的缓存驱动")
+	// ErrPrefixOverlap 安全存储与普通缓存的 Redis 前缀重叠
+	ErrPrefixOverlap = errors.New("REDIS_SECURITY_PREFIX 不能与 REDIS_PREFIX 互为前缀")
+)
+
+type (
+	// Cache 缓存接口，值统一为序列化后的字节
+	Cache interface {
+		// Get 获取缓存，第二个返回值表示是否命中
+		Get(key string) ([]byte, bool)
+		// Set 设置缓存，ttl 为 0 时使用默认过期时间
+		Set(key string, value []byte, ttl time.Duration) error
+		// Incr 计数加一并返回新值，键不存在时创建并设置过期时间
+		Incr(key string, ttl time.Duration) (int64, error)
+		// Delete 删除指定的键
+		Delete(keys ...string) error
+		// DeletePrefix 删除指定前缀的所有键
+		DeletePrefix(prefix string) error
+		// Stats 获取命中统计
+		Stats() Stats
+		// TTL 默认过期时间
+		TTL() time.Duration
+	}
+
+	// Stats 缓存统计
+	Stats struct {
+		Driver string `json:"driver"`
+		Hits   uint64 `json:"hits"`
+		Misses uint64 `json:"misses"`
+		Keys   int64  `json:"keys"`
+	}
+
+	// counter 命中计数器
+	counter struct {
+		hits   atomic.Uint64
+		misses atomic.Uint64
+	}
+)
+
+func (c *counter) hit() {
+	c.hits.Add(1)
+}
+
+func (c *counter) miss() {
+	c.misses.Add(1)
+}
+
+func New(cfg *config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case "", DriverMemory:
+		return NewMemoryCache(cfg.Capacity, cfg.TTL), nil
+	case DriverRedis:
+		return NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.RedisPrefix, cfg.TTL)
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
+
+// NewSecurityStore 创建保存登录失败计数、验证码错误次数等安全状态的存储。
+// 内存驱动下不按容量淘汰；Redis 驱动下使用独立的前缀，清空缓存时不会被删除
+func NewSecurityStore(cfg *config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case "", DriverMemory:
+		return NewMemoryStore(cfg.TTL), nil
+	case DriverRedis:
+		if strings.HasPrefix(cfg.RedisSecurityPrefix, cfg.RedisPrefix) || strings.HasPrefix(cfg.RedisPrefix, cfg.RedisSecurityPrefix) {
+			return nil, fmt.Errorf("%w: %q, %q", ErrPrefixOverlap, cfg.RedisSecurityPrefix, cfg.RedisPrefix)
+		}
+		return NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.RedisSecurityPrefix, cfg.TTL)
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
+
+// Remember 先从缓存读取，未命中时调用 fn 并写入缓存
+func Remember[T any](c Cache, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	return RememberWithTTL(c, key, func() (T, time.Duration, error) {
+		value, err := fn()
+		return value, ttl, err
+	})
+}
+
+// RememberWithTTL 与 Remember 相同，但过期时间由 fn 根据查询结果决定
+func RememberWithTTL[T any](c Cache, key string, fn func() (T, time.Duration, error)) (T, error) {
+	if data, ok := c.Get(key); ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, ttl, err := fn()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		c.Set(key, data, ttl)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	memoryCache struct {
+		mu       sync.Mutex
+		capacity int
+		ttl      time.Duration
+		ll       *list.List
+		items    map[string]*list.Element
+		counter
+	}
+
+	memoryEntry struct {
+		key       string
+		value     []byte
+		expiresAt time.Time
+	}
+)
+
+// NewMemoryCache 创建带过期时间的进程内 LRU 缓存
+func NewMemoryCache(capacity int, ttl time.Duration) Cache {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+
+	return &memoryCache{
+		capacity: capacity,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// NewMemoryStore 创建不按容量淘汰的进程内存储，条目只在过期后定期清理，
+// 用于登录失败计数等不能被其他缓存挤出的安全状态
+func NewMemoryStore(ttl time.Duration) Cache {
+	c := &memoryCache{
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+	go c.sweep(time.Minute)
+	return c
+}
+
+// sweep 定期删除已过期的条目
+func (c *memoryCache) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		c.mu.Lock()
+		for _, el := range c.items {
+			entry := el.Value.(*memoryEntry)
+			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				c.removeElement(el)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.miss()
+		return nil, false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.removeElement(el)
+		c.miss()
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hit()
+	return entry.value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	// 超出容量时淘汰最久未使用的条目
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Incr(key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			n, err := strconv.ParseInt(string(entry.value), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			n++
+			entry.value = strconv.AppendInt(nil, n, 10)
+			c.ll.MoveToFront(el)
+			return n, nil
+		}
+		c.removeElement(el)
+	}
+
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: []byte("1"), expiresAt: expiresAt})
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+	return 1, nil
+}
+
+func (c *memoryCache) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) DeletePrefix(prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) TTL() time.Duration {
+	return c.ttl
+}
+
+func (c *memoryCache) Stats() Stats {
+	c.mu.Lock()
+	keys := int64(c.ll.Len())
+	c.mu.Unlock()
+
+	return Stats{
+		Driver: DriverMemory,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Keys:   keys,
+	}
+}
+
+func (c *memoryCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
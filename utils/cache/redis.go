@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+	counter
+}
+
+// NewRedisCache 创建 Redis 兼容缓存，所有键都会加上 prefix
+func NewRedisCache(addr, password string, db int, prefix string, ttl time.Duration) (Cache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisCache{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}, nil
+}
+
+func (c *redisCache) Get(key string) ([]byte, bool) {
+	data, err := c.client.Get(context.Background(), c.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Errorf("读取缓存 %s 失败: %v", key, err)
+		}
+		c.miss()
+		return nil, false
+	}
+
+	c.hit()
+	return data, true
+}
+
+func (c *redisCache) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return c.client.Set(context.Background(), c.prefix+key, value, ttl).Err()
+}
+
+// incrScript 在同一个脚本中自增并设置过期时间，进程在两步之间退出也不会留下永不过期的计数器。
+// 计数器没有过期时间时（包括首次创建）才设置，不会延长已有计数窗口
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+local ttl = tonumber(ARGV[1])
+if ttl > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return n
+`)
+
+func (c *redisCache) Incr(key string, ttl time.Duration) (int64, error) {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	return incrScript.Run(context.Background(), c.client, []string{c.prefix + key}, ttl.Milliseconds()).Int64()
+}
+
+func (c *redisCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	fullKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fullKeys = append(fullKeys, c.prefix+key)
+	}
+	return c.client.Del(context.Background(), fullKeys...).Err()
+}
+
+func (c *redisCache) DeletePrefix(prefix string) error {
+	ctx := context.Background()
+	iter := c.client.Scan(ctx, 0, c.prefix+prefix+"*", 500).Iterator()
+
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := c.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return c.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
+
+func (c *redisCache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Stats 只统计带前缀的键，同一个库中的其他数据不计入
+func (c *redisCache) Stats() Stats {
+	ctx := context.Background()
+	iter := c.client.Scan(ctx, 0, c.prefix+"*", 500).Iterator()
+
+	var keys int64
+	for iter.Next(ctx) {
+		keys++
+	}
+	if err := iter.Err(); err != nil {
+		log.Errorf("统计缓存键数量失败: %v", err)
+	}
+
+	return Stats{
+		Driver: DriverRedis,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Keys:   keys,
+	}
+}
@@ -0,0 +1,87 @@
+package captcha
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// 验证码类型
+const (
+	ModeImage = "image" // 扭曲的数字
+	ModeMath  = "math"  // 算术题
+)
+
+var (
+	// ErrUnknownMode 不支持的验证码类型
+	ErrUnknownMode = errors.New("不支持的验证码类型")
+)
+
+// Captcha 生成的验证码，Answer 只保存在服务端
+type Captcha struct {
+	ID     string
+	Image  []byte // PNG
+	Answer string
+}
+
+// New 生成指定类型的验证码，length 为数字验证码的位数
+func New(mode string, length int) (*Captcha, error) {
+	var text, answer string
+	switch mode {
+	case "", ModeImage:
+		var b strings.Builder
+		for range max(length, 1) {
+			b.WriteByte(byte('0' + randInt(10)))
+		}
+		text, answer = b.String(), b.String()
+	case ModeMath:
+		text, answer = mathChallenge()
+	default:
+		return nil, ErrUnknownMode
+	}
+
+	img, err := render(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Captcha{ID: newID(), Image: img, Answer: answer}, nil
+}
+
+// mathChallenge 生成结果为非负整数的算术题
+func mathChallenge() (string, string) {
+	a, b := randInt(20)+1, randInt(20)+1
+	switch randInt(3) {
+	case 0:
+		return fmt.Sprintf("%d+%d=?", a, b), strconv.Itoa(a + b)
+	case 1:
+		if a < b {
+			a, b = b, a
+		}
+		return fmt.Sprintf("%d-%d=?", a, b), strconv.Itoa(a - b)
+	default:
+		a, b = randInt(9)+1, randInt(9)+1
+		return fmt.Sprintf("%dx%d=?", a, b), strconv.Itoa(a * b)
+	}
+}
+
+// randInt 返回 [0, n) 之间的随机数
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
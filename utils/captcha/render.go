@@ -0,0 +1,118 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+const (
+	imageWidth  = 150
+	imageHeight = 50
+)
+
+// render 将文字绘制为带噪点、干扰线和波形扭曲的 PNG 图片
+func render(text string) ([]byte, error) {
+	src := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	bg := color.RGBA{uint8(230 + randInt(26)), uint8(230 + randInt(26)), uint8(230 + randInt(26)), 255}
+	for y := range imageHeight {
+		for x := range imageWidth {
+			src.Set(x, y, bg)
+		}
+	}
+
+	runes := []rune(text)
+	// 根据字符数量计算缩放比例
+	scale := min(4, (imageWidth-10)/(len(runes)*(glyphWidth+1)))
+	scale = max(scale, 2)
+	x := (imageWidth - len(runes)*(glyphWidth+1)*scale) / 2
+	for _, r := range runes {
+		y := (imageHeight-glyphHeight*scale)/2 + randInt(7) - 3
+		// 每个字符随机倾斜
+		shear := float64(randInt(7)-3) / 10
+		drawGlyph(src, r, x, y, scale, shear, randomDark())
+		x += (glyphWidth + 1) * scale
+	}
+
+	// 干扰线
+	for range 3 {
+		drawCurve(src, randomDark())
+	}
+
+	// 噪点
+	for range imageWidth * imageHeight / 12 {
+		src.Set(randInt(imageWidth), randInt(imageHeight), randomDark())
+	}
+
+	dst := wave(src, bg)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawGlyph 按比例绘制点阵字符，shear 为水平倾斜系数
+func drawGlyph(img *image.RGBA, r rune, x0, y0, scale int, shear float64, c color.Color) {
+	glyph, ok := glyphs[r]
+	if !ok {
+		return
+	}
+
+	for row := range glyphHeight {
+		for col := range glyphWidth {
+			if glyph[row]&(1<<(glyphWidth-1-col)) == 0 {
+				continue
+			}
+			for dy := range scale {
+				y := y0 + row*scale + dy
+				offset := int(shear * float64(glyphHeight*scale/2-row*scale-dy))
+				for dx := range scale {
+					img.Set(x0+col*scale+dx+offset, y, c)
+				}
+			}
+		}
+	}
+}
+
+// drawCurve 绘制一条横穿图片的随机正弦曲线
+func drawCurve(img *image.RGBA, c color.Color) {
+	amplitude := float64(randInt(imageHeight/4) + 2)
+	period := float64(randInt(imageWidth) + imageWidth/2)
+	phase := float64(randInt(628)) / 100
+	base := float64(randInt(imageHeight/2) + imageHeight/4)
+
+	for x := range imageWidth {
+		y := int(base + amplitude*math.Sin(2*math.Pi*float64(x)/period+phase))
+		img.Set(x, y, c)
+		img.Set(x, y+1, c)
+	}
+}
+
+// wave 对图片做水平方向的正弦扭曲
+func wave(src *image.RGBA, bg color.Color) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	amplitude := float64(randInt(3) + 2)
+	period := float64(randInt(20) + 25)
+	phase := float64(randInt(628)) / 100
+
+	for y := range imageHeight {
+		shift := int(amplitude * math.Sin(2*math.Pi*float64(y)/period+phase))
+		for x := range imageWidth {
+			sx := x + shift
+			if sx < 0 || sx >= imageWidth {
+				dst.Set(x, y, bg)
+				continue
+			}
+			dst.Set(x, y, src.At(sx, y))
+		}
+	}
+	return dst
+}
+
+func randomDark() color.RGBA {
+	return color.RGBA{uint8(randInt(140)), uint8(randInt(140)), uint8(randInt(140)), 255}
+}
@@ -44,7 +44,7 @@ func InitDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	db.AutoMigrate(&models.Category{}, &models.User{}, &models.Image{}, &models.Tag{}, &models.Article{}, &models.Dict{})
+	db.AutoMigrate(&models.Category{}, &models.User{}, &models.Image{}, &models.Tag{}, &models.Article{}, &models.Dict{}, &models.ArticleRevision{}, &models.ArticleReview{}, &models.ArticleSlug{}, &models.SigningKey{}, &models.Session{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Permission{}, &models.Role{}, &models.RecoveryCode{}, &models.PasswordHistory{}, &models.AccessToken{}, &models.UserIdentity{})
 
 	return db, nil
 }
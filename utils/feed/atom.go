@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type (
+	atomFeed struct {
+		XMLName  xml.Name    `xml:"feed"`
+		Xmlns    string      `xml:"xmlns,attr"`
+		Lang     string      `xml:"xml:lang,attr,omitempty"`
+		ID       string      `xml:"id"`
+		Title    string      `xml:"title"`
+		Subtitle string      `xml:"subtitle,omitempty"`
+		Updated  string      `xml:"updated"`
+		Links    []atomLink  `xml:"link"`
+		Entries  []atomEntry `xml:"entry"`
+	}
+
+	atomLink struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr,omitempty"`
+		Type   string `xml:"type,attr,omitempty"`
+		Length int64  `xml:"length,attr,omitempty"`
+	}
+
+	atomEntry struct {
+		ID         string         `xml:"id"`
+		Title      string         `xml:"title"`
+		Updated    string         `xml:"updated"`
+		Published  string         `xml:"published"`
+		Links      []atomLink     `xml:"link"`
+		Author     *atomAuthor    `xml:"author"`
+		Categories []atomCategory `xml:"category"`
+		Summary    string         `xml:"summary,omitempty"`
+	}
+
+	atomAuthor struct {
+		Name string `xml:"name"`
+	}
+
+	atomCategory struct {
+		Term string `xml:"term,attr"`
+	}
+)
+
+// Atom 输出 Atom 1.0 格式
+func Atom(f *Feed) ([]byte, error) {
+	feed := atomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		Lang:     f.Language,
+		ID:       f.FeedURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Updated:   item.Updated.Format(time.RFC3339),
+			Published: item.Published.Format(time.RFC3339),
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Summary:   item.Description,
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Enclosure != nil {
+			entry.Links = append(entry.Links, atomLink{Href: item.Enclosure.URL, Rel: "enclosure", Type: item.Enclosure.Type, Length: item.Enclosure.Length})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
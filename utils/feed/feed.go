@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"errors"
+	"time"
+)
+
+// 支持的订阅源格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var (
+	// ErrUnknownFormat 不支持的订阅源格式
+	ErrUnknownFormat = errors.New("不支持的订阅源格式")
+)
+
+type (
+	// Feed 与输出格式无关的订阅源
+	Feed struct {
+		Title       string    `json:"title"`
+		Link        string    `json:"link"` // 站点或分类页地址
+		FeedURL     string    `json:"feedUrl"`
+		Description string    `json:"description"`
+		Language    string    `json:"language"`
+		Updated     time.Time `json:"updated"`
+		Items       []*Item   `json:"items"`
+	}
+
+	Item struct {
+		ID          string     `json:"id"`
+		Title       string     `json:"title"`
+		Link        string     `json:"link"`
+		Description string     `json:"description"`
+		Author      string     `json:"author"`
+		Categories  []string   `json:"categories"`
+		Published   time.Time  `json:"published"`
+		Updated     time.Time  `json:"updated"`
+		Enclosure   *Enclosure `json:"enclosure"`
+	}
+
+	Enclosure struct {
+		URL    string `json:"url"`
+		Type   string `json:"type"`
+		Length int64  `json:"length"`
+	}
+)
+
+// ContentType 返回格式对应的 Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return ""
+}
+
+// Render 按指定格式输出订阅源
+func Render(f *Feed, format string) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return RSS(f)
+	case FormatAtom:
+		return Atom(f)
+	case FormatJSON:
+		return JSON(f)
+	}
+	return nil, ErrUnknownFormat
+}
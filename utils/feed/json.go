@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type (
+	jsonFeed struct {
+		Version     string     `json:"version"`
+		Title       string     `json:"title"`
+		HomePageURL string     `json:"home_page_url,omitempty"`
+		FeedURL     string     `json:"feed_url,omitempty"`
+		Description string     `json:"description,omitempty"`
+		Language    string     `json:"language,omitempty"`
+		Items       []jsonItem `json:"items"`
+	}
+
+	jsonItem struct {
+		ID            string           `json:"id"`
+		URL           string           `json:"url,omitempty"`
+		Title         string           `json:"title"`
+		Summary       string           `json:"summary,omitempty"`
+		ContentText   string           `json:"content_text"`
+		Image         string           `json:"image,omitempty"`
+		DatePublished string           `json:"date_published"`
+		DateModified  string           `json:"date_modified"`
+		Authors       []jsonAuthor     `json:"authors,omitempty"`
+		Tags          []string         `json:"tags,omitempty"`
+		Attachments   []jsonAttachment `json:"attachments,omitempty"`
+	}
+
+	jsonAuthor struct {
+		Name string `json:"name"`
+	}
+
+	jsonAttachment struct {
+		URL         string `json:"url"`
+		MimeType    string `json:"mime_type"`
+		SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+	}
+)
+
+// JSON 输出 JSON Feed 1.1 格式
+func JSON(f *Feed) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+
+	for _, item := range f.Items {
+		entry := jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			Summary:       item.Description,
+			ContentText:   item.Description,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		if item.Enclosure != nil {
+			entry.Image = item.Enclosure.URL
+			entry.Attachments = []jsonAttachment{{URL: item.Enclosure.URL, MimeType: item.Enclosure.Type, SizeInBytes: item.Enclosure.Length}}
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
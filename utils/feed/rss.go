@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type (
+	rss struct {
+		XMLName xml.Name   `xml:"rss"`
+		Version string     `xml:"version,attr"`
+		Atom    string     `xml:"xmlns:atom,attr"`
+		DC      string     `xml:"xmlns:dc,attr"`
+		Channel rssChannel `xml:"channel"`
+	}
+
+	rssChannel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Self          atomLink  `xml:"atom:link"`
+		Description   string    `xml:"description"`
+		Language      string    `xml:"language,omitempty"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Items         []rssItem `xml:"item"`
+	}
+
+	rssItem struct {
+		Title       string        `xml:"title"`
+		Link        string        `xml:"link"`
+		GUID        rssGUID       `xml:"guid"`
+		Description string        `xml:"description,omitempty"`
+		Author      string        `xml:"dc:creator,omitempty"`
+		Categories  []string      `xml:"category"`
+		PubDate     string        `xml:"pubDate"`
+		Enclosure   *rssEnclosure `xml:"enclosure"`
+	}
+
+	rssGUID struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+
+	rssEnclosure struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	}
+)
+
+// RSS 输出 RSS 2.0 格式。RSS 的 author 要求是邮箱，作者昵称使用 dc:creator 输出
+func RSS(f *Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Self:        atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       make([]rssItem, 0, len(f.Items)),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			Description: item.Description,
+			Author:      item.Author,
+			Categories:  item.Categories,
+			PubDate:     item.Published.Format(time.RFC1123Z),
+		}
+		if item.Enclosure != nil {
+			entry.Enclosure = &rssEnclosure{URL: item.Enclosure.URL, Type: item.Enclosure.Type, Length: item.Enclosure.Length}
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+
+	return marshalXML(rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel,
+	})
+}
+
+// marshalXML 输出带 XML 声明的文档
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,395 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image/jpeg"
+	"image/png"
+	"slices"
+)
+
+var (
+	// ErrMalformed 文件结构错误
+	ErrMalformed = errors.New("图片文件结构错误")
+)
+
+// CleanOptions 上传图片的处理参数
+type CleanOptions struct {
+	// 删除 EXIF、XMP、IPTC、注释等元数据，颜色配置文件会保留
+	StripMetadata bool
+	// 按 EXIF 方向旋转图片，旋转后需要重新编码
+	AutoOrient bool
+	// 删除元数据时保留的标签：artist、copyright
+	KeepTags []string
+	// 旋转后重新编码 JPEG 的质量
+	Quality int
+}
+
+// Clean 对上传的图片应用 EXIF 方向并删除元数据。
+// 不需要旋转时只删除元数据段，不会重新编码，图像数据保持不变；目前处理 JPEG、PNG 和 WebP，其他格式原样返回
+func Clean(data []byte, format string, opts CleanOptions) ([]byte, error) {
+	if !opts.StripMetadata && !opts.AutoOrient {
+		return data, nil
+	}
+	switch format {
+	case FormatJPEG:
+		return cleanJPEG(data, opts)
+	case FormatPNG:
+		return cleanPNG(data, opts)
+	case FormatWebP:
+		// WebP 只删除元数据，不应用 EXIF 方向
+		if opts.StripMetadata {
+			return cleanWebP(data, opts)
+		}
+	}
+	return data, nil
+}
+
+// keptExif 删除元数据时需要写回的 EXIF，没有需要保留的标签时返回 nil
+func keptExif(info Exif, keep []string) []byte {
+	var artist, copyright string
+	if slices.Contains(keep, TagArtist) {
+		artist = info.Artist
+	}
+	if slices.Contains(keep, TagCopyright) {
+		copyright = info.Copyright
+	}
+	return buildExif(artist, copyright)
+}
+
+// jpegSegment JPEG 中 SOS 之前的一个段，data 包含标记和长度
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// payload 段的内容，不含标记和长度
+func (s jpegSegment) payload() []byte {
+	return s.data[4:]
+}
+
+// splitJPEG 拆分 SOS 之前的段，rest 为从 SOS 开始的剩余数据
+func splitJPEG(data []byte) ([]jpegSegment, []byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, nil, ErrMalformed
+	}
+
+	var segments []jpegSegment
+	i := 2
+	for {
+		// 跳过填充字节
+		for i < len(data) && data[i] == 0xff && i+1 < len(data) && data[i+1] == 0xff {
+			i++
+		}
+		if i+4 > len(data) || data[i] != 0xff {
+			return nil, nil, ErrMalformed
+		}
+		marker := data[i+1]
+		if marker == 0xda {
+			return segments, data[i:], nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, nil, ErrMalformed
+		}
+		segments = append(segments, jpegSegment{marker: marker, data: data[i : i+2+length]})
+		i += 2 + length
+	}
+}
+
+func jpegAPP1(tiff []byte) jpegSegment {
+	payload := append(bytes.Clone(exifHeader), tiff...)
+	data := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(data[2:], uint16(len(payload)+2))
+	return jpegSegment{marker: 0xe1, data: append(data, payload...)}
+}
+
+func cleanJPEG(data []byte, opts CleanOptions) ([]byte, error) {
+	segments, rest, err := splitJPEG(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info Exif
+	for _, s := range segments {
+		if s.marker == 0xe1 && bytes.HasPrefix(s.payload(), exifHeader) {
+			info = parseExif(s.payload()[len(exifHeader):])
+			break
+		}
+	}
+	rotate := opts.AutoOrient && info.Orientation >= 2 && info.Orientation <= 8
+
+	// 元数据段：APPn 和注释，其他为图像数据需要的段
+	var meta, base []jpegSegment
+	for _, s := range segments {
+		isMeta := (s.marker >= 0xe0 && s.marker <= 0xef) || s.marker == 0xfe
+		switch {
+		case !isMeta:
+			base = append(base, s)
+		case s.marker == 0xe1 && bytes.HasPrefix(s.payload(), exifHeader):
+			if opts.StripMetadata {
+				continue
+			}
+			if rotate {
+				s = jpegAPP1(info.resetOrientation(s.payload()[len(exifHeader):]))
+			}
+			meta = append(meta, s)
+		case opts.StripMetadata && !jpegKeepSegment(s.marker):
+			continue
+		case rotate && s.marker == 0xee:
+			// 重新编码后为 YCbCr，原来的 Adobe 颜色变换不再适用
+			continue
+		default:
+			meta = append(meta, s)
+		}
+	}
+	if opts.StripMetadata {
+		if tiff := keptExif(info, opts.KeepTags); tiff != nil {
+			// JFIF 要求 APP0 在最前面
+			at := 0
+			if len(meta) > 0 && meta[0].marker == 0xe0 {
+				at = 1
+			}
+			meta = slices.Insert(meta, at, jpegAPP1(tiff))
+		}
+	}
+
+	// 旋转后使用新编码的图像数据
+	if rotate {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Orient(img, info.Orientation), &jpeg.Options{Quality: opts.Quality}); err != nil {
+			return nil, err
+		}
+		encoded, encodedRest, err := splitJPEG(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		base, rest = base[:0], encodedRest
+		for _, s := range encoded {
+			if !(s.marker >= 0xe0 && s.marker <= 0xef) && s.marker != 0xfe {
+				base = append(base, s)
+			}
+		}
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write([]byte{0xff, 0xd8})
+	for _, s := range meta {
+		out.Write(s.data)
+	}
+	for _, s := range base {
+		out.Write(s.data)
+	}
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+// jpegKeepSegment 删除元数据时保留的段：APP0（JFIF）、APP2（ICC 颜色配置）、APP14（Adobe 颜色变换）
+func jpegKeepSegment(marker byte) bool {
+	return marker == 0xe0 || marker == 0xe2 || marker == 0xee
+}
+
+// pngChunk PNG 中的一个数据块，data 包含长度、类型和 CRC
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func (c pngChunk) payload() []byte {
+	return c.data[8 : len(c.data)-4]
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func splitPNG(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+	var chunks []pngChunk
+	for i := len(pngSignature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		if length < 0 || i+12+length > len(data) {
+			return nil, ErrMalformed
+		}
+		chunk := pngChunk{typ: string(data[i+4 : i+8]), data: data[i : i+12+length]}
+		chunks = append(chunks, chunk)
+		i += 12 + length
+		if chunk.typ == "IEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+func newPNGChunk(typ string, payload []byte) pngChunk {
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	data = append(data, typ...)
+	data = append(data, payload...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data[4:]))
+	return pngChunk{typ: typ, data: data}
+}
+
+// pngTextKeyword 文本块的关键字
+func pngTextKeyword(c pngChunk) string {
+	keyword, _, _ := bytes.Cut(c.payload(), []byte{0})
+	return string(keyword)
+}
+
+func cleanPNG(data []byte, opts CleanOptions) ([]byte, error) {
+	chunks, err := splitPNG(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info Exif
+	for _, c := range chunks {
+		if c.typ == "eXIf" {
+			info = parseExif(c.payload())
+			break
+		}
+	}
+	rotate := opts.AutoOrient && info.Orientation >= 2 && info.Orientation <= 8
+
+	// 图像结构相关的块，旋转后由重新编码的结果替换
+	structural := func(typ string) bool {
+		return typ == "IHDR" || typ == "PLTE" || typ == "tRNS" || typ == "IDAT" || typ == "IEND"
+	}
+
+	var ancillary []pngChunk
+	for _, c := range chunks {
+		switch {
+		case structural(c.typ):
+		case c.typ == "eXIf":
+			if opts.StripMetadata {
+				continue
+			}
+			if rotate {
+				c = newPNGChunk("eXIf", info.resetOrientation(c.payload()))
+			}
+			ancillary = append(ancillary, c)
+		case opts.StripMetadata && (c.typ == "tEXt" || c.typ == "zTXt" || c.typ == "iTXt"):
+			// 文本块中的 Author、Copyright 对应 EXIF 的作者和版权
+			keyword := pngTextKeyword(c)
+			if (keyword == "Author" && slices.Contains(opts.KeepTags, TagArtist)) ||
+				(keyword == "Copyright" && slices.Contains(opts.KeepTags, TagCopyright)) {
+				ancillary = append(ancillary, c)
+			}
+		case opts.StripMetadata && c.typ == "tIME":
+		default:
+			ancillary = append(ancillary, c)
+		}
+	}
+	if opts.StripMetadata {
+		if tiff := keptExif(info, opts.KeepTags); tiff != nil {
+			ancillary = append(ancillary, newPNGChunk("eXIf", tiff))
+		}
+	}
+
+	base := chunks
+	if rotate {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, Orient(img, info.Orientation)); err != nil {
+			return nil, err
+		}
+		if base, err = splitPNG(buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 辅助块放在 IHDR 之后，满足 iCCP、gAMA 等必须位于 PLTE 和 IDAT 之前的要求
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	for _, c := range base {
+		if !structural(c.typ) {
+			continue
+		}
+		out.Write(c.data)
+		if c.typ == "IHDR" {
+			for _, a := range ancillary {
+				out.Write(a.data)
+			}
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// VP8X 中表示包含 EXIF 和 XMP 的标志位
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func cleanWebP(data []byte, opts CleanOptions) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	var info Exif
+	var chunks [][]byte
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			// 部分编码器不写最后的填充字节
+			if i+8+size != len(data) {
+				return nil, ErrMalformed
+			}
+			end = len(data)
+		}
+		chunk := data[i:end]
+		switch string(chunk[:4]) {
+		case "EXIF":
+			info = parseExif(bytes.TrimPrefix(chunk[8:8+size], exifHeader))
+		case "XMP ":
+		default:
+			chunks = append(chunks, bytes.Clone(chunk))
+		}
+		i = end
+	}
+
+	tiff := keptExif(info, opts.KeepTags)
+	for _, chunk := range chunks {
+		if string(chunk[:4]) == "VP8X" && len(chunk) > 8 {
+			chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			if tiff != nil {
+				chunk[8] |= webpFlagEXIF
+			}
+		}
+	}
+	// 没有 VP8X 的简单格式不能包含 EXIF
+	if tiff != nil && len(chunks) > 0 && string(chunks[0][:4]) == "VP8X" {
+		chunk := append([]byte("EXIF"), binary.LittleEndian.AppendUint32(nil, uint32(len(tiff)))...)
+		chunk = append(chunk, tiff...)
+		if len(tiff)%2 == 1 {
+			chunk = append(chunk, 0)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.WriteString("RIFF")
+	out.Write([]byte{0, 0, 0, 0})
+	out.WriteString("WEBP")
+	for _, chunk := range chunks {
+		out.Write(chunk)
+	}
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:], uint32(len(result)-8))
+	return result, nil
+}
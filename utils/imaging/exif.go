@@ -0,0 +1,195 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// 保留的 EXIF 标签
+const (
+	TagArtist    = "artist"
+	TagCopyright = "copyright"
+)
+
+const (
+	exifTagOrientation = 0x0112
+	exifTagArtist      = 0x013b
+	exifTagCopyright   = 0x8298
+
+	exifTypeASCII = 2
+	exifTypeShort = 3
+)
+
+// exifHeader JPEG APP1 段中 EXIF 数据的前缀
+var exifHeader = []byte("Exif\x00\x00")
+
+// Exif 需要关注的 EXIF 信息
+type Exif struct {
+	Orientation int
+	Artist      string
+	Copyright   string
+	// 方向标签值在 TIFF 数据中的位置，用于保留元数据时重置方向
+	orientationOffset int
+	order             binary.ByteOrder
+}
+
+// parseExif 解析 TIFF 格式的 EXIF 数据中第一个 IFD，格式错误时返回空结果
+func parseExif(tiff []byte) Exif {
+	var info Exif
+	if len(tiff) < 8 {
+		return info
+	}
+	switch string(tiff[:2]) {
+	case "II":
+		info.order = binary.LittleEndian
+	case "MM":
+		info.order = binary.BigEndian
+	default:
+		return info
+	}
+	if info.order.Uint16(tiff[2:]) != 42 {
+		return info
+	}
+
+	offset := int(info.order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return info
+	}
+	count := int(info.order.Uint16(tiff[offset:]))
+	for i := range count {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := info.order.Uint16(tiff[entry:])
+		typ := info.order.Uint16(tiff[entry+2:])
+		n := int(info.order.Uint32(tiff[entry+4:]))
+
+		switch {
+		case tag == exifTagOrientation && typ == exifTypeShort:
+			info.Orientation = int(info.order.Uint16(tiff[entry+8:]))
+			info.orientationOffset = entry + 8
+		case (tag == exifTagArtist || tag == exifTagCopyright) && typ == exifTypeASCII:
+			value := tiff[entry+8 : entry+12]
+			if n > 4 {
+				start := int(info.order.Uint32(tiff[entry+8:]))
+				if start < 0 || start+n > len(tiff) {
+					continue
+				}
+				value = tiff[start : start+n]
+			} else {
+				value = value[:n]
+			}
+			text := string(bytes.TrimRight(value, "\x00 "))
+			if tag == exifTagArtist {
+				info.Artist = text
+			} else {
+				info.Copyright = text
+			}
+		}
+	}
+	return info
+}
+
+// resetOrientation 返回方向改为 1 的 EXIF 数据副本
+func (e Exif) resetOrientation(tiff []byte) []byte {
+	out := bytes.Clone(tiff)
+	if e.orientationOffset > 0 {
+		e.order.PutUint16(out[e.orientationOffset:], 1)
+	}
+	return out
+}
+
+// buildExif 只包含作者和版权的 TIFF 数据，都为空时返回 nil
+func buildExif(artist, copyright string) []byte {
+	type entry struct {
+		tag   uint16
+		value []byte
+	}
+	var entries []entry
+	// IFD 中的标签必须按升序排列
+	if artist != "" {
+		entries = append(entries, entry{exifTagArtist, append([]byte(artist), 0)})
+	}
+	if copyright != "" {
+		entries = append(entries, entry{exifTagCopyright, append([]byte(copyright), 0)})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	buf.Write(le.AppendUint16(nil, 42))
+	buf.Write(le.AppendUint32(nil, 8))
+	buf.Write(le.AppendUint16(nil, uint16(len(entries))))
+
+	// 超过 4 字节的值保存在 IFD 之后
+	dataOffset := 8 + 2 + len(entries)*12 + 4
+	var data []byte
+	for _, e := range entries {
+		buf.Write(le.AppendUint16(nil, e.tag))
+		buf.Write(le.AppendUint16(nil, exifTypeASCII))
+		buf.Write(le.AppendUint32(nil, uint32(len(e.value))))
+		if len(e.value) <= 4 {
+			value := make([]byte, 4)
+			copy(value, e.value)
+			buf.Write(value)
+			continue
+		}
+		buf.Write(le.AppendUint32(nil, uint32(dataOffset+len(data))))
+		data = append(data, e.value...)
+		if len(data)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	buf.Write(le.AppendUint32(nil, 0))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// Orient 按 EXIF 方向旋转或翻转图片，使其以正常方向显示
+func Orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180 度
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90 度
+				dx, dy = h-1-y, x
+			case 7: // 沿副对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转 90 度
+				dx, dy = y, w-1-x
+			}
+			si, di := img.PixOffset(x, y), dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
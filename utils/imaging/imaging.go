@@ -0,0 +1,205 @@
+// Package imaging 使用纯 Go 实现图片缩放、裁剪和格式转换
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// 缩放方式
+const (
+	FitContain = "contain" // 完整显示在目标尺寸内，不裁剪
+	FitCover   = "cover"   // 填满目标尺寸，居中裁剪多余部分
+)
+
+// 输出格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+var (
+	// ErrUnsupportedFormat 不支持的图片格式
+	ErrUnsupportedFormat = errors.New("不支持的图片格式")
+	// ErrTooManyPixels 图片像素过多
+	ErrTooManyPixels = errors.New("图片像素过多")
+)
+
+// Options 处理参数，宽高为 0 时按比例计算，都为 0 时保持原尺寸
+type Options struct {
+	Width   int
+	Height  int
+	Fit     string
+	Quality int // 只对 JPEG 有效，WebP 使用无损编码
+	Format  string
+	// 原图允许的最大像素数，0 表示不限制
+	MaxPixels int
+}
+
+// Process 解码图片，缩放后按指定格式编码，格式为空时保持原格式（GIF 输出为 PNG）
+func Process(r io.Reader, opts Options) ([]byte, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 解码前检查尺寸，避免超大图片占用过多内存
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if opts.MaxPixels > 0 && cfg.Width*cfg.Height > opts.MaxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Format == "" {
+		if opts.Format, err = DefaultFormat(bytes.NewReader(data)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, Resize(src, opts.Width, opts.Height, opts.Fit), opts.Format, opts.Quality); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), ContentType(opts.Format), nil
+}
+
+// DefaultFormat 读取图片头部判断格式，返回未指定格式时输出的格式，GIF 和 BMP 输出为 PNG
+func DefaultFormat(r io.Reader) (string, error) {
+	_, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	if format == FormatGIF || format == FormatBMP {
+		return FormatPNG, nil
+	}
+	return format, nil
+}
+
+// DefaultFormatOf 根据 MIME 类型返回未指定格式时输出的格式，与 DefaultFormat 的结果一致
+func DefaultFormatOf(contentType string) (string, error) {
+	switch contentType {
+	case "image/jpeg":
+		return FormatJPEG, nil
+	case "image/png", "image/gif", "image/bmp":
+		return FormatPNG, nil
+	case "image/webp":
+		return FormatWebP, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Resize 按缩放方式调整尺寸，不会放大图片
+func Resize(src image.Image, width, height int, fit string) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if (width <= 0 && height <= 0) || sw == 0 || sh == 0 {
+		return src
+	}
+
+	// 只指定一边或不裁剪时按比例完整缩放
+	if fit != FitCover || width <= 0 || height <= 0 {
+		scale := 1.0
+		if width > 0 {
+			scale = min(scale, float64(width)/float64(sw))
+		}
+		if height > 0 {
+			scale = min(scale, float64(height)/float64(sh))
+		}
+		if scale >= 1 {
+			return src
+		}
+		return scaleTo(src, b, max(1, int(float64(sw)*scale+0.5)), max(1, int(float64(sh)*scale+0.5)))
+	}
+
+	// 目标尺寸大于原图时按原图能提供的最大区域裁剪
+	if width > sw || height > sh {
+		ratio := min(float64(sw)/float64(width), float64(sh)/float64(height))
+		width, height = max(1, int(float64(width)*ratio)), max(1, int(float64(height)*ratio))
+	}
+
+	// 按目标宽高比从原图中间取出裁剪区域再缩放
+	cw, ch := sw, sw*height/width
+	if ch > sh {
+		cw, ch = sh*width/height, sh
+	}
+	x, y := b.Min.X+(sw-cw)/2, b.Min.Y+(sh-ch)/2
+	return scaleTo(src, image.Rect(x, y, x+cw, y+ch), width, height)
+}
+
+func scaleTo(src image.Image, sr image.Rectangle, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, sr, xdraw.Src, nil)
+	return dst
+}
+
+// Encode 按格式编码图片，JPEG 不支持透明，透明部分填充为白色
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG, "jpg":
+		if quality <= 0 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	case FormatGIF:
+		return gif.Encode(w, img, nil)
+	}
+	return ErrUnsupportedFormat
+}
+
+// ContentType 格式对应的 MIME 类型
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG, "jpg":
+		return "image/jpeg"
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	case FormatGIF:
+		return "image/gif"
+	case FormatSVG:
+		return "image/svg+xml"
+	case FormatBMP:
+		return "image/bmp"
+	case FormatAVIF:
+		return "image/avif"
+	case FormatHEIC:
+		return "image/heic"
+	}
+	return "application/octet-stream"
+}
+
+// flatten 将图片合成到白色背景上
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
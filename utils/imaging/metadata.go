@@ -0,0 +1,100 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+)
+
+// 计算主色和占位符前先缩小到该尺寸以内
+const analyzeSize = 64
+
+// Metadata 图片的基本信息
+type Metadata struct {
+	MimeType string
+	Width    int
+	Height   int
+	Size     int64
+	// 主色，格式为 #rrggbb
+	DominantColor string
+	// BlurHash 占位符
+	Placeholder string
+}
+
+// Analyze 读取图片信息，MIME 类型根据文件头判断，见 Sniff。
+// 无法解码的文件只返回 MIME 类型和大小，像素数超过 maxPixels 时不计算主色和占位符
+func Analyze(r io.Reader, maxPixels int) (*Metadata, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{
+		MimeType: http.DetectContentType(data),
+		Size:     int64(len(data)),
+	}
+	if format := Sniff(data); format != "" {
+		meta.MimeType = ContentType(format)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return meta, nil
+	}
+	meta.Width, meta.Height = cfg.Width, cfg.Height
+	if maxPixels > 0 && cfg.Width*cfg.Height > maxPixels {
+		return meta, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return meta, nil
+	}
+
+	small := Resize(img, analyzeSize, analyzeSize, FitContain)
+	meta.DominantColor = DominantColor(small)
+	meta.Placeholder = BlurHash(small, 4, 3)
+	return meta, nil
+}
+
+// DominantColor 将颜色量化后统计出现次数最多的颜色，返回该类颜色的平均值，忽略透明像素
+func DominantColor(img image.Image) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[int]*bucket)
+	var best *bucket
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			// 每个通道保留高 4 位
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			bk, ok := buckets[key]
+			if !ok {
+				bk = new(bucket)
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
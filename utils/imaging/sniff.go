@@ -0,0 +1,72 @@
+package imaging
+
+import (
+	"bytes"
+)
+
+// 其他可以识别的格式，FormatJPEG、FormatPNG、FormatWebP 见 imaging.go
+const (
+	FormatGIF = "gif"
+	FormatSVG = "svg"
+	FormatBMP = "bmp"
+	// AVIF 和 HEIC 只用于给出明确的拒绝原因，无法解码
+	FormatAVIF = "avif"
+	FormatHEIC = "heic"
+)
+
+// Sniff 根据文件头判断图片格式，不信任文件名和客户端提供的 Content-Type，无法识别时返回空字符串
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return FormatJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return FormatWebP
+	case bytes.HasPrefix(data, []byte("BM")) && len(data) >= 26:
+		return FormatBMP
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")):
+		switch string(data[8:12]) {
+		case "avif", "avis":
+			return FormatAVIF
+		case "heic", "heix", "heim", "heis", "mif1", "msf1":
+			return FormatHEIC
+		}
+	case isSVG(data):
+		return FormatSVG
+	}
+	return ""
+}
+
+// isSVG 跳过 BOM、XML 声明、注释和 DOCTYPE 后根元素为 svg
+func isSVG(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n")
+		switch {
+		case bytes.HasPrefix(data, []byte("<?")):
+			end := bytes.Index(data, []byte("?>"))
+			if end < 0 {
+				return false
+			}
+			data = data[end+2:]
+		case bytes.HasPrefix(data, []byte("<!--")):
+			end := bytes.Index(data, []byte("-->"))
+			if end < 0 {
+				return false
+			}
+			data = data[end+3:]
+		case bytes.HasPrefix(data, []byte("<!")):
+			end := bytes.IndexByte(data, '>')
+			if end < 0 {
+				return false
+			}
+			data = data[end+1:]
+		default:
+			return bytes.HasPrefix(data, []byte("<svg")) && len(data) > 4 &&
+				bytes.IndexByte([]byte(" \t\r\n>/"), data[4]) >= 0
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrInvalidSVG SVG 格式错误或包含 DOCTYPE
+	ErrInvalidSVG = errors.New("SVG 格式错误")
+)
+
+// 允许的元素，其他元素连同子元素一起删除，如 script、style、foreignObject、image、a
+var svgElements = toSet(
+	"svg", "g", "defs", "symbol", "use", "title", "desc", "switch",
+	"path", "rect", "circle", "ellipse", "line", "polyline", "polygon",
+	"text", "tspan", "textPath",
+	"linearGradient", "radialGradient", "stop", "clipPath", "mask", "pattern", "marker",
+	"filter", "feBlend", "feColorMatrix", "feComposite", "feDropShadow", "feFlood",
+	"feGaussianBlur", "feMerge", "feMergeNode", "feMorphology", "feOffset",
+)
+
+// 允许的属性，事件属性（on*）等其他属性全部删除
+var svgAttributes = toSet(
+	"id", "class", "style", "version", "xmlns", "xmlns:xlink", "xml:space",
+	"x", "y", "x1", "y1", "x2", "y2", "cx", "cy", "r", "rx", "ry", "fx", "fy", "dx", "dy",
+	"width", "height", "d", "points", "transform", "viewBox", "preserveAspectRatio",
+	"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width", "stroke-linecap", "stroke-linejoin",
+	"stroke-dasharray", "stroke-dashoffset", "stroke-miterlimit", "stroke-opacity",
+	"opacity", "color", "visibility", "display", "overflow",
+	"offset", "stop-color", "stop-opacity", "gradientUnits", "gradientTransform", "spreadMethod",
+	"clip-path", "clip-rule", "clipPathUnits", "mask", "maskUnits", "maskContentUnits",
+	"patternUnits", "patternContentUnits", "patternTransform",
+	"marker-start", "marker-mid", "marker-end", "markerWidth", "markerHeight", "markerUnits", "refX", "refY", "orient",
+	"font-family", "font-size", "font-weight", "font-style", "text-anchor", "dominant-baseline",
+	"letter-spacing", "word-spacing", "text-decoration", "rotate", "textLength", "lengthAdjust", "startOffset",
+	"filter", "filterUnits", "primitiveUnits", "in", "in2", "result", "stdDeviation", "mode", "type", "values",
+	"operator", "k1", "k2", "k3", "k4", "flood-color", "flood-opacity", "radius",
+	"href", "xlink:href",
+)
+
+// SanitizeSVG 按白名单重新生成 SVG，删除脚本、事件属性和外部引用，引用只能指向文档内部（#id）
+func SanitizeSVG(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+
+	var out bytes.Buffer
+	var stack []string
+	// 被删除的元素的层级，0 表示当前没有跳过
+	skip := 0
+
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidSVG
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 && name != "svg" {
+				return nil, ErrInvalidSVG
+			}
+			stack = append(stack, name)
+			if skip > 0 || !svgElements[name] {
+				skip++
+				continue
+			}
+
+			out.WriteString("<" + name)
+			for _, attr := range t.Attr {
+				attrName := qualifiedName(attr.Name)
+				if !svgAttributes[attrName] || !safeSVGValue(attrName, attr.Value) {
+					continue
+				}
+				out.WriteString(" " + attrName + `="`)
+				xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+		case xml.EndElement:
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				return nil, ErrInvalidSVG
+			}
+			stack = stack[:len(stack)-1]
+			if skip > 0 {
+				skip--
+				continue
+			}
+			out.WriteString("</" + name + ">")
+		case xml.CharData:
+			if skip == 0 && len(stack) > 0 {
+				xml.EscapeText(&out, t)
+			}
+		case xml.Directive:
+			// DOCTYPE 可以定义实体，直接拒绝
+			return nil, ErrInvalidSVG
+		}
+		// 注释和处理指令直接删除
+	}
+
+	if len(stack) != 0 || out.Len() == 0 {
+		return nil, ErrInvalidSVG
+	}
+	return out.Bytes(), nil
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// safeSVGValue 链接只能指向文档内部，样式和属性值中的 url() 同样只能引用 #id
+func safeSVGValue(name, value string) bool {
+	v := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	if name == "href" || name == "xlink:href" {
+		return strings.HasPrefix(v, "#")
+	}
+	if strings.Contains(v, "javascript:") || strings.Contains(v, "expression(") ||
+		strings.Contains(v, "@import") || strings.Contains(v, `\`) {
+		return false
+	}
+	for rest := v; ; {
+		i := strings.Index(rest, "url(")
+		if i < 0 {
+			return true
+		}
+		rest = strings.TrimLeft(rest[i+4:], `'"`)
+		if !strings.HasPrefix(rest, "#") {
+			return false
+		}
+	}
+}
+
+func toSet(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		// 输出中必须包含的片段
+		keep []string
+		// 输出中不能出现的片段
+		drop []string
+	}{
+		{
+			name: "plain shapes",
+			in:   `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><rect width="10" height="10" fill="red"/></svg>`,
+			keep: []string{`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">`, `<rect width="10" height="10" fill="red"></rect>`},
+		},
+		{
+			name: "script element",
+			in:   `<svg><script>alert(1)</script><circle r="1"/></svg>`,
+			keep: []string{`<circle r="1"></circle>`},
+			drop: []string{"script", "alert"},
+		},
+		{
+			name: "nested elements inside removed element",
+			in:   `<svg><foreignObject><div><rect width="1"/></div></foreignObject><g/></svg>`,
+			keep: []string{"<g></g>"},
+			drop: []string{"foreignObject", "div", "rect"},
+		},
+		{
+			name: "style element",
+			in:   `<svg><style>@import url(https://evil.example/x.css);</style></svg>`,
+			drop: []string{"style", "@import"},
+		},
+		{
+			name: "event attributes",
+			in:   `<svg onload="alert(1)"><rect onclick="alert(2)" onmouseover="alert(3)" width="1"/></svg>`,
+			keep: []string{`<rect width="1">`},
+			drop: []string{"onload", "onclick", "onmouseover", "alert"},
+		},
+		{
+			name: "javascript href",
+			in:   `<svg><use href="javascript:alert(1)"/><use xlink:href=" JavaScript:alert(2)"/></svg>`,
+			keep: []string{"<use></use>"},
+			drop: []string{"javascript", "JavaScript"},
+		},
+		{
+			name: "external href",
+			in:   `<svg><use href="https://evil.example/sprite.svg#icon"/><use href="//evil.example/a.svg"/></svg>`,
+			drop: []string{"evil.example"},
+		},
+		{
+			name: "internal href",
+			in:   `<svg><defs><path id="p" d="M0 0"/></defs><use href="#p"/><use xlink:href="#p"/></svg>`,
+			keep: []string{`<use href="#p">`, `<use xlink:href="#p">`},
+		},
+		{
+			name: "external url in fill and style",
+			in:   `<svg><rect fill="url(https://evil.example/a.svg#g)" style="fill: url( 'http://evil.example/b' )" stroke="url(#g)"/></svg>`,
+			keep: []string{`stroke="url(#g)"`},
+			drop: []string{"evil.example"},
+		},
+		{
+			name: "javascript and expression in style",
+			in:   `<svg><rect style="background:javascript:alert(1)"/><rect style="width:expression(alert(2))"/><rect style="x:\75rl(a)"/></svg>`,
+			drop: []string{"style", "alert"},
+		},
+		{
+			name: "comments and processing instructions",
+			in:   `<?xml version="1.0"?><svg><!-- secret --><?php echo 1 ?><g/></svg>`,
+			keep: []string{"<svg><g></g></svg>"},
+			drop: []string{"secret", "php"},
+		},
+		{
+			name: "escaped text",
+			in:   `<svg><text>a &lt;b&gt; &amp; c</text></svg>`,
+			keep: []string{"<text>a &lt;b&gt; &amp; c</text>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeSVG([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("SanitizeSVG error: %v", err)
+			}
+			for _, s := range tt.keep {
+				if !strings.Contains(string(got), s) {
+					t.Errorf("SanitizeSVG = %s, want %q", got, s)
+				}
+			}
+			for _, s := range tt.drop {
+				if strings.Contains(string(got), s) {
+					t.Errorf("SanitizeSVG = %s, must not contain %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeSVGInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"doctype entity", `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><svg><text>&xxe;</text></svg>`},
+		{"doctype billion laughs", `<!DOCTYPE svg [<!ENTITY a "aaaa"><!ENTITY b "&a;&a;&a;&a;">]><svg>&b;</svg>`},
+		{"plain doctype", `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd"><svg/>`},
+		{"undefined entity", `<svg><text>&xxe;</text></svg>`},
+		{"root is not svg", `<html><svg/></html>`},
+		{"unclosed element", `<svg><g></svg>`},
+		{"unclosed root", `<svg>`},
+		{"empty", ``},
+		{"not xml", `GIF89a`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeSVG([]byte(tt.in))
+			if !errors.Is(err, ErrInvalidSVG) {
+				t.Errorf("SanitizeSVG = %s, %v, want ErrInvalidSVG", got, err)
+			}
+		})
+	}
+}
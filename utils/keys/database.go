@@ -0,0 +1,59 @@
+package keys
+
+import (
+	"cms/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type databaseStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseStore 创建基于数据库的密钥存储，适合多实例部署
+func NewDatabaseStore(db *gorm.DB) Store {
+	return &databaseStore{db: db}
+}
+
+func (s *databaseStore) Load() ([]*Key, error) {
+	var rows []*models.SigningKey
+	if err := s.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(rows))
+	for _, row := range rows {
+		private, _, err := DecodePEM([]byte(row.PrivateKey))
+		if err != nil {
+			return nil, err
+		}
+
+		key := &Key{ID: row.ID, Private: private, CreatedAt: time.Time(row.CreatedAt)}
+		if row.RetiredAt != nil {
+			retired := time.Time(*row.RetiredAt)
+			key.RetiredAt = &retired
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *databaseStore) Save(key *Key) error {
+	data, err := EncodePEM(key.Private, nil)
+	if err != nil {
+		return err
+	}
+
+	row := &models.SigningKey{ID: key.ID, PrivateKey: string(data)}
+	row.CreatedAt = models.CustomTime(key.CreatedAt)
+	if key.RetiredAt != nil {
+		retired := models.CustomTime(*key.RetiredAt)
+		row.RetiredAt = &retired
+	}
+	return s.db.Save(row).Error
+}
+
+func (s *databaseStore) Delete(id string) error {
+	return s.db.Where("id = ?", id).Delete(&models.SigningKey{}).Error
+}
@@ -0,0 +1,111 @@
+package keys
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PEM 头中保存的密钥元数据
+const (
+	headerCreated = "Created"
+	headerRetired = "Retired"
+)
+
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore 创建基于 PEM 文件的密钥存储，每个密钥保存为 <kid>.pem。
+// 也可以手动放入 PKCS#1 或 PKCS#8 格式的私钥文件，kid 由公钥计算
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (s *fileStore) Load() ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		private, headers, err := DecodePEM(data)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &Key{ID: Thumbprint(&private.PublicKey), Private: private}
+		if created, err := time.Parse(time.RFC3339, headers[headerCreated]); err == nil {
+			key.CreatedAt = created
+		} else if info, err := os.Stat(path); err == nil {
+			key.CreatedAt = info.ModTime()
+		}
+		if retired, err := time.Parse(time.RFC3339, headers[headerRetired]); err == nil {
+			key.RetiredAt = &retired
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *fileStore) Save(key *Key) error {
+	headers := map[string]string{headerCreated: key.CreatedAt.Format(time.RFC3339)}
+	if key.RetiredAt != nil {
+		headers[headerRetired] = key.RetiredAt.Format(time.RFC3339)
+	}
+
+	data, err := EncodePEM(key.Private, headers)
+	if err != nil {
+		return err
+	}
+
+	path, err := s.find(key.ID)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = filepath.Join(s.dir, key.ID+".pem")
+	}
+
+	// 先写临时文件再重命名，避免其他实例读到不完整的文件
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *fileStore) Delete(id string) error {
+	path, err := s.find(id)
+	if err != nil || path == "" {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// find 查找公钥指纹为 id 的文件，手动放入的文件名不一定是 kid
+func (s *fileStore) find(id string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return "", err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if private, _, err := DecodePEM(data); err == nil && Thumbprint(&private.PublicKey) == id {
+			return path, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,39 @@
+package keys
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+)
+
+type (
+	// JWKS JSON Web Key Set
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+
+	JWK struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+)
+
+// NewJWKS 将公钥转换为 JWKS
+func NewJWKS(alg string, keys []*Key) *JWKS {
+	jwks := &JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		pub := key.Public().(*rsa.PublicKey)
+		jwks.Keys = append(jwks.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: alg,
+			Kid: key.ID,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   encodeExponent(pub.E),
+		})
+	}
+	return jwks
+}
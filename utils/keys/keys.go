@@ -0,0 +1,110 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// 支持的密钥存储
+const (
+	StoreFile     = "file"
+	StoreDatabase = "database"
+)
+
+var (
+	// ErrUnknownStore 不支持的密钥存储
+	ErrUnknownStore = errors.New("不支持的密钥存储")
+	// ErrInvalidKey 密钥格式错误
+	ErrInvalidKey = errors.New("密钥格式错误，需要 RSA 私钥")
+)
+
+type (
+	// Key 一个 RSA 签名密钥
+	Key struct {
+		ID        string
+		Private   *rsa.PrivateKey
+		CreatedAt time.Time
+		// 轮换下线时间，为空表示当前可用于签名
+		RetiredAt *time.Time
+	}
+
+	// Store 密钥的持久化存储
+	Store interface {
+		Load() ([]*Key, error)
+		Save(key *Key) error
+		Delete(id string) error
+	}
+)
+
+// Generate 生成新的 RSA 密钥，kid 使用公钥的 RFC 7638 指纹
+func Generate(bits int) (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: Thumbprint(&private.PublicKey), Private: private, CreatedAt: time.Now()}, nil
+}
+
+// Thumbprint 计算 RSA 公钥的 JWK 指纹
+func Thumbprint(pub *rsa.PublicKey) string {
+	e, n := encodeExponent(pub.E), base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+	sum := sha256.Sum256(fmt.Appendf(nil, `{"e":"%s","kty":"RSA","n":"%s"}`, e, n))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Public 返回公钥
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// Active 判断密钥是否可用于签名
+func (k *Key) Active() bool {
+	return k.RetiredAt == nil
+}
+
+// EncodePEM 以 PKCS#8 PEM 格式输出私钥
+func EncodePEM(private *rsa.PrivateKey, headers map[string]string) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: headers, Bytes: der}), nil
+}
+
+// DecodePEM 解析 PKCS#8 或 PKCS#1 格式的 RSA 私钥
+func DecodePEM(data []byte) (*rsa.PrivateKey, map[string]string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, ErrInvalidKey
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		return private, block.Headers, err
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		private, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, ErrInvalidKey
+		}
+		return private, block.Headers, nil
+	}
+	return nil, nil, ErrInvalidKey
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
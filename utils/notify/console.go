@@ -0,0 +1,15 @@
+package notify
+
+import "github.com/gofiber/fiber/v2/log"
+
+// consoleNotifier 开发环境使用，通知内容输出到日志
+type consoleNotifier struct{}
+
+func NewConsoleNotifier() Notifier {
+	return &consoleNotifier{}
+}
+
+func (n *consoleNotifier) Send(to Recipient, msg Message) error {
+	log.Infof("[通知] 发送给 %s（手机号: %s，邮箱: %s）\n主题: %s\n%s", to.Name, to.Phone, to.Email, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileNotifier 开发和测试环境使用，每条通知以一行 JSON 追加到文件
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Send(to Recipient, msg Message) error {
+	line, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		To   Recipient `json:"to"`
+		Message
+	}{time.Now(), to, msg})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,53 @@
+// Package notify 向用户发送短信、邮件等通知
+package notify
+
+import (
+	"cms/config"
+	"errors"
+)
+
+// 通知驱动
+const (
+	DriverConsole = "console"
+	DriverFile    = "file"
+	DriverSMTP    = "smtp"
+)
+
+var (
+	// ErrUnknownDriver 不支持的通知驱动
+	ErrUnknownDriver = errors.New("不支持的通知驱动")
+	// ErrNoRecipient 用户没有可用的联系方式
+	ErrNoRecipient = errors.New("用户没有可用的联系方式")
+)
+
+type (
+	// Recipient 接收人，驱动根据自身类型选择手机号或邮箱
+	Recipient struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone,omitempty"`
+		Email string `json:"email,omitempty"`
+	}
+
+	// Message 通知内容，短信只使用 Body
+	Message struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+
+	Notifier interface {
+		Send(to Recipient, msg Message) error
+	}
+)
+
+func New(cfg *config.NotifyConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "", DriverConsole:
+		return NewConsoleNotifier(), nil
+	case DriverFile:
+		return NewFileNotifier(cfg.FilePath), nil
+	case DriverSMTP:
+		return NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.SMTPEncryption)
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
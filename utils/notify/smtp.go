@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP 加密方式
+const (
+	EncryptionStartTLS = "starttls"
+	EncryptionTLS      = "tls"
+	EncryptionNone     = "none"
+)
+
+// smtpNotifier 通过 SMTP 发送邮件，只使用接收人的邮箱
+type smtpNotifier struct {
+	host       string
+	addr       string
+	username   string
+	password   string
+	from       *mail.Address
+	encryption string
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from, encryption string) (Notifier, error) {
+	if host == "" {
+		return nil, errors.New("未配置 SMTP_HOST")
+	}
+
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP_FROM 格式错误: %w", err)
+	}
+
+	switch encryption {
+	case EncryptionStartTLS, EncryptionTLS, EncryptionNone:
+	default:
+		return nil, fmt.Errorf("不支持的 SMTP 加密方式: %s", encryption)
+	}
+
+	return &smtpNotifier{
+		host:       host,
+		addr:       net.JoinHostPort(host, strconv.Itoa(port)),
+		username:   username,
+		password:   password,
+		from:       address,
+		encryption: encryption,
+	}, nil
+}
+
+func (n *smtpNotifier) Send(to Recipient, msg Message) error {
+	if to.Email == "" {
+		return ErrNoRecipient
+	}
+	rcpt := &mail.Address{Name: to.Name, Address: to.Email}
+
+	client, err := n.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.build(rcpt, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (n *smtpNotifier) dial() (*smtp.Client, error) {
+	tlsConfig := &tls.Config{ServerName: n.host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	if n.encryption == EncryptionTLS {
+		conn, err := tls.DialWithDialer(dialer, "tcp", n.addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, n.host)
+	}
+
+	conn, err := dialer.Dial("tcp", n.addr)
+	if err != nil {
+		return nil, err
+	}
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if n.encryption == EncryptionStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// build 生成 UTF-8 编码的纯文本邮件
+func (n *smtpNotifier) build(to *mail.Address, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,347 @@
+// Package oidc 实现 OpenID Connect 授权码模式（PKCE）客户端
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrDiscovery 读取身份提供方配置失败
+	ErrDiscovery = errors.New("读取身份提供方配置失败")
+	// ErrExchange 授权码换取令牌失败
+	ErrExchange = errors.New("授权码换取令牌失败")
+	// ErrIDTokenInvalid ID 令牌无效
+	ErrIDTokenInvalid = errors.New("ID 令牌无效")
+	// ErrUserInfo 获取用户信息失败
+	ErrUserInfo = errors.New("获取用户信息失败")
+)
+
+// 重新获取公钥的最小间隔，避免伪造的 kid 导致频繁请求身份提供方
+const jwksRefreshInterval = 30 * time.Second
+
+type (
+	// Config 客户端配置
+	Config struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+	}
+
+	// Discovery 身份提供方公开的配置
+	Discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+
+	// Token 令牌端点的响应
+	Token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		IDToken     string `json:"id_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	// Claims ID 令牌和用户信息中的声明
+	Claims map[string]any
+
+	// Provider 身份提供方客户端，首次使用时读取配置，失败后下次请求重试
+	Provider struct {
+		cfg    Config
+		client *http.Client
+
+		mu         sync.Mutex
+		discovery  *Discovery
+		keys       map[string]any
+		keysLoaded time.Time
+	}
+)
+
+func NewProvider(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// CodeChallenge 计算 PKCE S256 挑战值
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL 生成跳转到身份提供方的授权地址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.Discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange 使用授权码和 PKCE 校验码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	d, err := p.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	// 公开客户端没有密钥，只提交 client_id
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// RFC 6749 2.3.1 要求先进行表单编码
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	token := new(Token)
+	if err := p.do(req, token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: 响应中没有 id_token", ErrExchange)
+	}
+	return token, nil
+}
+
+// VerifyIDToken 校验 ID 令牌的签名、签发方、受众、有效期和 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	d, err := p.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce 不匹配", ErrIDTokenInvalid)
+	}
+	// 有多个受众时 azp 必须是当前客户端
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp 不匹配", ErrIDTokenInvalid)
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, fmt.Errorf("%w: 缺少 sub", ErrIDTokenInvalid)
+	}
+
+	return Claims(claims), nil
+}
+
+// UserInfo 获取用户信息，身份提供方没有用户信息端点时返回 nil
+func (p *Provider) UserInfo(ctx context.Context, accessToken string) (Claims, error) {
+	d, err := p.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserinfoEndpoint == "" || accessToken == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	claims := Claims{}
+	if err := p.do(req, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+	return claims, nil
+}
+
+// Discovery 读取身份提供方配置，成功后缓存
+func (p *Provider) Discovery(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(Discovery)
+	if err := p.do(req, d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	// 防止配置被替换为其他签发方
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer 不匹配 %q", ErrDiscovery, d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: 缺少必要的端点", ErrDiscovery)
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+// key 查找验证签名的公钥，找不到时重新获取公钥集合，身份提供方轮换密钥后无需重启
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysLoaded) < jwksRefreshInterval {
+		return nil, fmt.Errorf("未知的密钥 %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	set := new(jwkSet)
+	if err := p.do(req, set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	p.keys, p.keysLoaded = keys, time.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的密钥 %q", kid)
+}
+
+// lookup 令牌没有 kid 且只有一个公钥时使用该公钥
+func (p *Provider) lookup(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) do(req *http.Request, v any) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s 返回 %d: %s", req.Method, req.URL.Redacted(), res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// String 读取字符串声明
+func (c Claims) String(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+// Bool 读取布尔声明，部分身份提供方以字符串返回
+func (c Claims) Bool(name string) bool {
+	switch v := c[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// Strings 读取字符串数组声明，单个字符串视为只有一个元素
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package passwd 密码哈希与密码策略
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 哈希算法
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	// ErrUnknownAlgorithm 不支持的哈希算法
+	ErrUnknownAlgorithm = errors.New("不支持的密码哈希算法")
+	// ErrInvalidHash 哈希格式错误
+	ErrInvalidHash = errors.New("密码哈希格式错误")
+)
+
+// Argon2Params argon2id 参数，Memory 单位为 KiB
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Hasher 使用配置的算法生成哈希，校验时根据哈希前缀识别算法，
+// 因此切换算法或调整参数后旧密码仍然可以登录
+type Hasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+func NewHasher(algorithm string, bcryptCost int, argon2Params Argon2Params) (*Hasher, error) {
+	switch algorithm {
+	case AlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost 需要在 %d 到 %d 之间", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if argon2Params.Memory == 0 || argon2Params.Iterations == 0 || argon2Params.Parallelism == 0 {
+			return nil, errors.New("argon2id 参数不能为 0")
+		}
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+
+	if argon2Params.SaltLength == 0 {
+		argon2Params.SaltLength = 16
+	}
+	if argon2Params.KeyLength == 0 {
+		argon2Params.KeyLength = 32
+	}
+
+	return &Hasher{Algorithm: algorithm, BcryptCost: bcryptCost, Argon2: argon2Params}, nil
+}
+
+// Hash 使用当前配置生成密码哈希
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.Algorithm == AlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 校验密码是否与哈希匹配
+func (h *Hasher) Verify(hash, password string) (bool, error) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NeedsRehash 哈希使用的算法或参数与当前配置不同时需要重新生成
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if h.Algorithm == AlgorithmArgon2id {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		return params.Memory != h.Argon2.Memory ||
+			params.Iterations != h.Argon2.Iterations ||
+			params.Parallelism != h.Argon2.Parallelism ||
+			uint32(len(salt)) != h.Argon2.SaltLength ||
+			uint32(len(key)) != h.Argon2.KeyLength
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.BcryptCost
+}
+
+// hashArgon2id 生成 PHC 格式的哈希：$argon2id$v=19$m=65536,t=3,p=2$salt$key
+func (h *Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, h.Argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Argon2.Iterations, h.Argon2.Memory, h.Argon2.Parallelism, h.Argon2.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Argon2.Memory, h.Argon2.Iterations, h.Argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func decodeArgon2id(hash string) (*Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	params := new(Argon2Params)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+
+	return params, salt, key, nil
+}
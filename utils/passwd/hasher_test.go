@@ -0,0 +1,156 @@
+package passwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 测试使用较小的参数，避免拖慢测试
+var testArgon2 = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, algorithm string, bcryptCost int, params Argon2Params) *Hasher {
+	t.Helper()
+	h, err := NewHasher(algorithm, bcryptCost, params)
+	if err != nil {
+		t.Fatalf("NewHasher(%s) error: %v", algorithm, err)
+	}
+	return h
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		cost      int
+		params    Argon2Params
+		wantErr   bool
+	}{
+		{"bcrypt", AlgorithmBcrypt, 10, Argon2Params{}, false},
+		{"bcrypt cost too low", AlgorithmBcrypt, 3, Argon2Params{}, true},
+		{"bcrypt cost too high", AlgorithmBcrypt, 32, Argon2Params{}, true},
+		{"argon2id", AlgorithmArgon2id, 0, testArgon2, false},
+		{"argon2id zero memory", AlgorithmArgon2id, 0, Argon2Params{Iterations: 1, Parallelism: 1}, true},
+		{"unknown", "md5", 10, testArgon2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHasher(tt.algorithm, tt.cost, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHasher error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	h := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2)
+	if h.Argon2.SaltLength != 16 || h.Argon2.KeyLength != 32 {
+		t.Errorf("default salt/key length = %d/%d, want 16/32", h.Argon2.SaltLength, h.Argon2.KeyLength)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	bcryptHasher := newTestHasher(t, AlgorithmBcrypt, 4, Argon2Params{})
+	argon2Hasher := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2)
+
+	bcryptHash, err := bcryptHasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := argon2Hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected argon2id hash format: %s", argon2Hash)
+	}
+
+	// 修改最后一个字符，格式仍然有效但密钥不同
+	tampered := argon2Hash[:len(argon2Hash)-1] + "A"
+	if strings.HasSuffix(argon2Hash, "A") {
+		tampered = argon2Hash[:len(argon2Hash)-1] + "B"
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+		wantErr  error
+	}{
+		// OpenBSD bcrypt 测试向量
+		{"bcrypt vector", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", "U*U", true, nil},
+		{"bcrypt", bcryptHash, "correct horse", true, nil},
+		{"bcrypt wrong password", bcryptHash, "wrong horse", false, nil},
+		{"argon2id", argon2Hash, "correct horse", true, nil},
+		{"argon2id wrong password", argon2Hash, "wrong horse", false, nil},
+		{"argon2id tampered key", tampered, "correct horse", false, nil},
+		{"argon2id wrong version", strings.Replace(argon2Hash, "v=19", "v=16", 1), "correct horse", false, ErrInvalidHash},
+		{"argon2id missing part", argon2Hash[:strings.LastIndex(argon2Hash, "$")], "correct horse", false, ErrInvalidHash},
+		{"argon2id bad params", strings.Replace(argon2Hash, "m=64", "m=x", 1), "correct horse", false, ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 校验时根据哈希识别算法，与当前配置无关
+			for _, h := range []*Hasher{bcryptHasher, argon2Hasher} {
+				got, err := h.Verify(tt.hash, tt.password)
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s Verify error = %v, want %v", h.Algorithm, err, tt.wantErr)
+				}
+				if tt.wantErr == nil && err != nil {
+					t.Errorf("%s Verify error = %v", h.Algorithm, err)
+				}
+				if got != tt.want {
+					t.Errorf("%s Verify = %v, want %v", h.Algorithm, got, tt.want)
+				}
+			}
+		})
+	}
+
+	if ok, err := bcryptHasher.Verify("not a hash", "correct horse"); ok || err == nil {
+		t.Errorf("Verify invalid bcrypt hash = (%v, %v), want (false, error)", ok, err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcrypt4 := newTestHasher(t, AlgorithmBcrypt, 4, Argon2Params{})
+	bcrypt5 := newTestHasher(t, AlgorithmBcrypt, 5, Argon2Params{})
+	argon2Small := newTestHasher(t, AlgorithmArgon2id, 0, testArgon2)
+	argon2Large := newTestHasher(t, AlgorithmArgon2id, 0, Argon2Params{Memory: 128, Iterations: 1, Parallelism: 1})
+	argon2LongKey := newTestHasher(t, AlgorithmArgon2id, 0, Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, KeyLength: 64})
+
+	hash := func(h *Hasher) string {
+		s, err := h.Hash("correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	bcryptHash := hash(bcrypt4)
+	argon2Hash := hash(argon2Small)
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt same cost", bcrypt4, bcryptHash, false},
+		{"bcrypt cost changed", bcrypt5, bcryptHash, true},
+		{"bcrypt to argon2id", argon2Small, bcryptHash, true},
+		{"argon2id same params", argon2Small, argon2Hash, false},
+		{"argon2id memory changed", argon2Large, argon2Hash, true},
+		{"argon2id key length changed", argon2LongKey, argon2Hash, true},
+		{"argon2id to bcrypt", bcrypt4, argon2Hash, true},
+		{"invalid hash", argon2Small, "invalid", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package passwd
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密码策略规则
+const (
+	RuleMinLength = "min_length"
+	RuleLower     = "lower"
+	RuleUpper     = "upper"
+	RuleLetter    = "letter"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleDenylist  = "denylist"
+	RuleUsername  = "username"
+	RuleHistory   = "history"
+)
+
+// 字符类型及其检查函数，用于 PASSWORD_CHARACTER_CLASSES
+var classes = map[string]struct {
+	name  string
+	match func(rune) bool
+}{
+	RuleLower:  {"小写字母", unicode.IsLower},
+	RuleUpper:  {"大写字母", unicode.IsUpper},
+	RuleLetter: {"字母", unicode.IsLetter},
+	RuleDigit:  {"数字", unicode.IsDigit},
+	RuleSymbol: {"特殊字符", func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) }},
+}
+
+// commonPasswords 内置的常见弱密码
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Violation 未通过的规则
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError 密码不符合策略，Violations 列出全部未通过的规则
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "；")
+}
+
+// Details 未通过的规则，接口响应中按规则展示
+func (e *PolicyError) Details() any {
+	return e.Violations
+}
+
+// Policy 密码策略
+type Policy struct {
+	MinLength int
+	Classes   []string
+	denylist  map[string]struct{}
+}
+
+// NewPolicy 创建密码策略，denylistPath 为额外的弱密码列表文件，每行一个
+func NewPolicy(minLength int, characterClasses []string, denylistPath string) (*Policy, error) {
+	p := &Policy{MinLength: minLength, denylist: map[string]struct{}{}}
+
+	for _, class := range characterClasses {
+		class = strings.TrimSpace(class)
+		if class == "" {
+			continue
+		}
+		if _, ok := classes[class]; !ok {
+			return nil, fmt.Errorf("不支持的字符类型: %s", class)
+		}
+		p.Classes = append(p.Classes, class)
+	}
+
+	if err := p.addDenylist(strings.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+	if denylistPath != "" {
+		f, err := os.Open(denylistPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := p.addDenylist(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Check 返回密码未通过的全部规则，username 不为空时密码不能包含用户名
+func (p *Policy) Check(password, username string) []Violation {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("密码至少需要 %d 个字符", p.MinLength)})
+	}
+
+	for _, class := range p.Classes {
+		if !strings.ContainsFunc(password, classes[class].match) {
+			violations = append(violations, Violation{class, "密码需要包含" + classes[class].name})
+		}
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := p.denylist[lower]; ok {
+		violations = append(violations, Violation{RuleDenylist, "密码过于常见"})
+	}
+
+	if len(username) >= 3 && strings.Contains(lower, strings.ToLower(username)) {
+		violations = append(violations, Violation{RuleUsername, "密码不能包含用户名"})
+	}
+
+	return violations
+}
+
+func (p *Policy) addDenylist(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.denylist[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
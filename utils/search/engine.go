@@ -0,0 +1,89 @@
+package search
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DriverMemory 内嵌的内存倒排索引
+	DriverMemory = "memory"
+	// DriverMySQL MySQL FULLTEXT 索引
+	DriverMySQL = "mysql"
+)
+
+var (
+	// ErrUnknownDriver 未知的搜索驱动
+	ErrUnknownDriver = errors.New("未知的搜索驱动")
+)
+
+type (
+	// Engine 搜索引擎
+	Engine interface {
+		// Index 添加或更新文档
+		Index(doc *Document) error
+		// Delete 删除文档
+		Delete(id uuid.UUID) error
+		// Search 检索，结果按相关度排序
+		Search(query *Query) (*Result, error)
+		// Reset 清空索引，重建索引前调用
+		Reset() error
+	}
+
+	// Document 被索引的文章
+	Document struct {
+		ID          uuid.UUID
+		Title       string
+		Description string
+		Content     string
+		Tags        []string
+		TagIDs      []uuid.UUID
+		CategoryID  uuid.UUID
+		Status      uint8
+		// 是否为公开状态（已发布或定时发布）
+		Public      bool
+		PublishAt   *time.Time
+		UnpublishAt *time.Time
+		CreatedAt   time.Time
+	}
+
+	// Query 检索条件
+	Query struct {
+		Text       string
+		CategoryID *uuid.UUID
+		TagID      *uuid.UUID
+		Status     *uint8
+		// 不为空时只返回该时间点公开的文章
+		VisibleAt *time.Time
+		Offset    int
+		Limit     int
+	}
+
+	// Hit 命中的文档
+	Hit struct {
+		ID    uuid.UUID
+		Score float64
+	}
+
+	// Result 检索结果
+	Result struct {
+		Total int64
+		Hits  []Hit
+	}
+)
+
+// visible 判断文档在指定时间是否公开
+func (d *Document) visible(now time.Time) bool {
+	if !d.Public {
+		return false
+	}
+	if d.PublishAt != nil && d.PublishAt.After(now) {
+		return false
+	}
+	if d.UnpublishAt != nil && !d.UnpublishAt.After(now) {
+		return false
+	}
+	return true
+}
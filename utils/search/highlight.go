@@ -0,0 +1,100 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+	spaceRegexp   = regexp.MustCompile(`\s+`)
+)
+
+// StripHTML 去除 HTML 标签并合并空白，得到纯文本
+func StripHTML(s string) string {
+	s = htmlTagRegexp.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(spaceRegexp.ReplaceAllString(s, " "))
+}
+
+// Highlight 转义文本并用 <em> 标记命中的检索词
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return render(runes, mark(runes, terms), 0, len(runes))
+}
+
+// Snippet 截取第一个命中位置附近长度为 length 个字符的片段并高亮
+func Snippet(text string, terms []string, length int) string {
+	runes := []rune(text)
+	marks := mark(runes, terms)
+
+	first := 0
+	for i, marked := range marks {
+		if marked {
+			first = i
+			break
+		}
+	}
+
+	start := max(first-length/4, 0)
+	end := min(start+length, len(runes))
+	start = max(end-length, 0)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	b.WriteString(render(runes, marks, start, end))
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// mark 标记命中检索词的字符，匹配时忽略大小写
+func mark(runes []rune, terms []string) []bool {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marks := make([]bool, len(runes))
+	for _, term := range terms {
+		needle := []rune(term)
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) == term {
+				for j := i; j < i+len(needle); j++ {
+					marks[j] = true
+				}
+			}
+		}
+	}
+	return marks
+}
+
+// render 输出 [start, end) 范围内转义后的文本，连续命中的字符包裹在 <em> 中
+func render(runes []rune, marks []bool, start, end int) string {
+	var b strings.Builder
+	for i := start; i < end; {
+		j := i
+		for j < end && marks[j] == marks[i] {
+			j++
+		}
+
+		segment := html.EscapeString(string(runes[i:j]))
+		if marks[i] {
+			b.WriteString("<em>")
+			b.WriteString(segment)
+			b.WriteString("</em>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package search
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"<p>Hello <b>World</b></p>", "Hello World"},
+		{"<p>a</p>\n\n<p>b</p>", "a b"},
+		{"&lt;script&gt; &amp; 中文", "<script> & 中文"},
+		{"plain", "plain"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := StripHTML(tt.in); got != tt.want {
+			t.Errorf("StripHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"no terms", "Hello World", nil, "Hello World"},
+		{"case insensitive", "Hello World", []string{"world"}, "Hello <em>World</em>"},
+		{"every occurrence", "go and Go", []string{"go"}, "<em>go</em> and <em>Go</em>"},
+		{"adjacent terms merged", "内容管理系统", []string{"内容", "管理"}, "<em>内容管理</em>系统"},
+		{"overlapping terms merged", "内容管理", []string{"内容", "容管"}, "<em>内容管</em>理"},
+		{"escape text", "<b>a&b</b>", []string{"a"}, "&lt;b&gt;<em>a</em>&amp;b&lt;/b&gt;"},
+		{"escape inside match", "x<y", []string{"x<y"}, "<em>x&lt;y</em>"},
+		{"empty term ignored", "abc", []string{""}, "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.terms); got != tt.want {
+				t.Errorf("Highlight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		terms  []string
+		length int
+		want   string
+	}{
+		{"shorter than length", "Hello World", []string{"world"}, 20, "Hello <em>World</em>"},
+		{"no match keeps start", "abcdefghij", []string{"z"}, 4, "abcd..."},
+		{"match in middle", "abcdefghijklmnop", []string{"ij"}, 8, "...gh<em>ij</em>klmn..."},
+		{"match at end", "abcdefghij", []string{"ij"}, 4, "...gh<em>ij</em>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.text, tt.terms, tt.length); got != tt.want {
+				t.Errorf("Snippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// 参与检索的字段
+const (
+	fieldTitle = iota
+	fieldTags
+	fieldDescription
+	fieldContent
+	fieldCount
+)
+
+// 各字段权重以及 BM25 参数
+var fieldWeights = [fieldCount]float64{3, 2.5, 1.5, 1}
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type (
+	memoryEngine struct {
+		mu        sync.RWMutex
+		tokenizer *Tokenizer
+		docs      map[uuid.UUID]*memoryDoc
+		postings  map[string]map[uuid.UUID]*[fieldCount]int
+		totalLen  [fieldCount]int
+	}
+
+	memoryDoc struct {
+		meta   Document
+		length [fieldCount]int
+		terms  []string
+	}
+)
+
+// NewMemoryEngine 创建内嵌的内存倒排索引，使用 BM25F 计算相关度
+func NewMemoryEngine(tokenizer *Tokenizer) Engine {
+	return &memoryEngine{
+		tokenizer: tokenizer,
+		docs:      make(map[uuid.UUID]*memoryDoc),
+		postings:  make(map[string]map[uuid.UUID]*[fieldCount]int),
+	}
+}
+
+func (e *memoryEngine) Index(doc *Document) error {
+	fields := [fieldCount]string{
+		fieldTitle:       doc.Title,
+		fieldTags:        strings.Join(doc.Tags, " "),
+		fieldDescription: doc.Description,
+		fieldContent:     StripHTML(doc.Content),
+	}
+
+	entry := &memoryDoc{meta: *doc}
+	// 正文不需要常驻内存
+	entry.meta.Content = ""
+
+	freqs := make(map[string]*[fieldCount]int)
+	for field, text := range fields {
+		tokens := e.tokenizer.Tokenize(text)
+		entry.length[field] = len(tokens)
+		for _, token := range tokens {
+			if freqs[token] == nil {
+				freqs[token] = new([fieldCount]int)
+			}
+			freqs[token][field]++
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(doc.ID)
+
+	for term, freq := range freqs {
+		if e.postings[term] == nil {
+			e.postings[term] = make(map[uuid.UUID]*[fieldCount]int)
+		}
+		e.postings[term][doc.ID] = freq
+		entry.terms = append(entry.terms, term)
+	}
+	for field := range fieldCount {
+		e.totalLen[field] += entry.length[field]
+	}
+	e.docs[doc.ID] = entry
+
+	return nil
+}
+
+func (e *memoryEngine) Delete(id uuid.UUID) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(id)
+	return nil
+}
+
+func (e *memoryEngine) Reset() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.docs = make(map[uuid.UUID]*memoryDoc)
+	e.postings = make(map[string]map[uuid.UUID]*[fieldCount]int)
+	e.totalLen = [fieldCount]int{}
+	return nil
+}
+
+func (e *memoryEngine) Search(query *Query) (*Result, error) {
+	terms := e.tokenizer.Terms(query.Text)
+	if len(terms) == 0 {
+		return &Result{Hits: []Hit{}}, nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	// 优先要求包含全部检索词，没有结果时退化为包含任意检索词
+	hits := e.match(query, terms, true)
+	if len(hits) == 0 && len(terms) > 1 {
+		hits = e.match(query, terms, false)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return e.docs[hits[i].ID].meta.CreatedAt.After(e.docs[hits[j].ID].meta.CreatedAt)
+	})
+
+	total := int64(len(hits))
+	start := min(max(query.Offset, 0), len(hits))
+	end := len(hits)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(hits))
+	}
+
+	return &Result{Total: total, Hits: hits[start:end]}, nil
+}
+
+// match 计算满足条件的文档及其得分
+func (e *memoryEngine) match(query *Query, terms []string, all bool) []Hit {
+	n := float64(len(e.docs))
+	var avgLen [fieldCount]float64
+	for field := range fieldCount {
+		if n > 0 {
+			avgLen[field] = math.Max(float64(e.totalLen[field])/n, 1)
+		}
+	}
+
+	scores := make(map[uuid.UUID]float64)
+	matched := make(map[uuid.UUID]int)
+	for _, term := range terms {
+		postings := e.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, freq := range postings {
+			doc := e.docs[id]
+			if !e.accept(doc, query) {
+				continue
+			}
+
+			var tf float64
+			for field := range fieldCount {
+				if freq[field] == 0 {
+					continue
+				}
+				norm := 1 - bm25B + bm25B*float64(doc.length[field])/avgLen[field]
+				tf += fieldWeights[field] * float64(freq[field]) / norm
+			}
+
+			scores[id] += idf * tf / (bm25K1 + tf)
+			matched[id]++
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		if all && matched[id] < len(terms) {
+			continue
+		}
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	return hits
+}
+
+// accept 检查文档是否满足过滤条件
+func (e *memoryEngine) accept(doc *memoryDoc, query *Query) bool {
+	if query.CategoryID != nil && doc.meta.CategoryID != *query.CategoryID {
+		return false
+	}
+	if query.TagID != nil && !slices.Contains(doc.meta.TagIDs, *query.TagID) {
+		return false
+	}
+	if query.Status != nil && doc.meta.Status != *query.Status {
+		return false
+	}
+	if query.VisibleAt != nil && !doc.meta.visible(*query.VisibleAt) {
+		return false
+	}
+	return true
+}
+
+// remove 删除文档，调用方需持有写锁
+func (e *memoryEngine) remove(id uuid.UUID) {
+	doc, ok := e.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range doc.terms {
+		delete(e.postings[term], id)
+		if len(e.postings[term]) == 0 {
+			delete(e.postings, term)
+		}
+	}
+	for field := range fieldCount {
+		e.totalLen[field] -= doc.length[field]
+	}
+	delete(e.docs, id)
+}
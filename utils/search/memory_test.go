@@ -0,0 +1,245 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	testCategory = uuid.MustParse("00000000-0000-0000-0000-00000000c001")
+	testTag      = uuid.MustParse("00000000-0000-0000-0000-00000000a001")
+)
+
+func newTestEngine(t *testing.T, docs ...*Document) Engine {
+	t.Helper()
+	engine := NewMemoryEngine(newTestTokenizer(t))
+	for _, doc := range docs {
+		if err := engine.Index(doc); err != nil {
+			t.Fatalf("Index error: %v", err)
+		}
+	}
+	return engine
+}
+
+func hitIDs(res *Result) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestMemoryEngineRanking(t *testing.T) {
+	// 标题权重最高，其次是标签、摘要和正文
+	title := &Document{ID: uuid.New(), Title: "Redis", Content: "其他内容"}
+	tags := &Document{ID: uuid.New(), Title: "其他", Tags: []string{"Redis"}}
+	content := &Document{ID: uuid.New(), Title: "其他", Content: "<p>使用 <b>Redis</b> 作为缓存</p>"}
+	unrelated := &Document{ID: uuid.New(), Title: "MySQL 索引", Content: "全文索引"}
+	engine := newTestEngine(t, content, tags, title, unrelated)
+
+	res, err := engine.Search(&Query{Text: "redis"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uuid.UUID{title.ID, tags.ID, content.ID}
+	got := hitIDs(res)
+	if res.Total != 3 || len(got) != 3 {
+		t.Fatalf("Search = %v (total %d), want %v", got, res.Total, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Search = %v, want %v", got, want)
+		}
+	}
+	for i := 1; i < len(res.Hits); i++ {
+		if res.Hits[i].Score > res.Hits[i-1].Score {
+			t.Errorf("hits not sorted by score: %v", res.Hits)
+		}
+	}
+}
+
+func TestMemoryEngineTermFrequency(t *testing.T) {
+	// 同样长度的正文中出现次数越多得分越高，较短的文档得分更高
+	once := &Document{ID: uuid.New(), Content: "go a b c d"}
+	twice := &Document{ID: uuid.New(), Content: "go go a b c"}
+	long := &Document{ID: uuid.New(), Content: "go a b c d e f g h i j k l m n o p"}
+	engine := newTestEngine(t, once, twice, long)
+
+	res, err := engine.Search(&Query{Text: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := hitIDs(res)
+	want := []uuid.UUID{twice.ID, once.ID, long.ID}
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("Search = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMemoryEngineMatchAll(t *testing.T) {
+	both := &Document{ID: uuid.New(), Title: "Go 搜索"}
+	goOnly := &Document{ID: uuid.New(), Title: "Go 语言"}
+	engine := newTestEngine(t, both, goOnly)
+
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		// 优先返回包含全部检索词的文档
+		{"all terms", "go 搜索", 1},
+		// 没有文档包含全部检索词时退化为任意匹配
+		{"any term", "go 缓存", 2},
+		{"chinese substring", "搜索", 1},
+		{"no match", "rust", 0},
+		{"empty query", " ", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := engine.Search(&Query{Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int(res.Total) != tt.want || len(res.Hits) != tt.want {
+				t.Errorf("Search(%q) = %d hits (total %d), want %d", tt.text, len(res.Hits), res.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryEngineFilters(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	later := future.Add(time.Minute)
+	published := uint8(1)
+
+	public := &Document{ID: uuid.New(), Title: "go", Public: true, Status: published, CategoryID: testCategory, TagIDs: []uuid.UUID{testTag}}
+	draft := &Document{ID: uuid.New(), Title: "go", CategoryID: testCategory}
+	scheduled := &Document{ID: uuid.New(), Title: "go", Public: true, Status: published, PublishAt: &future}
+	expired := &Document{ID: uuid.New(), Title: "go", Public: true, Status: published, UnpublishAt: &past}
+	engine := newTestEngine(t, public, draft, scheduled, expired)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []uuid.UUID
+	}{
+		{"category", Query{Text: "go", CategoryID: &testCategory}, []uuid.UUID{public.ID, draft.ID}},
+		{"tag", Query{Text: "go", TagID: &testTag}, []uuid.UUID{public.ID}},
+		{"status", Query{Text: "go", Status: &published}, []uuid.UUID{public.ID, scheduled.ID, expired.ID}},
+		{"visible", Query{Text: "go", VisibleAt: &now}, []uuid.UUID{public.ID}},
+		{"visible later", Query{Text: "go", VisibleAt: &later}, []uuid.UUID{public.ID, scheduled.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := engine.Search(&tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[uuid.UUID]bool)
+			for _, id := range hitIDs(res) {
+				got[id] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search = %v, want %v", hitIDs(res), tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("Search = %v, want %v", hitIDs(res), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryEnginePagination(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]*Document, 5)
+	for i := range docs {
+		// 得分相同时按创建时间倒序
+		docs[i] = &Document{ID: uuid.New(), Title: "go", CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+	}
+	engine := newTestEngine(t, docs...)
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []uuid.UUID
+	}{
+		{"first page", 0, 2, []uuid.UUID{docs[4].ID, docs[3].ID}},
+		{"last page", 4, 2, []uuid.UUID{docs[0].ID}},
+		{"out of range", 10, 2, []uuid.UUID{}},
+		{"no limit", 3, 0, []uuid.UUID{docs[1].ID, docs[0].ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := engine.Search(&Query{Text: "go", Offset: tt.offset, Limit: tt.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Total != 5 {
+				t.Errorf("Total = %d, want 5", res.Total)
+			}
+			got := hitIDs(res)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Search = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryEngineUpdateAndDelete(t *testing.T) {
+	doc := &Document{ID: uuid.New(), Title: "redis"}
+	engine := newTestEngine(t, doc)
+
+	search := func(text string) int64 {
+		t.Helper()
+		res, err := engine.Search(&Query{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Total
+	}
+
+	// 重新索引会替换旧的检索词
+	if err := engine.Index(&Document{ID: doc.ID, Title: "mysql"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("redis"); got != 0 {
+		t.Errorf("search old term after reindex = %d, want 0", got)
+	}
+	if got := search("mysql"); got != 1 {
+		t.Errorf("search new term after reindex = %d, want 1", got)
+	}
+
+	if err := engine.Delete(doc.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("mysql"); got != 0 {
+		t.Errorf("search after delete = %d, want 0", got)
+	}
+
+	if err := engine.Index(doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("redis"); got != 0 {
+		t.Errorf("search after reset = %d, want 0", got)
+	}
+}
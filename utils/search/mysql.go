@@ -0,0 +1,82 @@
+package search
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MySQL 全文索引名称
+const mysqlFulltextIndex = "idx_articles_fulltext"
+
+type mysqlEngine struct {
+	db      *gorm.DB
+	visible func(now time.Time) func(*gorm.DB) *gorm.DB
+}
+
+// NewMySQLEngine 创建基于 MySQL FULLTEXT（ngram 解析器）的搜索引擎。
+// MySQL 自动维护索引，Index、Delete 不需要做任何事；visible 用于过滤公开文章。
+func NewMySQLEngine(db *gorm.DB, visible func(now time.Time) func(*gorm.DB) *gorm.DB) (Engine, error) {
+	var count int64
+	if err := db.Raw(
+		"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+		"articles", mysqlFulltextIndex,
+	).Scan(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		if err := db.Exec("ALTER TABLE articles ADD FULLTEXT INDEX " + mysqlFulltextIndex + " (title, description, content) WITH PARSER ngram").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &mysqlEngine{db: db, visible: visible}, nil
+}
+
+func (e *mysqlEngine) Index(doc *Document) error {
+	return nil
+}
+
+func (e *mysqlEngine) Delete(id uuid.UUID) error {
+	return nil
+}
+
+func (e *mysqlEngine) Reset() error {
+	return nil
+}
+
+func (e *mysqlEngine) Search(query *Query) (*Result, error) {
+	const match = "MATCH(title, description, content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	model := e.db.Table("articles").Where("deleted_at IS NULL").Where(match, query.Text)
+
+	if query.CategoryID != nil {
+		model = model.Where("category_id = ?", *query.CategoryID)
+	}
+	if query.TagID != nil {
+		model = model.Where("id IN (SELECT article_id FROM article_tags WHERE tag_id = ?)", *query.TagID)
+	}
+	if query.Status != nil {
+		model = model.Where("status = ?", *query.Status)
+	}
+	if query.VisibleAt != nil {
+		model = model.Scopes(e.visible(*query.VisibleAt))
+	}
+
+	var total int64
+	if err := model.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0)
+	if err := model.Select("id, "+match+" AS score", query.Text).
+		Order("score DESC, created_at DESC").
+		Offset(query.Offset).Limit(query.Limit).
+		Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	return &Result{Total: total, Hits: hits}, nil
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 词典分词时单个词的最大长度（字符数）
+const maxWordLength = 8
+
+// Tokenizer 分词器
+//
+// 英文、数字按单词切分并转为小写；中文在加载词典时使用正向最大匹配分词，
+// 词典中不存在的片段以及未加载词典时按二元切分，保证任意子串都能被检索到。
+type Tokenizer struct {
+	dict    map[string]struct{}
+	maxWord int
+}
+
+// NewTokenizer 创建分词器，dictPath 为空时只使用二元切分。
+// 词典每行一个词，兼容 jieba 格式（词 词频 词性），只读取第一列。
+func NewTokenizer(dictPath string) (*Tokenizer, error) {
+	t := &Tokenizer{dict: make(map[string]struct{})}
+	if dictPath == "" {
+		return t, nil
+	}
+
+	file, err := os.Open(dictPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		word := strings.ToLower(fields[0])
+		length := utf8.RuneCountInString(word)
+		if length < 2 || length > maxWordLength {
+			continue
+		}
+
+		t.dict[word] = struct{}{}
+		t.maxWord = max(t.maxWord, length)
+	}
+
+	return t, scanner.Err()
+}
+
+// Tokenize 将文本切分为检索词
+func (t *Tokenizer) Tokenize(text string) []string {
+	tokens := make([]string, 0)
+
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) > 0 {
+			tokens = append(tokens, t.segment(han)...)
+			han = han[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return tokens
+}
+
+// Terms 返回去重后的检索词，保持首次出现的顺序
+func (t *Tokenizer) Terms(text string) []string {
+	seen := make(map[string]struct{})
+	terms := make([]string, 0)
+	for _, token := range t.Tokenize(text) {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+// segment 对连续的中文字符分词
+func (t *Tokenizer) segment(runes []rune) []string {
+	if len(runes) == 1 {
+		return []string{string(runes)}
+	}
+
+	if len(t.dict) == 0 {
+		return bigrams(runes)
+	}
+
+	tokens := make([]string, 0)
+	// 词典中不存在的连续片段
+	var rest []rune
+	flushRest := func() {
+		if len(rest) > 0 {
+			if len(rest) == 1 {
+				tokens = append(tokens, string(rest))
+			} else {
+				tokens = append(tokens, bigrams(rest)...)
+			}
+			rest = rest[:0]
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		matched := 0
+		for n := min(t.maxWord, len(runes)-i); n >= 2; n-- {
+			if _, ok := t.dict[string(runes[i:i+n])]; ok {
+				matched = n
+				break
+			}
+		}
+
+		if matched == 0 {
+			rest = append(rest, runes[i])
+			i++
+			continue
+		}
+
+		flushRest()
+		tokens = append(tokens, string(runes[i:i+matched]))
+		i += matched
+	}
+	flushRest()
+
+	return tokens
+}
+
+// bigrams 二元切分
+func bigrams(runes []rune) []string {
+	tokens := make([]string, 0, len(runes)-1)
+	for i := 0; i+1 < len(runes); i++ {
+		tokens = append(tokens, string(runes[i:i+2]))
+	}
+	return tokens
+}
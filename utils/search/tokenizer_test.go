@@ -0,0 +1,83 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestTokenizer(t *testing.T, words ...string) *Tokenizer {
+	t.Helper()
+	if len(words) == 0 {
+		tokenizer, err := NewTokenizer("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenizer
+	}
+
+	path := filepath.Join(t.TempDir(), "dict.txt")
+	content := ""
+	for _, word := range words {
+		// jieba 格式：词 词频 词性
+		content += word + " 100 n\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer, err := NewTokenizer(path)
+	if err != nil {
+		t.Fatalf("NewTokenizer error: %v", err)
+	}
+	return tokenizer
+}
+
+func TestTokenize(t *testing.T) {
+	bigram := newTestTokenizer(t)
+	dict := newTestTokenizer(t, "内容管理", "管理系统", "系统", "搜索引擎", "Go语言", "超过八个字的词语不会加载")
+
+	tests := []struct {
+		name      string
+		tokenizer *Tokenizer
+		text      string
+		want      []string
+	}{
+		{"english lower case", bigram, "Hello, World! GoLang 1.24", []string{"hello", "world", "golang", "1", "24"}},
+		{"empty", bigram, " ,.!? ", []string{}},
+		{"single han", bigram, "中", []string{"中"}},
+		{"han bigrams", bigram, "内容管理", []string{"内容", "容管", "管理"}},
+		{"mixed", bigram, "Go语言入门", []string{"go", "语言", "言入", "入门"}},
+		{"dictionary longest match", dict, "内容管理系统", []string{"内容管理", "系统"}},
+		{"dictionary with unknown rest", dict, "全文搜索引擎", []string{"全文", "搜索引擎"}},
+		{"dictionary single unknown", dict, "新搜索引擎", []string{"新", "搜索引擎"}},
+		{"dictionary without match", dict, "标签云", []string{"标签", "签云"}},
+		{"word over max length ignored", dict, "超过八个", []string{"超过", "过八", "八个"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.tokenizer.Tokenize(tt.text)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tokenizer := newTestTokenizer(t)
+
+	got := tokenizer.Terms("Go go GO 语言 语言")
+	want := []string{"go", "语言"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Terms = %q, want %q", got, want)
+	}
+}
+
+func TestNewTokenizerMissingDict(t *testing.T) {
+	if _, err := NewTokenizer(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("NewTokenizer with missing dictionary: want error")
+	}
+}
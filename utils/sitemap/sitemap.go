@@ -0,0 +1,86 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type (
+	// URL sitemap 中的一条记录
+	URL struct {
+		Loc        string
+		LastMod    time.Time
+		ChangeFreq string
+		Priority   float64
+	}
+
+	urlSet struct {
+		XMLName xml.Name `xml:"urlset"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		URLs    []xmlURL `xml:"url"`
+	}
+
+	xmlURL struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod,omitempty"`
+		ChangeFreq string `xml:"changefreq,omitempty"`
+		Priority   string `xml:"priority,omitempty"`
+	}
+
+	sitemapIndex struct {
+		XMLName  xml.Name     `xml:"sitemapindex"`
+		Xmlns    string       `xml:"xmlns,attr"`
+		Sitemaps []xmlSitemap `xml:"sitemap"`
+	}
+
+	xmlSitemap struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+
+	// IndexEntry sitemap 索引中的一个子文件
+	IndexEntry struct {
+		Loc     string
+		LastMod time.Time
+	}
+)
+
+// URLSet 输出 urlset 文档
+func URLSet(urls []URL) ([]byte, error) {
+	set := urlSet{Xmlns: xmlns, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		entry := xmlURL{Loc: u.Loc, LastMod: formatTime(u.LastMod), ChangeFreq: u.ChangeFreq}
+		if u.Priority > 0 {
+			entry.Priority = strconv.FormatFloat(min(u.Priority, 1), 'f', 1, 64)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	return marshal(set)
+}
+
+// Index 输出 sitemapindex 文档
+func Index(entries []IndexEntry) ([]byte, error) {
+	index := sitemapIndex{Xmlns: xmlns, Sitemaps: make([]xmlSitemap, 0, len(entries))}
+	for _, e := range entries {
+		index.Sitemaps = append(index.Sitemaps, xmlSitemap{Loc: e.Loc, LastMod: formatTime(e.LastMod)})
+	}
+	return marshal(index)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func marshal(v any) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
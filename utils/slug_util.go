@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// slug 最大长度
+const maxSlugLength = 80
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Slugify 将文本转换为 URL 友好的 slug，中文转换为不带声调的拼音
+func Slugify(s string) string {
+	words := make([]string, 0)
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.LazyPinyin(string(r), pinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := strings.Join(words, "-")
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		// 在单词边界截断
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"english", "Hello World", "hello-world"},
+		{"punctuation and spaces", "  Go 1.24: What's New?  ", "go-1-24-what-s-new"},
+		{"chinese pinyin without tones", "你好世界", "ni-hao-shi-jie"},
+		{"mixed", "Go语言入门", "go-yu-yan-ru-men"},
+		{"polyphonic character", "重庆", "zhong-qing"},
+		{"full width punctuation", "内容管理，系统！", "nei-rong-guan-li-xi-tong"},
+		{"non ascii letters dropped", "Café déjà vu", "caf-d-j-vu"},
+		{"emoji dropped", "🚀 launch", "launch"},
+		{"empty", "", ""},
+		{"only symbols", "!@#$%", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"english words", strings.Repeat("abcdefg ", 20)},
+		{"chinese", strings.Repeat("中文标题", 20)},
+		{"single long word", strings.Repeat("a", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slugify(tt.in)
+			if len(got) > maxSlugLength {
+				t.Errorf("len(Slugify) = %d, want <= %d", len(got), maxSlugLength)
+			}
+			// 在单词边界截断，不以连字符结尾
+			if strings.HasSuffix(got, "-") {
+				t.Errorf("Slugify = %q, ends with '-'", got)
+			}
+		})
+	}
+
+	if got := Slugify(strings.Repeat("abcdefg ", 20)); got != strings.TrimSuffix(strings.Repeat("abcdefg-", 10), "-") {
+		t.Errorf("Slugify = %q, want 10 whole words", got)
+	}
+	if got := Slugify(strings.Repeat("a", 100)); got != strings.Repeat("a", maxSlugLength) {
+		t.Errorf("Slugify = %q, want first %d characters", got, maxSlugLength)
+	}
+}
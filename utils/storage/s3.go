@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 签名链接的最长有效期
+	s3MaxPresignTTL = 7 * 24 * time.Hour
+	// 上传、删除和查询单个对象的超时；下载的内容按流读取，只限制等待响应头的时间
+	s3RequestTimeout = time.Minute
+)
+
+var (
+	// ErrS3Request S3 返回错误
+	ErrS3Request = errors.New("对象存储请求失败")
+)
+
+type (
+	// S3Options S3 兼容存储的连接参数
+	S3Options struct {
+		// 如 https://s3.amazonaws.com 或 http://127.0.0.1:9001
+		Endpoint  string
+		Region    string
+		Bucket    string
+		AccessKey string
+		SecretKey string
+		// 对象名前缀，如 uploads/
+		Prefix string
+		// 使用 endpoint/bucket/key 形式的地址，MinIO 等自建服务通常需要开启
+		PathStyle bool
+		// 为空时使用限制连接和等待响应头时间的默认客户端，不限制读取内容的总时间
+		Client *http.Client
+	}
+
+	s3Storage struct {
+		endpoint  *url.URL
+		bucket    string
+		prefix    string
+		pathStyle bool
+		signer    SigV4
+		client    *http.Client
+	}
+
+	// s3Error S3 返回的错误信息
+	s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+)
+
+// NewS3Storage 使用 Signature Version 4 签名访问 S3 兼容存储，不检查存储桶是否存在
+func NewS3Storage(opts S3Options) (Storage, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" || opts.Bucket == "" {
+		return nil, fmt.Errorf("%w: endpoint 或 bucket 为空", ErrS3Request)
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/")
+
+	client := opts.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = 10 * time.Second
+		transport.ResponseHeaderTimeout = 30 * time.Second
+		client = &http.Client{Transport: transport}
+	}
+
+	region := opts.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Storage{
+		endpoint:  endpoint,
+		bucket:    opts.Bucket,
+		prefix:    opts.Prefix,
+		pathStyle: opts.PathStyle,
+		signer:    SigV4{AccessKey: opts.AccessKey, SecretKey: opts.SecretKey, Region: region},
+		client:    client,
+	}, nil
+}
+
+// objectURL 对象地址，路径按 SigV4 的规则编码
+func (s *s3Storage) objectURL(key string) (*url.URL, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return nil, ErrInvalidKey
+	}
+
+	u := *s.endpoint
+	objectPath := u.Path + "/" + s.prefix + key
+	if s.pathStyle {
+		objectPath = u.Path + "/" + s.bucket + "/" + s.prefix + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = objectPath
+	u.RawPath = URIEncode(objectPath, false)
+	return &u, nil
+}
+
+func (s *s3Storage) do(ctx context.Context, method, key string, body []byte, header http.Header) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	s.signer.signRequest(req, HashHex(body), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var e s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(data, &e)
+	// HEAD 请求没有响应体，存储桶不存在等其他 404 不作为文件不存在处理
+	if resp.StatusCode == http.StatusNotFound && (e.Code == "" || e.Code == "NoSuchKey") {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrS3Request, resp.Status, e.Code, e.Message)
+}
+
+// Put S3 需要对内容签名，文件会先读入内存
+func (s *s3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("%w: 文件大小不一致", ErrS3Request)
+	}
+
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodPut, key, data, header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Get 返回的内容由调用方读取，读取时间不受超时限制
+func (s *s3Storage) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(context.Background(), http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Storage) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) Stat(key string) (*ObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &ObjectInfo{ContentType: resp.Header.Get("Content-Type")}
+	info.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	info.ModTime, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return info, nil
+}
+
+func (s *s3Storage) SignedURL(key string, ttl time.Duration, filename string) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	ttl = min(max(ttl, time.Second), s3MaxPresignTTL)
+
+	if filename != "" {
+		query := u.Query()
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		u.RawQuery = query.Encode()
+	}
+	return s.signer.presign(u, ttl, time.Now()), nil
+}
+
+func (s *s3Storage) Driver() string {
+	return DriverS3
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 相关常量
+const (
+	SigV4Algorithm  = "AWS4-HMAC-SHA256"
+	SigV4TimeFormat = "20060102T150405Z"
+	// UnsignedPayload 签名链接不对内容签名
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	sigV4DateFormat = "20060102"
+	sigV4Service    = "s3"
+)
+
+// SigV4 S3 请求签名，cmd/mocks3 也用它校验请求
+type SigV4 struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+}
+
+// Scope 签名范围：日期/区域/s3/aws4_request
+func (s SigV4) Scope(t time.Time) string {
+	return t.UTC().Format(sigV4DateFormat) + "/" + s.Region + "/" + sigV4Service + "/aws4_request"
+}
+
+// Signature 计算签名。path 为编码后的路径；query 不包含 X-Amz-Signature；
+// header 中需要有 signedHeaders 列出的全部请求头，Host 也放在其中
+func (s SigV4) Signature(method, path string, query url.Values, header http.Header, signedHeaders []string, payloadHash string, t time.Time) string {
+	var canonical strings.Builder
+	canonical.WriteString(method + "\n")
+	canonical.WriteString(path + "\n")
+	canonical.WriteString(canonicalQuery(query) + "\n")
+	for _, name := range signedHeaders {
+		values := slices.Clone(header.Values(name))
+		for i, v := range values {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		canonical.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+	canonical.WriteString("\n" + strings.Join(signedHeaders, ";") + "\n")
+	canonical.WriteString(payloadHash)
+
+	stringToSign := SigV4Algorithm + "\n" +
+		t.UTC().Format(SigV4TimeFormat) + "\n" +
+		s.Scope(t) + "\n" +
+		HashHex([]byte(canonical.String()))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), t.UTC().Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, sigV4Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// signRequest 为请求添加 Authorization 等请求头
+func (s SigV4) signRequest(req *http.Request, payloadHash string, t time.Time) {
+	req.Header.Set("X-Amz-Date", t.UTC().Format(SigV4TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	header := req.Header.Clone()
+	header.Set("Host", req.URL.Host)
+	signed := []string{"host"}
+	for name := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			signed = append(signed, name)
+		}
+	}
+	slices.Sort(signed)
+
+	signature := s.Signature(req.Method, req.URL.EscapedPath(), req.URL.Query(), header, signed, payloadHash, t)
+	req.Header.Set("Authorization", SigV4Algorithm+
+		" Credential="+s.AccessKey+"/"+s.Scope(t)+
+		", SignedHeaders="+strings.Join(signed, ";")+
+		", Signature="+signature)
+}
+
+// presign 为 GET 请求生成签名链接
+func (s SigV4) presign(u *url.URL, ttl time.Duration, t time.Time) string {
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", SigV4Algorithm)
+	query.Set("X-Amz-Credential", s.AccessKey+"/"+s.Scope(t))
+	query.Set("X-Amz-Date", t.UTC().Format(SigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	header := http.Header{"Host": {u.Host}}
+	signature := s.Signature(http.MethodGet, u.EscapedPath(), query, header, []string{"host"}, UnsignedPayload, t)
+
+	signed := *u
+	signed.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := slices.Clone(query[k])
+		slices.Sort(values)
+		for _, v := range values {
+			parts = append(parts, URIEncode(k, true)+"="+URIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// URIEncode 按 SigV4 的规则编码，只保留字母、数字和 -._~，encodeSlash 为 false 时保留 /
+func URIEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+// HashHex 内容的 SHA-256，用于 X-Amz-Content-Sha256
+func HashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"cms/config"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// DriverLocal 本地文件系统
+	DriverLocal = "local"
+	// DriverS3 S3 兼容的对象存储，如 AWS S3、MinIO
+	DriverS3 = "s3"
+)
+
+var (
+	// ErrUnknownDriver 未知的存储驱动
+	ErrUnknownDriver = errors.New("未知的存储驱动")
+	// ErrNotFound 文件不存在
+	ErrNotFound = errors.New("文件不存在")
+	// ErrInvalidKey 文件名为空或包含 .. 等路径
+	ErrInvalidKey = errors.New("文件名不合法")
+	// ErrSignedURLUnsupported 存储不支持生成签名链接，只能通过 Get 读取
+	ErrSignedURLUnsupported = errors.New("存储不支持签名链接")
+)
+
+type (
+	// Storage 上传文件的存储接口，key 为相对路径，如图片哈希
+	Storage interface {
+		// Put 保存文件，已存在时覆盖
+		Put(key string, r io.Reader, size int64, contentType string) error
+		// Get 读取文件，调用方负责关闭，不存在时返回 ErrNotFound
+		Get(key string) (io.ReadCloser, error)
+		// Delete 删除文件，不存在时不返回错误
+		Delete(key string) error
+		// Stat 获取文件信息，不存在时返回 ErrNotFound
+		Stat(key string) (*ObjectInfo, error)
+		// SignedURL 生成有效期为 ttl 的下载链接，filename 不为空时浏览器按该文件名下载
+		SignedURL(key string, ttl time.Duration, filename string) (string, error)
+		// Driver 存储驱动名称
+		Driver() string
+	}
+
+	// ObjectInfo 文件信息
+	ObjectInfo struct {
+		Size    int64
+		ModTime time.Time
+		// 本地存储不记录类型，为空
+		ContentType string
+	}
+)
+
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		return NewLocalStorage(cfg.LocalPath)
+	case DriverS3:
+		return NewS3Storage(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Prefix:    cfg.S3Prefix,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，原文为 8 位，这里取后 6 位
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error: %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestCodeSecretFormat(t *testing.T) {
+	want, err := Code(rfc6238Secret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 验证器应用中复制的密钥可能是小写或带空白
+	got, err := Code(" "+strings.ToLower(rfc6238Secret)+"\n", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Code = %s, want %s", got, want)
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with invalid secret: want error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current", code(step), 0, step, true},
+		{"with spaces", code(step)[:3] + " " + code(step)[3:], 0, step, true},
+		{"previous within skew", code(step - 1), 1, step - 1, true},
+		{"next within skew", code(step + 1), 1, step + 1, true},
+		{"previous without skew", code(step - 1), 0, 0, false},
+		{"outside skew", code(step - 2), 1, 0, false},
+		{"wrong length", code(step)[:5], 1, 0, false},
+		{"empty", "", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate(%q) = (%d, %v), want (%d, %v)", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret returned invalid base32 %q: %v", secret, err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("secret length = %d, want %d", len(key), secretSize)
+	}
+}